@@ -0,0 +1,321 @@
+// Package gettext reads and writes GNU gettext PO and POT files.
+package gettext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Entry is one message of a PO file.
+type Entry struct {
+	TranslatorComments []string // "# " lines
+	ExtractedComments  []string // "#." lines, notes for the translator from the developer
+	References         []string // "#:" lines, e.g., "src/checkout.php:42"
+	Flags              []string // "#," lines, e.g., "fuzzy" or "php-format"
+	Context            string   // msgctxt, empty when the entry has none
+	ID                 string   // msgid, the source text
+	IDPlural           string   // msgid_plural, empty for entries without plural forms
+	Str                []string // msgstr, or msgstr[0], msgstr[1], ... for plural entries
+	Obsolete           bool     // "#~" entries kept by msgmerge for messages no longer in use
+}
+
+// Fuzzy reports whether the translation of e still has to be reviewed.
+func (e Entry) Fuzzy() bool {
+	for _, f := range e.Flags {
+		if f == "fuzzy" {
+			return true
+		}
+	}
+	return false
+}
+
+// Translated reports whether every msgstr of e is filled in.
+func (e Entry) Translated() bool {
+	if len(e.Str) == 0 {
+		return false
+	}
+	for _, s := range e.Str {
+		if s == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Header is one "Name: Value" line of the header entry.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// File is a PO or POT file. The header entry, the one with an empty msgid, is kept in Headers
+// rather than in Entries.
+type File struct {
+	Headers []Header
+	Entries []Entry
+}
+
+// Header returns the value of the header with the given name, which is case-insensitive.
+func (f File) Header(name string) string {
+	for _, h := range f.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// Write renders f in PO syntax.
+func Write(f File) []byte {
+	var b bytes.Buffer
+	if len(f.Headers) > 0 {
+		var h strings.Builder
+		for _, header := range f.Headers {
+			h.WriteString(header.Name + ": " + header.Value + "\n")
+		}
+		writeEntry(&b, Entry{Str: []string{h.String()}})
+	}
+	for _, e := range f.Entries {
+		b.WriteByte('\n')
+		writeEntry(&b, e)
+	}
+	return b.Bytes()
+}
+
+func writeEntry(b *bytes.Buffer, e Entry) {
+	for _, c := range e.TranslatorComments {
+		b.WriteString(strings.TrimRight("# "+c, " ") + "\n")
+	}
+	for _, c := range e.ExtractedComments {
+		b.WriteString("#. " + c + "\n")
+	}
+	for _, r := range e.References {
+		b.WriteString("#: " + r + "\n")
+	}
+	if len(e.Flags) > 0 {
+		b.WriteString("#, " + strings.Join(e.Flags, ", ") + "\n")
+	}
+
+	prefix := ""
+	if e.Obsolete {
+		prefix = "#~ "
+	}
+	if e.Context != "" {
+		writeString(b, prefix, "msgctxt", e.Context)
+	}
+	writeString(b, prefix, "msgid", e.ID)
+	if e.IDPlural == "" {
+		str := ""
+		if len(e.Str) > 0 {
+			str = e.Str[0]
+		}
+		writeString(b, prefix, "msgstr", str)
+		return
+	}
+	writeString(b, prefix, "msgid_plural", e.IDPlural)
+	for i, s := range e.Str {
+		writeString(b, prefix, "msgstr["+strconv.Itoa(i)+"]", s)
+	}
+}
+
+// writeString writes a keyword and its quoted string, breaking the string after each newline
+// the way xgettext does.
+func writeString(b *bytes.Buffer, prefix, keyword, s string) {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		b.WriteString(prefix + keyword + " " + quote(s) + "\n")
+		return
+	}
+	b.WriteString(prefix + keyword + " \"\"\n")
+	for _, line := range lines {
+		b.WriteString(prefix + quote(line) + "\n")
+	}
+}
+
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Parse reads a PO or POT file. Entries are separated by blank lines or start with their
+// comments or msgctxt/msgid; previous-msgid ("#|") comments are dropped.
+func Parse(data []byte) (File, error) {
+	p := parser{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		p.line++
+		if err := p.parseLine(strings.TrimSpace(scanner.Text())); err != nil {
+			return File{}, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return File{}, fmt.Errorf("gettext: %w", err)
+	}
+	p.flush()
+	return p.file, nil
+}
+
+type parser struct {
+	file    File
+	line    int
+	entry   Entry
+	started bool    // entry has any content
+	hasStr  bool    // entry has a msgstr, so the next comment or msgid starts a new one
+	target  *string // string continuation lines append to
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("gettext: line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) flush() {
+	if p.started {
+		e := p.entry
+		if e.ID == "" && e.Context == "" && !e.Obsolete {
+			p.file.Headers = parseHeaders(strings.Join(e.Str, ""))
+		} else {
+			p.file.Entries = append(p.file.Entries, e)
+		}
+	}
+	p.entry, p.started, p.hasStr, p.target = Entry{}, false, false, nil
+}
+
+func (p *parser) parseLine(line string) error {
+	if line == "" {
+		p.flush()
+		return nil
+	}
+	obsolete := false
+	if strings.HasPrefix(line, "#~") {
+		obsolete = true
+		line = strings.TrimSpace(line[2:])
+		if line == "" {
+			return nil
+		}
+	}
+
+	if line[0] == '#' {
+		if p.hasStr {
+			p.flush()
+		}
+		p.started = true
+		text := ""
+		if len(line) > 2 {
+			text = strings.TrimSpace(line[2:])
+		}
+		switch {
+		case strings.HasPrefix(line, "#."):
+			p.entry.ExtractedComments = append(p.entry.ExtractedComments, text)
+		case strings.HasPrefix(line, "#:"):
+			p.entry.References = append(p.entry.References, strings.Fields(text)...)
+		case strings.HasPrefix(line, "#,"):
+			for _, f := range strings.Split(text, ",") {
+				if f = strings.TrimSpace(f); f != "" {
+					p.entry.Flags = append(p.entry.Flags, f)
+				}
+			}
+		case strings.HasPrefix(line, "#|"):
+		default:
+			p.entry.TranslatorComments = append(p.entry.TranslatorComments, strings.TrimSpace(line[1:]))
+		}
+		return nil
+	}
+
+	if line[0] == '"' {
+		if p.target == nil {
+			return p.errorf("string without a keyword")
+		}
+		s, err := unquote(line)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		*p.target += s
+		return nil
+	}
+
+	keyword, rest, _ := strings.Cut(line, " ")
+	s, err := unquote(strings.TrimSpace(rest))
+	if err != nil {
+		return p.errorf("%v", err)
+	}
+	if (keyword == "msgctxt" || keyword == "msgid") && p.hasStr {
+		p.flush()
+	}
+	p.started = true
+	p.entry.Obsolete = p.entry.Obsolete || obsolete
+
+	switch {
+	case keyword == "msgctxt":
+		p.entry.Context = s
+		p.target = &p.entry.Context
+	case keyword == "msgid":
+		p.entry.ID = s
+		p.target = &p.entry.ID
+	case keyword == "msgid_plural":
+		p.entry.IDPlural = s
+		p.target = &p.entry.IDPlural
+	case keyword == "msgstr":
+		p.entry.Str = []string{s}
+		p.target = &p.entry.Str[0]
+		p.hasStr = true
+	case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+		i, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+		if err != nil || i != len(p.entry.Str) {
+			return p.errorf("unexpected %s", keyword)
+		}
+		p.entry.Str = append(p.entry.Str, s)
+		p.target = &p.entry.Str[i]
+		p.hasStr = true
+	default:
+		return p.errorf("unknown keyword %q", keyword)
+	}
+	return nil
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected a quoted string, got %q", s)
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s)-1 {
+			return "", fmt.Errorf("unterminated escape in %q", s)
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+func parseHeaders(s string) []Header {
+	var out []Header
+	for _, line := range strings.Split(s, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		out = append(out, Header{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	return out
+}
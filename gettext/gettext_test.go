@@ -0,0 +1,174 @@
+package gettext
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	data := Write(File{
+		Headers: []Header{
+			{Name: "Language", Value: "de_DE"},
+			{Name: "Content-Type", Value: "text/plain; charset=UTF-8"},
+		},
+		Entries: []Entry{
+			{
+				TranslatorComments: []string{"Is this a verb?"},
+				ExtractedComments:  []string{"Button of the form"},
+				References:         []string{"src/form.php"},
+				Flags:              []string{"fuzzy"},
+				Context:            "verb",
+				ID:                 "Post",
+				Str:                []string{"Senden"},
+			},
+			{
+				ID:       "One \"file\"",
+				IDPlural: "# files",
+				Str:      []string{"Eine Datei", "# Dateien"},
+			},
+			{ID: "Line one\nLine two", Str: []string{""}},
+		},
+	})
+
+	want := `msgid ""
+msgstr ""
+"Language: de_DE\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+# Is this a verb?
+#. Button of the form
+#: src/form.php
+#, fuzzy
+msgctxt "verb"
+msgid "Post"
+msgstr "Senden"
+
+msgid "One \"file\""
+msgid_plural "# files"
+msgstr[0] "Eine Datei"
+msgstr[1] "# Dateien"
+
+msgid ""
+"Line one\n"
+"Line two"
+msgstr ""
+`
+	if string(data) != want {
+		t.Errorf("Write =\n%s\nwant\n%s", data, want)
+	}
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.Header("language") != "de_DE" || len(f.Entries) != 3 {
+		t.Fatalf("Parse = %+v", f)
+	}
+	if !f.Entries[0].Fuzzy() || f.Entries[1].Fuzzy() {
+		t.Errorf("Expected only the first entry to be fuzzy")
+	}
+	if f.Entries[2].ID != "Line one\nLine two" || f.Entries[2].Translated() {
+		t.Errorf("Unexpected multi-line entry %+v", f.Entries[2])
+	}
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(`# Header comment
+msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n>=2 && n<=4 ? 1 : 2);\n"
+#: a.c:1 b.c:2
+#, c-format, fuzzy
+#| msgid "Old"
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d soubor"
+msgstr[1] "%d soubory"
+msgstr[2] "%d souborů"
+msgctxt "menu"
+msgid "Open"
+msgstr "Otevřít\t\"soubor\""
+
+#~ msgid "Gone"
+#~ msgstr "Pryč"
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if n, err := NPlurals(f.Header("Plural-Forms")); err != nil || n != 3 {
+		t.Errorf("NPlurals = %d, %v", n, err)
+	}
+
+	want := []Entry{
+		{
+			References: []string{"a.c:1", "b.c:2"},
+			Flags:      []string{"c-format", "fuzzy"},
+			ID:         "%d file",
+			IDPlural:   "%d files",
+			Str:        []string{"%d soubor", "%d soubory", "%d souborů"},
+		},
+		{Context: "menu", ID: "Open", Str: []string{"Otevřít\t\"soubor\""}},
+		{ID: "Gone", Str: []string{"Pryč"}, Obsolete: true},
+	}
+	if !reflect.DeepEqual(f.Entries, want) {
+		t.Errorf("Parse entries =\n%+v\nwant\n%+v", f.Entries, want)
+	}
+
+	for _, bad := range []string{`msgid "unterminated`, `msgfoo "x"`, `"no keyword"`, "msgid \"a\"\nmsgid_plural \"b\"\nmsgstr[1] \"c\""} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPluralForms(t *testing.T) {
+	categories, header := PluralForms("de-DE")
+	if !reflect.DeepEqual(categories, []string{"one", "other"}) || header != "nplurals=2; plural=(n == 1) ? 0 : 1;" {
+		t.Errorf("PluralForms(de-DE) = %v, %q", categories, header)
+	}
+	for _, tt := range []struct {
+		locale   string
+		nplurals int
+		want     []string
+	}{
+		{"fr", 3, []string{"one", "many", "other"}},
+		{"fr", 2, []string{"one", "other"}},
+		{"es-ES", 2, []string{"one", "other"}},
+		{"pl", 3, []string{"one", "few", "many"}},
+	} {
+		if got, ok := FormCategories(tt.locale, tt.nplurals); !ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FormCategories(%s, %d) = %v, %v", tt.locale, tt.nplurals, got, ok)
+		}
+	}
+	if _, ok := FormCategories("pl", 2); ok {
+		t.Errorf("Expected 2 forms not to fit Polish")
+	}
+	if _, err := NPlurals("plural=0;"); err == nil {
+		t.Errorf("Expected missing nplurals to fail")
+	}
+}
+
+func TestSplitAndJoinPlural(t *testing.T) {
+	categories := []string{"one", "few", "other"}
+	msg := "{n, plural, one {# plik w {dir}} other {# plików '{'x'}'}}"
+	arg, forms, ok := SplitPlural(msg, categories)
+	if !ok || arg != "n" || !reflect.DeepEqual(forms, []string{"# plik w {dir}", "# plików '{'x'}'", "# plików '{'x'}'"}) {
+		t.Fatalf("SplitPlural = %q, %q, %v", arg, forms, ok)
+	}
+	if got := JoinPlural(arg, categories, forms[:2]); got != "{n, plural, one {# plik w {dir}} few {# plików '{'x'}'} other {# plików '{'x'}'}}" {
+		t.Errorf("JoinPlural = %q", got)
+	}
+
+	if got := JoinPlural("n", []string{"one", "few", "many"}, []string{"# plik", "# pliki", "# plików"}); got != "{n, plural, one {# plik} few {# pliki} many {# plików} other {# plików}}" {
+		t.Errorf("JoinPlural without other = %q", got)
+	}
+
+	if _, forms, _ := SplitPlural("{n, plural, one {# file isn''t here} other {# files aren't}}", []string{"one", "other"}); !reflect.DeepEqual(forms, []string{"# file isn't here", "# files aren't"}) {
+		t.Errorf("Expected plain apostrophes in the forms, got %q", forms)
+	}
+
+	for _, msg := range []string{"Hello", "{n, plural, =0 {none} other {#}}", "{n, selectordinal, other {#th}}", "{n, plural, other {#}} files"} {
+		if _, _, ok := SplitPlural(msg, categories); ok {
+			t.Errorf("SplitPlural(%q) should not split", msg)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package gettext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/icu"
+	"github.com/rah-0/meisterwerk/plural"
+)
+
+// PluralForms returns the categories whole numbers fall into for locale, in the order of the
+// msgstr[i] forms, and the matching Plural-Forms header, e.g.,
+// "nplurals=2; plural=(n == 1) ? 0 : 1;".
+func PluralForms(locale string) ([]string, string) {
+	categories, expr := plural.ForLocale(locale).IntegerExpression()
+	return categories, fmt.Sprintf("nplurals=%d; plural=%s;", len(categories), expr)
+}
+
+// FormCategories returns the categories of the msgstr[i] forms of a file for locale with nplurals
+// forms: those of PluralForms, or one per category whole numbers below one million fall into,
+// the layout of legacy files such as French ones with nplurals=2.
+func FormCategories(locale string, nplurals int) ([]string, bool) {
+	if categories, _ := PluralForms(locale); len(categories) == nplurals {
+		return categories, true
+	}
+	if categories := plural.ForLocale(locale).CountCategories(); len(categories) == nplurals {
+		return categories, true
+	}
+	return nil, false
+}
+
+// NPlurals reads the number of plural forms from a Plural-Forms header.
+func NPlurals(header string) (int, error) {
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if ok && strings.TrimSpace(name) == "nplurals" {
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("gettext: invalid nplurals in %q", header)
+			}
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("gettext: no nplurals in %q", header)
+}
+
+// SplitPlural returns the argument and the texts of the cases of msg, for each category in
+// categories, when msg is a single cardinal plural without offset or exact matches, as
+// gettext can express. Categories msg has no case for get the text of "other".
+func SplitPlural(msg string, categories []string) (string, []string, bool) {
+	nodes, err := icu.Parse(msg)
+	if err != nil || len(nodes) != 1 {
+		return "", nil, false
+	}
+	p, ok := nodes[0].(icu.Plural)
+	if !ok || p.Ordinal || p.Offset != 0 {
+		return "", nil, false
+	}
+	texts := make(map[string]string, len(p.Cases))
+	for _, c := range p.Cases {
+		if strings.HasPrefix(c.Key, "=") {
+			return "", nil, false
+		}
+		texts[c.Key] = icu.PrintCase(c.Message)
+	}
+	forms := make([]string, len(categories))
+	for i, category := range categories {
+		text, ok := texts[category]
+		if !ok {
+			text = texts[plural.Other]
+		}
+		forms[i] = text
+	}
+	return p.Name, forms, true
+}
+
+// JoinPlural is the reverse of SplitPlural: it builds a plural of arg with one case per
+// category. Forms missing at the end repeat the last one, which also serves as "other" when
+// only fractions fall into it, as in Polish.
+func JoinPlural(arg string, categories, forms []string) string {
+	text := func(i int) string {
+		if len(forms) == 0 {
+			return ""
+		}
+		return forms[min(i, len(forms)-1)]
+	}
+	var b strings.Builder
+	b.WriteString("{" + arg + ", plural,")
+	for i, category := range categories {
+		b.WriteString(" " + category + " {" + text(i) + "}")
+	}
+	if len(categories) == 0 || categories[len(categories)-1] != plural.Other {
+		b.WriteString(" " + plural.Other + " {" + text(len(categories)-1) + "}")
+	}
+	b.WriteString("}")
+	return b.String()
+}
@@ -2,14 +2,16 @@ module github.com/rah-0/meisterwerk
 
 go 1.24.1
 
-require github.com/google/uuid v1.6.0
+require (
+	github.com/google/uuid v1.6.0
+	github.com/nats-io/nats.go v1.40.1
+	github.com/rah-0/nabu v0.0.4
+)
 
 require (
 	github.com/klauspost/compress v1.18.0 // indirect
-	github.com/nats-io/nats.go v1.40.1 // indirect
 	github.com/nats-io/nkeys v0.4.9 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
-	github.com/rah-0/nabu v0.0.4 // indirect
 	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 )
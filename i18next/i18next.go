@@ -0,0 +1,255 @@
+// Package i18next converts between ICU MessageFormat values and i18next JSON (v4) files.
+package i18next
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/icu"
+)
+
+// countArg is the argument i18next selects plural forms by.
+const countArg = "count"
+
+var (
+	pluralSuffix  = regexp.MustCompile(`^(.+?)_(ordinal_)?(zero|one|two|few|many|other)$`)
+	interpolation = regexp.MustCompile(`\{\{-?\s*([^{},\s]+)\s*(?:,[^{}]*)?\}\}`)
+)
+
+// Export writes values, by dotted key, as nested i18next JSON. A value that is a single plural
+// of {count} becomes one key per category with an i18next plural suffix, e.g., "files_one" and
+// "files_other". Values using ICU features i18next has no syntax for are written unchanged. A
+// key below one that holds a text is written flat next to it, e.g., "checkout.pay" next to
+// "checkout".
+func Export(values map[string]string) ([]byte, error) {
+	texts := make(map[string]string, len(values))
+	for k, msg := range values {
+		for name, text := range exportValue(k, msg) {
+			texts[name] = text
+		}
+	}
+	names := make([]string, 0, len(texts))
+	for name := range texts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	root := make(map[string]any)
+	for _, name := range names {
+		set(root, name, texts[name])
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false) // values often carry markup such as <b>
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// exportValue returns the i18next keys and texts for one value.
+func exportValue(key, msg string) map[string]string {
+	nodes, err := icu.Parse(msg)
+	if err != nil {
+		return map[string]string{key: msg}
+	}
+
+	if len(nodes) == 1 {
+		if p, ok := nodes[0].(icu.Plural); ok && p.Name == countArg && p.Offset == 0 {
+			out := make(map[string]string, len(p.Cases))
+			for _, c := range p.Cases {
+				text, ok := interpolate(c.Message)
+				if !ok || strings.HasPrefix(c.Key, "=") {
+					return map[string]string{key: msg}
+				}
+				suffix := "_" + c.Key
+				if p.Ordinal {
+					suffix = "_ordinal" + suffix
+				}
+				out[key+suffix] = text
+			}
+			return out
+		}
+	}
+
+	if text, ok := interpolate(nodes); ok {
+		return map[string]string{key: text}
+	}
+	return map[string]string{key: msg}
+}
+
+// interpolate renders nodes with i18next {{name}} placeholders, reporting false when they use
+// anything else than text and untyped arguments.
+func interpolate(nodes []icu.Node) (string, bool) {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case icu.Text:
+			b.WriteString(n.Value)
+		case icu.Argument:
+			if n.Type != "" {
+				return "", false
+			}
+			b.WriteString("{{" + n.Name + "}}")
+		case icu.Pound:
+			b.WriteString("{{" + countArg + "}}")
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// set stores text at the dotted key, creating the objects on the way. Below a segment that
+// holds a text, the rest of the key is written flat, which i18next looks up as well. Keys must
+// be set in sorted order, so that a text is set before the keys below it.
+func set(root map[string]any, key, text string) {
+	segments := strings.Split(key, ".")
+	node := root
+	for i, segment := range segments[:len(segments)-1] {
+		switch child := node[segment].(type) {
+		case nil:
+			next := make(map[string]any)
+			node[segment] = next
+			node = next
+		case map[string]any:
+			node = child
+		default:
+			node[strings.Join(segments[i:], ".")] = text
+			return
+		}
+	}
+	node[segments[len(segments)-1]] = text
+}
+
+// Import reads nested i18next JSON into ICU MessageFormat values by dotted key. Keys with
+// plural suffixes are joined into one plural of {count}, as long as an "_other" form exists.
+func Import(data []byte) (map[string]string, error) {
+	var root map[string]any
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("i18next: %w", err)
+	}
+	flat := make(map[string]string)
+	if err := flatten(root, "", flat); err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		ordinal bool
+		forms   map[string]string
+	}
+	groups := make(map[string]*group)
+	for k, text := range flat {
+		m := pluralSuffix.FindStringSubmatch(k)
+		if m == nil {
+			continue
+		}
+		g, ok := groups[m[1]]
+		if !ok {
+			g = &group{ordinal: m[2] != "", forms: make(map[string]string)}
+			groups[m[1]] = g
+		}
+		if g.ordinal != (m[2] != "") {
+			return nil, fmt.Errorf("i18next: key %q has both cardinal and ordinal forms", m[1])
+		}
+		g.forms[m[3]] = text
+	}
+
+	out := make(map[string]string, len(flat))
+	for k, text := range flat {
+		out[k] = message(text, false)
+	}
+	for base, g := range groups {
+		if _, ok := g.forms["other"]; !ok {
+			continue
+		}
+		if _, exists := flat[base]; exists {
+			return nil, fmt.Errorf("i18next: key %q has both plural forms and a text", base)
+		}
+
+		kind, suffix := "plural", "_"
+		if g.ordinal {
+			kind, suffix = "selectordinal", "_ordinal_"
+		}
+		var b strings.Builder
+		b.WriteString("{" + countArg + ", " + kind + ",")
+		for _, category := range icu.PluralCategories {
+			text, ok := g.forms[category]
+			if !ok {
+				continue
+			}
+			b.WriteString(" " + category + " {" + message(text, true) + "}")
+			delete(out, base+suffix+category)
+		}
+		b.WriteString("}")
+		out[base] = b.String()
+	}
+	return out, nil
+}
+
+func flatten(node map[string]any, prefix string, out map[string]string) error {
+	for k, v := range node {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case string:
+			out[key] = v
+		case map[string]any:
+			if err := flatten(v, key, out); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("i18next: unsupported value at key %q", key)
+		}
+	}
+	return nil
+}
+
+// message turns an i18next text into an ICU MessageFormat string. Inside a plural, {{count}}
+// becomes #. Texts already written in ICU syntax, as Export does for values i18next cannot
+// express, are kept.
+func message(text string, inPlural bool) string {
+	if !interpolation.MatchString(text) {
+		if nodes, err := icu.Parse(text); err == nil && usesICU(nodes) {
+			return text
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range interpolation.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(icu.Escape(text[last:m[0]], inPlural))
+		name := text[m[2]:m[3]]
+		if inPlural && name == countArg {
+			b.WriteString("#")
+		} else {
+			b.WriteString("{" + name + "}")
+		}
+		last = m[1]
+	}
+	b.WriteString(icu.Escape(text[last:], inPlural))
+	return b.String()
+}
+
+// usesICU reports whether nodes contain ICU syntax beyond {name} placeholders, which i18next
+// texts treat as literal.
+func usesICU(nodes []icu.Node) bool {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case icu.Plural, icu.Select:
+			return true
+		case icu.Argument:
+			if n.Type != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
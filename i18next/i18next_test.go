@@ -0,0 +1,124 @@
+package i18next
+
+import "testing"
+
+func TestExport(t *testing.T) {
+	data, err := Export(map[string]string{
+		"checkout.title":       "Checkout",
+		"checkout.greeting":    "Hello {name}, <b>welcome</b>",
+		"checkout.items":       "{count, plural, one {# item} other {# items}}",
+		"checkout.place":       "{count, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+		"checkout.gender":      "{g, select, female {She} other {They}}",
+		"checkout.exact":       "{count, plural, =0 {No items} other {# items}}",
+		"checkout.price.total": "Total",
+	})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	want := `{
+  "checkout": {
+    "exact": "{count, plural, =0 {No items} other {# items}}",
+    "gender": "{g, select, female {She} other {They}}",
+    "greeting": "Hello {{name}}, <b>welcome</b>",
+    "items_one": "{{count}} item",
+    "items_other": "{{count}} items",
+    "place_ordinal_few": "{{count}}rd",
+    "place_ordinal_one": "{{count}}st",
+    "place_ordinal_other": "{{count}}th",
+    "place_ordinal_two": "{{count}}nd",
+    "price": {
+      "total": "Total"
+    },
+    "title": "Checkout"
+  }
+}
+`
+	if string(data) != want {
+		t.Errorf("Export =\n%s\nwant\n%s", data, want)
+	}
+
+	data, err = Export(map[string]string{"shop.checkout": "Checkout", "shop.checkout.pay": "Pay", "shop.checkout.pay.card": "Card", "shop.cart": "Cart"})
+	want = `{
+  "shop": {
+    "cart": "Cart",
+    "checkout": "Checkout",
+    "checkout.pay": "Pay",
+    "checkout.pay.card": "Card"
+  }
+}
+`
+	if err != nil || string(data) != want {
+		t.Errorf("Expected keys below a text to be written flat, got\n%s\n(%v)", data, err)
+	}
+}
+
+func TestImport(t *testing.T) {
+	values, err := Import([]byte(`{
+		"checkout": {
+			"greeting": "Hello {{name}}, it's {{- html}} {literal}",
+			"items_one": "{{count}} item",
+			"items_other": "{{count}} items in {{cart}}",
+			"place_ordinal_one": "{{count}}st",
+			"place_ordinal_other": "{{count}}th",
+			"button_one": "Only a suffix",
+			"gender": "{g, select, female {She} other {They}}",
+			"price": {"total": "Total: {{amount, currency}}"}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"checkout.greeting":    "Hello {name}, it's {html} '{'literal'}'",
+		"checkout.items":       "{count, plural, one {# item} other {# items in {cart}}}",
+		"checkout.place":       "{count, selectordinal, one {#st} other {#th}}",
+		"checkout.button_one":  "Only a suffix",
+		"checkout.gender":      "{g, select, female {She} other {They}}",
+		"checkout.price.total": "Total: {amount}",
+	}
+	if len(values) != len(want) {
+		t.Errorf("Import returned %d values, want %d: %+v", len(values), len(want), values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("Import[%q] = %q, want %q", k, values[k], v)
+		}
+	}
+
+	invalid := []string{
+		`{"a": 1}`,
+		`{"a": ["x"]}`,
+		`{"a": "text", "a_one": "one", "a_other": "other"}`,
+		`{"a_one": "one", "a_ordinal_other": "other"}`,
+		`not json`,
+	}
+	for _, data := range invalid {
+		if _, err := Import([]byte(data)); err == nil {
+			t.Errorf("Expected Import(%s) to fail", data)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"a.plural": "{count, plural, one {# file in {dir}} other {# files in {dir}}}",
+		"a.select": "{g, select, female {She} other {They}}",
+		"b":        "It's '{'literal'}' {name}",
+		"b.c":      "Below b",
+	}
+	data, err := Export(values)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	got, err := Import(data)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	for k, v := range values {
+		if got[k] != v {
+			t.Errorf("Round trip of %q = %q, want %q", k, got[k], v)
+		}
+	}
+}
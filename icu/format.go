@@ -0,0 +1,203 @@
+package icu
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rah-0/meisterwerk/plural"
+)
+
+// Format renders msg for locale, substituting args by argument name. Numbers are written
+// without grouping separators and dates/times in fixed Go layouts; only plural selection
+// depends on locale, following its CLDR plural rules.
+func Format(msg, locale string, args map[string]any) (string, error) {
+	nodes, err := Parse(msg)
+	if err != nil {
+		return "", err
+	}
+
+	f := formatter{locale: locale, args: args}
+	var b strings.Builder
+	if err = f.nodes(&b, nodes, nil); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+type formatter struct {
+	locale string
+	args   map[string]any
+}
+
+func (f formatter) arg(name string) (any, error) {
+	v, ok := f.args[name]
+	if !ok {
+		return nil, fmt.Errorf("icu: missing argument %q", name)
+	}
+	return v, nil
+}
+
+// nodes writes each node; pound is the number # stands for, nil outside plural cases.
+func (f formatter) nodes(b *strings.Builder, nodes []Node, pound *float64) error {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case Text:
+			b.WriteString(n.Value)
+
+		case Pound:
+			if pound != nil {
+				b.WriteString(formatNumber(*pound, ""))
+			}
+
+		case Argument:
+			v, err := f.arg(n.Name)
+			if err != nil {
+				return err
+			}
+			b.WriteString(formatArgument(v, n.Type, n.Style))
+
+		case Select:
+			v, err := f.arg(n.Name)
+			if err != nil {
+				return err
+			}
+			c := selectCase(n.Cases, fmt.Sprint(v))
+			if err = f.nodes(b, c.Message, pound); err != nil {
+				return err
+			}
+
+		case Plural:
+			v, err := f.arg(n.Name)
+			if err != nil {
+				return err
+			}
+			num, ok := toFloat(v)
+			if !ok {
+				return fmt.Errorf("icu: argument %q is not a number", n.Name)
+			}
+			c := f.pluralCase(n, v, num)
+			rel := num - n.Offset
+			if err = f.nodes(b, c.Message, &rel); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pluralCase picks an exact "=N" match first, then the locale's plural category, then "other".
+func (f formatter) pluralCase(p Plural, v any, num float64) Case {
+	for _, c := range p.Cases {
+		if strings.HasPrefix(c.Key, "=") {
+			if exact, err := strconv.ParseFloat(c.Key[1:], 64); err == nil && exact == num {
+				return c
+			}
+		}
+	}
+
+	// A number passed as a string keeps its visible fraction digits, so "1.0" is not "one" in English.
+	op := plural.FloatOperands(num - p.Offset)
+	if s, ok := v.(string); ok && p.Offset == 0 {
+		if written, err := plural.NewOperands(s); err == nil {
+			op = written
+		}
+	}
+
+	rules := plural.ForLocale(f.locale)
+	if p.Ordinal {
+		return selectCase(p.Cases, rules.Ordinal(op))
+	}
+	return selectCase(p.Cases, rules.Cardinal(op))
+}
+
+func selectCase(cases []Case, key string) Case {
+	var other Case
+	for _, c := range cases {
+		if c.Key == key {
+			return c
+		}
+		if c.Key == "other" {
+			other = c
+		}
+	}
+	return other
+}
+
+func formatArgument(v any, typ, style string) string {
+	switch typ {
+	case "number", "spellout", "ordinal", "duration":
+		if num, ok := toFloat(v); ok {
+			return formatNumber(num, style)
+		}
+	case "date", "time":
+		if t, ok := v.(time.Time); ok {
+			return formatTime(t, typ, style)
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+func formatNumber(n float64, style string) string {
+	switch style {
+	case "integer":
+		return strconv.FormatFloat(math.Round(n), 'f', -1, 64)
+	case "percent":
+		return strconv.FormatFloat(math.Round(n*100), 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func formatTime(t time.Time, typ, style string) string {
+	if typ == "time" {
+		if style == "short" {
+			return t.Format("15:04")
+		}
+		return t.Format("15:04:05")
+	}
+
+	switch style {
+	case "short":
+		return t.Format("2006-01-02")
+	case "long":
+		return t.Format("January 2, 2006")
+	case "full":
+		return t.Format("Monday, January 2, 2006")
+	}
+	return t.Format("Jan 2, 2006")
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
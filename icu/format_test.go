@@ -0,0 +1,52 @@
+package icu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	when := time.Date(2025, time.March, 4, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		msg  string
+		args map[string]any
+		want string
+	}{
+		{"Hello {name}!", map[string]any{"name": "Ada"}, "Hello Ada!"},
+		{"It''s '{literal}'", nil, "It's {literal}"},
+		{"{count, plural, =0 {no items} one {# item} other {# items}}", map[string]any{"count": 0}, "no items"},
+		{"{count, plural, =0 {no items} one {# item} other {# items}}", map[string]any{"count": 1}, "1 item"},
+		{"{count, plural, =0 {no items} one {# item} other {# items}}", map[string]any{"count": 5}, "5 items"},
+		{"{count, plural, one {# item} other {# items}}", map[string]any{"count": "2.5"}, "2.5 items"},
+		{"{n, plural, offset:1 =0 {nobody} =1 {{name}} one {{name} and # other} other {{name} and # others}}", map[string]any{"n": 3, "name": "Ada"}, "Ada and 2 others"},
+		{"{n, plural, offset:1 =0 {nobody} =1 {{name}} one {{name} and # other} other {{name} and # others}}", map[string]any{"n": 2, "name": "Ada"}, "Ada and 1 other"},
+		{"{gender, select, female {She} male {He} other {They}} replied", map[string]any{"gender": "female"}, "She replied"},
+		{"{gender, select, female {She} male {He} other {They}} replied", map[string]any{"gender": "x"}, "They replied"},
+		{"{n, number, integer} / {r, number, percent}", map[string]any{"n": 2.6, "r": 0.25}, "3 / 25%"},
+		{"{d, date, short} {d, time, short}", map[string]any{"d": when}, "2025-03-04 17:30"},
+	}
+
+	for _, tt := range tests {
+		got, err := Format(tt.msg, "en-US", tt.args)
+		if err != nil {
+			t.Errorf("Format(%q) failed: %v", tt.msg, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestFormat_Errors(t *testing.T) {
+	if _, err := Format("Hello {name}", "en-US", nil); err == nil {
+		t.Error("expected error for missing argument")
+	}
+	if _, err := Format("{n, plural, other {#}}", "en-US", map[string]any{"n": "many"}); err == nil {
+		t.Error("expected error for non-numeric plural argument")
+	}
+	if _, err := Format("Hello {name", "en-US", map[string]any{"name": "Ada"}); err == nil {
+		t.Error("expected error for invalid message")
+	}
+}
@@ -0,0 +1,458 @@
+// Package icu parses, validates and formats ICU MessageFormat strings.
+package icu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node is one piece of a parsed message: Text, Argument, Plural, Select or Pound.
+type Node interface {
+	node()
+}
+
+// Text is literal text, with quoting already resolved.
+type Text struct {
+	Value string
+}
+
+// Argument is a simple placeholder such as {name} or {count, number, integer}.
+type Argument struct {
+	Name  string
+	Type  string // "", "number", "date", "time", ...
+	Style string // Optional style after the type, e.g., "integer"
+}
+
+// Plural is a {n, plural, ...} or {n, selectordinal, ...} argument.
+type Plural struct {
+	Name    string
+	Ordinal bool
+	Offset  float64
+	Cases   []Case // Keys are plural categories or explicit values such as "=0"
+}
+
+// Select is a {gender, select, ...} argument.
+type Select struct {
+	Name  string
+	Cases []Case
+}
+
+// Pound is the # inside a plural case, replaced by the (offset) number.
+type Pound struct{}
+
+type Case struct {
+	Key     string
+	Message []Node
+}
+
+func (Text) node()     {}
+func (Argument) node() {}
+func (Plural) node()   {}
+func (Select) node()   {}
+func (Pound) node()    {}
+
+// PluralCategories are the CLDR plural category keywords allowed as plural case keys.
+var PluralCategories = []string{"zero", "one", "two", "few", "many", "other"}
+
+var simpleTypes = map[string]bool{
+	"number":   true,
+	"date":     true,
+	"time":     true,
+	"spellout": true,
+	"ordinal":  true,
+	"duration": true,
+}
+
+// SyntaxError reports where a message failed to parse.
+type SyntaxError struct {
+	Offset  int // Byte offset into the message
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("icu: %s at offset %d", e.Message, e.Offset)
+}
+
+// Parse parses an ICU MessageFormat string.
+func Parse(msg string) ([]Node, error) {
+	p := &parser{src: msg}
+	nodes, err := p.message(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return nodes, nil
+}
+
+// Validate reports whether msg is a well-formed ICU MessageFormat string.
+func Validate(msg string) error {
+	_, err := Parse(msg)
+	return err
+}
+
+// Arguments returns the names of the arguments nodes use, including those plurals and selects
+// are chosen by, in order of first appearance.
+func Arguments(nodes []Node) []string {
+	var out []string
+	seen := make(map[string]bool)
+	var walk func(nodes []Node)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	walk = func(nodes []Node) {
+		for _, n := range nodes {
+			switch n := n.(type) {
+			case Argument:
+				add(n.Name)
+			case Plural:
+				add(n.Name)
+				for _, c := range n.Cases {
+					walk(c.Message)
+				}
+			case Select:
+				add(n.Name)
+				for _, c := range n.Cases {
+					walk(c.Message)
+				}
+			}
+		}
+	}
+	walk(nodes)
+	return out
+}
+
+type parser struct {
+	src         string
+	pos         int
+	pluralDepth int // > 0 while inside a plural case, where # is special
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Offset: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && isSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// message parses until the end of input, or until the closing brace of a nested message
+// which is left unconsumed.
+func (p *parser) message(nested bool) ([]Node, error) {
+	var nodes []Node
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, Text{Value: text.String()})
+			text.Reset()
+		}
+	}
+
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\'':
+			p.quoted(&text)
+		case c == '{':
+			flush()
+			n, err := p.argument()
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		case c == '}':
+			if !nested {
+				return nil, p.errorf("unmatched '}'")
+			}
+			flush()
+			return nodes, nil
+		case c == '#' && p.pluralDepth > 0:
+			flush()
+			nodes = append(nodes, Pound{})
+			p.pos++
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	if nested {
+		return nil, p.errorf("unclosed '{'")
+	}
+	flush()
+	return nodes, nil
+}
+
+// quoted handles an apostrophe: a doubled apostrophe is a literal one, an apostrophe before syntax
+// characters starts a quoted literal, and any other apostrophe is literal.
+func (p *parser) quoted(text *strings.Builder) {
+	p.pos++ // opening apostrophe
+	if p.eof() {
+		text.WriteByte('\'')
+		return
+	}
+
+	c := p.peek()
+	if c == '\'' {
+		text.WriteByte('\'')
+		p.pos++
+		return
+	}
+	if c != '{' && c != '}' && c != '|' && !(c == '#' && p.pluralDepth > 0) {
+		text.WriteByte('\'')
+		return
+	}
+
+	for !p.eof() {
+		c = p.peek()
+		p.pos++
+		if c != '\'' {
+			text.WriteByte(c)
+			continue
+		}
+		if !p.eof() && p.peek() == '\'' {
+			text.WriteByte('\'')
+			p.pos++
+			continue
+		}
+		return
+	}
+}
+
+func (p *parser) identifier() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if isSpace(c) || strings.IndexByte("{},#'", c) >= 0 {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.eof() {
+		return p.errorf("expected %q, found end of message", c)
+	}
+	if p.peek() != c {
+		return p.errorf("expected %q, found %q", c, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) argument() (Node, error) {
+	p.pos++ // '{'
+	p.skipSpace()
+
+	name := p.identifier()
+	if name == "" {
+		return nil, p.errorf("missing argument name")
+	}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("unclosed '{'")
+	}
+	if p.peek() == '}' {
+		p.pos++
+		return Argument{Name: name}, nil
+	}
+	if err := p.expect(','); err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	typ := p.identifier()
+	p.skipSpace()
+
+	switch typ {
+	case "plural", "selectordinal":
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		return p.plural(name, typ == "selectordinal")
+	case "select":
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		cases, err := p.cases(false)
+		if err != nil {
+			return nil, err
+		}
+		return Select{Name: name, Cases: cases}, nil
+	}
+
+	if !simpleTypes[typ] {
+		return nil, p.errorf("unknown argument type %q", typ)
+	}
+
+	arg := Argument{Name: name, Type: typ}
+	if p.eof() {
+		return nil, p.errorf("unclosed '{'")
+	}
+	if p.peek() == ',' {
+		p.pos++
+		start := p.pos
+		for !p.eof() && p.peek() != '}' {
+			if p.peek() == '{' {
+				return nil, p.errorf("unexpected '{' in argument style")
+			}
+			p.pos++
+		}
+		arg.Style = strings.TrimSpace(p.src[start:p.pos])
+	}
+	if err := p.expect('}'); err != nil {
+		return nil, err
+	}
+	return arg, nil
+}
+
+func (p *parser) plural(name string, ordinal bool) (Node, error) {
+	pl := Plural{Name: name, Ordinal: ordinal}
+
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], "offset:") {
+		p.pos += len("offset:")
+		p.skipSpace()
+		start := p.pos
+		raw := p.identifier()
+		offset, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid plural offset %q", raw)
+		}
+		pl.Offset = offset
+	}
+
+	p.pluralDepth++
+	cases, err := p.cases(true)
+	p.pluralDepth--
+	if err != nil {
+		return nil, err
+	}
+	pl.Cases = cases
+	return pl, nil
+}
+
+// cases parses "key {message} key {message} ... }" including the closing brace of the argument.
+func (p *parser) cases(plural bool) ([]Case, error) {
+	var cases []Case
+	seen := make(map[string]bool)
+
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unclosed '{'")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			break
+		}
+
+		start := p.pos
+		key := p.identifier()
+		if key == "" {
+			return nil, p.errorf("missing case selector")
+		}
+		if plural && !validPluralKey(key) {
+			p.pos = start
+			return nil, p.errorf("invalid plural selector %q", key)
+		}
+		if seen[key] {
+			p.pos = start
+			return nil, p.errorf("duplicate selector %q", key)
+		}
+		seen[key] = true
+
+		p.skipSpace()
+		if err := p.expect('{'); err != nil {
+			return nil, err
+		}
+		msg, err := p.message(true)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++ // '}'
+		cases = append(cases, Case{Key: key, Message: msg})
+	}
+
+	if !seen["other"] {
+		return nil, p.errorf("missing 'other' case")
+	}
+	return cases, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func validPluralKey(key string) bool {
+	if strings.HasPrefix(key, "=") {
+		_, err := strconv.ParseFloat(key[1:], 64)
+		return err == nil
+	}
+	for _, c := range PluralCategories {
+		if key == c {
+			return true
+		}
+	}
+	return false
+}
+
+// Escape quotes literal text so that it parses back to itself, e.g., when building messages
+// from other formats. Set inPlural when the text goes into a plural case, where # is special.
+// Apostrophes are only doubled where they would start a quote, so "isn't" stays as it is.
+func Escape(text string, inPlural bool) string {
+	special := func(c byte) bool {
+		return c == '{' || c == '}' || c == '#' && inPlural
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'':
+			// Before syntax or another apostrophe it would start a quote or be read as a doubled
+			// one, and at the end the text may be followed by an argument.
+			if i+1 == len(text) || text[i+1] == '\'' || text[i+1] == '|' || special(text[i+1]) {
+				b.WriteString("''")
+			} else {
+				b.WriteByte('\'')
+			}
+		case special(c):
+			// Quote the whole run together with the apostrophes in and right after it, doubled
+			// inside the quote, since an apostrophe right after the closing one would be read as
+			// a doubled one.
+			b.WriteByte('\'')
+			for ; i < len(text) && (special(text[i]) || text[i] == '\''); i++ {
+				if text[i] == '\'' {
+					b.WriteString("''")
+				} else {
+					b.WriteByte(text[i])
+				}
+			}
+			i--
+			b.WriteByte('\'')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
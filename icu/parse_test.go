@@ -0,0 +1,131 @@
+package icu
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	nodes, err := Parse("Hello {name}, you have {count, plural, =0 {no items} one {# item} other {# items}}.")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(nodes) != 5 {
+		t.Fatalf("expected 5 nodes, got %d: %#v", len(nodes), nodes)
+	}
+	if arg, ok := nodes[1].(Argument); !ok || arg.Name != "name" {
+		t.Errorf("expected argument 'name', got %#v", nodes[1])
+	}
+	pl, ok := nodes[3].(Plural)
+	if !ok {
+		t.Fatalf("expected plural, got %#v", nodes[3])
+	}
+	if pl.Name != "count" || len(pl.Cases) != 3 || pl.Cases[0].Key != "=0" {
+		t.Errorf("unexpected plural: %#v", pl)
+	}
+	if _, ok := pl.Cases[1].Message[0].(Pound); !ok {
+		t.Errorf("expected # to parse as Pound, got %#v", pl.Cases[1].Message)
+	}
+}
+
+func TestParse_Valid(t *testing.T) {
+	for _, msg := range []string{
+		"",
+		"Plain text",
+		"It''s {name}''s turn",
+		"Use '{braces}' literally",
+		"Don't worry",
+		"{n, number, integer} of {total, number}",
+		"{d, date, short} at {d, time, short}",
+		"{gender, select, female {She} male {He} other {They}} replied",
+		"{n, plural, offset:1 =0 {nobody} =1 {{name}} one {{name} and # other} other {{name} and # others}}",
+		"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}",
+		"{g, select, other {{n, plural, other {# #}}}}",
+		"Päckchen für {name}",
+	} {
+		if err := Validate(msg); err != nil {
+			t.Errorf("Validate(%q) failed: %v", msg, err)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, msg := range []string{
+		"Hello {name",
+		"Hello name}",
+		"{}",
+		"{n, plural, one {# item}}",
+		"{n, plural, several {x} other {y}}",
+		"{n, plural, one {x} one {y} other {z}}",
+		"{n, select, a {x}}",
+		"{n, currency}",
+		"{n, plural, offset:x other {y}}",
+		"{n, plural, other {unclosed}",
+		"{n number}",
+	} {
+		err := Validate(msg)
+		if err == nil {
+			t.Errorf("Validate(%q) should fail", msg)
+			continue
+		}
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Validate(%q) returned %T, want *SyntaxError", msg, err)
+		}
+	}
+}
+
+func TestArguments(t *testing.T) {
+	nodes, err := Parse("{name} has {n, plural, one {# file in {dir}} other {# files in {dir} of {name}}} {g, select, other {{when, date}}}")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got, want := Arguments(nodes), []string{"name", "n", "dir", "g", "when"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Arguments = %v, want %v", got, want)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, text := range []string{"It's {not} an argument", "{'", "50% # off", "''{}''", "{'s", "a'|b", "'#'", "end'"} {
+		got, err := Format("{n, plural, other {"+Escape(text, true)+"{x}}}", "en", map[string]any{"n": 1, "x": "!"})
+		if err != nil || got != text+"!" {
+			t.Errorf("Escape(%q, true) did not round-trip: %q (%v)", text, got, err)
+		}
+		got, err = Format(Escape(text, false)+"{x}", "en", map[string]any{"x": "!"})
+		if err != nil || got != text+"!" {
+			t.Errorf("Escape(%q, false) did not round-trip: %q (%v)", text, got, err)
+		}
+	}
+
+	if got := Escape("# file isn't here", true); got != "'#' file isn't here" {
+		t.Errorf("Escape should leave plain apostrophes alone, got %q", got)
+	}
+}
+
+// TestEscape_RoundTrip checks every text of up to six syntax characters, apostrophes and
+// spaces.
+func TestEscape_RoundTrip(t *testing.T) {
+	const alphabet = "'{}#| "
+	texts, level := []string{""}, []string{""}
+	for n := 0; n < 6; n++ {
+		var next []string
+		for _, text := range level {
+			for i := range alphabet {
+				next = append(next, text+alphabet[i:i+1])
+			}
+		}
+		texts, level = append(texts, next...), next
+	}
+
+	for _, text := range texts {
+		got, err := Format("{n, plural, other {"+Escape(text, true)+"{x}}}", "en", map[string]any{"n": 1, "x": "!"})
+		if err != nil || got != text+"!" {
+			t.Fatalf("Escape(%q, true) did not round-trip: %q (%v)", text, got, err)
+		}
+		got, err = Format(Escape(text, false)+"{x}", "en", map[string]any{"x": "!"})
+		if err != nil || got != text+"!" {
+			t.Fatalf("Escape(%q, false) did not round-trip: %q (%v)", text, got, err)
+		}
+	}
+}
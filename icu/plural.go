@@ -0,0 +1,55 @@
+package icu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/plural"
+)
+
+// ValidatePlurals checks that msg is well-formed and that every plural argument has a case for
+// each category whole numbers below one million fall into, and every selectordinal argument one
+// for each ordinal category, by the locale's CLDR rules. Explicit "=N" cases do not count
+// towards a category.
+func ValidatePlurals(msg, locale string) error {
+	nodes, err := Parse(msg)
+	if err != nil {
+		return err
+	}
+	return checkPlurals(nodes, plural.ForLocale(locale), locale)
+}
+
+func checkPlurals(nodes []Node, rules *plural.Rules, locale string) error {
+	for _, n := range nodes {
+		var cases []Case
+		switch n := n.(type) {
+		case Select:
+			cases = n.Cases
+		case Plural:
+			required := rules.CountCategories()
+			kind := "plural"
+			if n.Ordinal {
+				required = rules.OrdinalCategories()
+				kind = "selectordinal"
+			}
+
+			var missing []string
+			for _, category := range required {
+				if selectCase(n.Cases, category).Key != category {
+					missing = append(missing, category)
+				}
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("icu: %s argument %q is missing %s for %s", kind, n.Name, strings.Join(missing, ", "), locale)
+			}
+			cases = n.Cases
+		}
+
+		for _, c := range cases {
+			if err := checkPlurals(c.Message, rules, locale); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
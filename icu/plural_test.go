@@ -0,0 +1,71 @@
+package icu
+
+import "testing"
+
+func TestValidatePlurals(t *testing.T) {
+	valid := []struct {
+		msg, locale string
+	}{
+		{"{n, plural, one {# file} other {# files}}", "en-US"},
+		{"{n, plural, other {# ファイル}}", "ja"},
+		{"{n, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}", "pl"},
+		{"{n, plural, one {# fichier} other {# fichiers}}", "fr-FR"},
+		{"{n, plural, one {# archivo} many {# de archivos} other {# archivos}}", "es"},
+		{"{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}", "en"},
+		{"{g, select, female {{n, plural, one {her file} other {her files}}} other {{n, plural, one {a file} other {files}}}}", "de"},
+		{"No plurals here", "ar"},
+	}
+	for _, tt := range valid {
+		if err := ValidatePlurals(tt.msg, tt.locale); err != nil {
+			t.Errorf("ValidatePlurals(%q, %s) failed: %v", tt.msg, tt.locale, err)
+		}
+	}
+
+	invalid := []struct {
+		msg, locale string
+	}{
+		{"{n, plural, one {# plik} other {# pliku}}", "pl"},
+		{"{n, plural, other {# fichiers}}", "fr"},
+		{"{n, plural, =1 {one file} other {# files}}", "en"},
+		{"{n, selectordinal, other {#th}}", "en"},
+		{"{g, select, other {{n, plural, other {files}}}}", "de"},
+		{"{n, plural, one {# file}", "en"},
+	}
+	for _, tt := range invalid {
+		if err := ValidatePlurals(tt.msg, tt.locale); err == nil {
+			t.Errorf("expected ValidatePlurals(%q, %s) to fail", tt.msg, tt.locale)
+		}
+	}
+}
+
+func TestFormat_PluralRules(t *testing.T) {
+	const ru = "{n, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}"
+	const ordinal = "{n, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}"
+
+	tests := []struct {
+		msg, locale string
+		n           any
+		want        string
+	}{
+		{ru, "ru-RU", 1, "1 файл"},
+		{ru, "ru-RU", 3, "3 файла"},
+		{ru, "ru-RU", 11, "11 файлов"},
+		{ru, "ru-RU", 21, "21 файл"},
+		{ru, "ru-RU", "1.5", "1.5 файла"},
+		{ordinal, "en", 1, "1st"},
+		{ordinal, "en", 22, "22nd"},
+		{ordinal, "en", 13, "13th"},
+		{"{n, plural, one {# item} other {# items}}", "en", "1.0", "1 items"},
+		{"{n, plural, one {# objet} other {# objets}}", "fr", 0, "0 objet"},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.msg, tt.locale, map[string]any{"n": tt.n})
+		if err != nil {
+			t.Errorf("Format(%q, %s, %v) failed: %v", tt.msg, tt.locale, tt.n, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Format(%q, %s, %v) = %q, want %q", tt.msg, tt.locale, tt.n, got, tt.want)
+		}
+	}
+}
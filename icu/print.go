@@ -0,0 +1,80 @@
+package icu
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Print writes nodes back as an ICU MessageFormat string that parses to the same nodes.
+func Print(nodes []Node) string {
+	var b strings.Builder
+	printNodes(&b, nodes, false)
+	return b.String()
+}
+
+// PrintCase writes the message of a plural case, where # stands for the number.
+func PrintCase(nodes []Node) string {
+	var b strings.Builder
+	printNodes(&b, nodes, true)
+	return b.String()
+}
+
+// Equal reports whether a and b are the same message, however their literal text is quoted,
+// e.g., with or without a doubled apostrophe. Messages that do not parse are compared as
+// written.
+func Equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+	na, err := Parse(a)
+	if err != nil {
+		return false
+	}
+	nb, err := Parse(b)
+	if err != nil {
+		return false
+	}
+	return Print(na) == Print(nb)
+}
+
+func printNodes(b *strings.Builder, nodes []Node, inPlural bool) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case Text:
+			b.WriteString(Escape(n.Value, inPlural))
+		case Argument:
+			b.WriteString("{" + n.Name)
+			if n.Type != "" {
+				b.WriteString(", " + n.Type)
+			}
+			if n.Style != "" {
+				b.WriteString(", " + n.Style)
+			}
+			b.WriteString("}")
+		case Pound:
+			b.WriteString("#")
+		case Plural:
+			kind := "plural"
+			if n.Ordinal {
+				kind = "selectordinal"
+			}
+			b.WriteString("{" + n.Name + ", " + kind + ",")
+			if n.Offset != 0 {
+				b.WriteString(" offset:" + strconv.FormatFloat(n.Offset, 'f', -1, 64))
+			}
+			printCases(b, n.Cases, true)
+		case Select:
+			b.WriteString("{" + n.Name + ", select,")
+			printCases(b, n.Cases, inPlural)
+		}
+	}
+}
+
+func printCases(b *strings.Builder, cases []Case, inPlural bool) {
+	for _, c := range cases {
+		b.WriteString(" " + c.Key + " {")
+		printNodes(b, c.Message, inPlural)
+		b.WriteString("}")
+	}
+	b.WriteString("}")
+}
@@ -0,0 +1,61 @@
+package icu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrint(t *testing.T) {
+	msgs := []string{
+		"Hello {name}, it's {n, number, integer}",
+		"{n, plural, offset:1 =0 {Nobody} one {You and # other} other {You and # others in '{'{dir}'}'}}",
+		"{n, selectordinal, one {#st} other {#th}}",
+		"{g, select, female {{n, plural, one {her file} other {her # files}}} other {{n, plural, other {'#'#}}}}",
+		"50% '#' off",
+	}
+	for _, msg := range msgs {
+		nodes, err := Parse(msg)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", msg, err)
+		}
+		printed := Print(nodes)
+		again, err := Parse(printed)
+		if err != nil {
+			t.Errorf("Print(%q) = %q does not parse: %v", msg, printed, err)
+			continue
+		}
+		if !reflect.DeepEqual(nodes, again) {
+			t.Errorf("Print(%q) = %q parses to different nodes", msg, printed)
+		}
+	}
+
+	if got := Print([]Node{Text{Value: "{x}"}}); got != "'{'x'}'" {
+		t.Errorf("Print quoted text = %q", got)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	equal := [][2]string{
+		{"Don't go", "Don''t go"},
+		{"it's {n, number}", "it''s {n,number}"},
+		{"{n, plural, one {# file isn't here} other {# files aren't}}", "{n, plural, one {# file isn''t here} other {# files aren''t}}"},
+		{"'{'x'}'", "'{x}'"},
+		{"Hello {name", "Hello {name"},
+	}
+	for _, tt := range equal {
+		if !Equal(tt[0], tt[1]) {
+			t.Errorf("Equal(%q, %q) = false", tt[0], tt[1])
+		}
+	}
+
+	different := [][2]string{
+		{"Don't go", "Do not go"},
+		{"{name}", "'{name}'"},
+		{"Hello {name", "Hello {name}"},
+	}
+	for _, tt := range different {
+		if Equal(tt[0], tt[1]) {
+			t.Errorf("Equal(%q, %q) = true", tt[0], tt[1])
+		}
+	}
+}
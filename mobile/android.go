@@ -0,0 +1,291 @@
+package mobile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/icu"
+)
+
+var androidVerbs = verbs{text: "s", number: "d"}
+
+// ResourceName returns the Android resource name of a key string: characters Android does not
+// allow become underscores, as does a leading digit.
+func ResourceName(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if !(c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' && i > 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// ExportAndroid writes values, by key string, as an Android strings.xml, naming resources with
+// ResourceName. sources holds the source values by key string, which decide the placeholder
+// positions. A value that is a single plural becomes a <plurals> resource; values using ICU
+// features Android has no syntax for, such as selects, are written unchanged.
+func ExportAndroid(values, sources map[string]string) ([]byte, error) {
+	keys := make(map[string]string, len(values)) // Key string by resource name
+	names := make([]string, 0, len(values))
+	for key := range values {
+		name := ResourceName(key)
+		if other, ok := keys[name]; ok {
+			return nil, fmt.Errorf("mobile: keys %q and %q are both resource %q", min(key, other), max(key, other), name)
+		}
+		keys[name] = key
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>\n")
+	for _, name := range names {
+		msg := values[keys[name]]
+		nodes, err := icu.Parse(msg)
+		if err != nil {
+			b.WriteString("    <string name=\"" + name + "\">" + escapeAndroid(msg) + "</string>\n")
+			continue
+		}
+		order := argumentOrder(nodes, sources[keys[name]])
+		if items, ok := androidPlural(nodes, order); ok {
+			b.WriteString("    <plurals name=\"" + name + "\">\n")
+			for _, category := range icu.PluralCategories {
+				if text, ok := items[category]; ok {
+					b.WriteString("        <item quantity=\"" + category + "\">" + escapeAndroid(text) + "</item>\n")
+				}
+			}
+			b.WriteString("    </plurals>\n")
+			continue
+		}
+		text, ok := printf(nodes, order, androidVerbs, "", len(icu.Arguments(nodes)) > 0)
+		if !ok {
+			text = msg
+		}
+		b.WriteString("    <string name=\"" + name + "\">" + escapeAndroid(text) + "</string>\n")
+	}
+	b.WriteString("</resources>\n")
+	return []byte(b.String()), nil
+}
+
+// androidPlural returns the format string of each plural item when nodes are a single plural
+// Android can express.
+func androidPlural(nodes []icu.Node, order []string) (map[string]string, bool) {
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	p, ok := nodes[0].(icu.Plural)
+	if !ok {
+		return nil, false
+	}
+	cases, ok := pluralCases(p)
+	if !ok {
+		return nil, false
+	}
+	items := make(map[string]string, len(cases))
+	for category, msg := range cases {
+		if items[category], ok = printf(msg, order, androidVerbs, p.Name, true); !ok {
+			return nil, false
+		}
+	}
+	return items, true
+}
+
+// escapeAndroid escapes s for a string resource. Texts with leading, trailing or repeated spaces
+// are quoted, since Android collapses whitespace outside quotes.
+func escapeAndroid(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '@', '?':
+			// A leading @ or ? would make the text a resource reference.
+			if i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if strings.TrimSpace(s) != s || strings.Contains(s, "  ") {
+		return `"` + b.String() + `"`
+	}
+	return b.String()
+}
+
+// unescapeAndroid resolves the escapes and quotes of a string resource the way aapt does,
+// collapsing whitespace outside quotes.
+func unescapeAndroid(s string) string {
+	var b strings.Builder
+	quoted, space := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			continue
+		case !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			space = true
+			continue
+		}
+		if space {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+		}
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if r, err := strconv.ParseUint(s[i+1:min(i+5, len(s))], 16, 32); err == nil && i+5 <= len(s) {
+				b.WriteRune(rune(r))
+				i += 4
+				continue
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// resource is a <string> or <item> element. Inline markup such as <b> is kept as text.
+type resource struct {
+	attrs map[string]string
+	text  string
+}
+
+func (r *resource) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	r.attrs = make(map[string]string, len(start.Attr))
+	for _, a := range start.Attr {
+		r.attrs[a.Name.Local] = a.Value
+	}
+	var b strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+			b.WriteString("<" + tok.Name.Local)
+			for _, a := range tok.Attr {
+				b.WriteString(" " + a.Name.Local + "=\"" + a.Value + "\"")
+			}
+			b.WriteString(">")
+		case xml.EndElement:
+			if depth--; depth > 0 {
+				b.WriteString("</" + tok.Name.Local + ">")
+			}
+		case xml.CharData:
+			b.Write(tok)
+		}
+	}
+	r.text = b.String()
+	return nil
+}
+
+type androidResources struct {
+	Strings []resource `xml:"string"`
+	Plurals []struct {
+		Name         string     `xml:"name,attr"`
+		Translatable string     `xml:"translatable,attr"`
+		Items        []resource `xml:"item"`
+	} `xml:"plurals"`
+}
+
+// ImportAndroid reads an Android strings.xml into ICU MessageFormat values by resource name,
+// naming placeholders after the arguments of the source values in sources, also by resource
+// name. Resources marked translatable="false" are left out.
+func ImportAndroid(data []byte, sources map[string]string) (map[string]string, error) {
+	var res androidResources
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&res); err != nil {
+		return nil, fmt.Errorf("mobile: %w", err)
+	}
+
+	out := make(map[string]string, len(res.Strings)+len(res.Plurals))
+	for _, r := range res.Strings {
+		name := r.attrs["name"]
+		if r.attrs["translatable"] == "false" {
+			continue
+		}
+		msg, err := message(unescapeAndroid(r.text), sourceOrder(sources[name]), "", false, false)
+		if err != nil {
+			return nil, fmt.Errorf("mobile: key %q: %w", name, err)
+		}
+		out[name] = msg
+	}
+
+	for _, p := range res.Plurals {
+		if p.Translatable == "false" {
+			continue
+		}
+		order, pound := sourcePlural(sources[p.Name])
+		cases := make(map[string]string, len(p.Items))
+		for _, item := range p.Items {
+			msg, err := message(unescapeAndroid(item.text), order, pound, true, true)
+			if err != nil {
+				return nil, fmt.Errorf("mobile: key %q: %w", p.Name, err)
+			}
+			cases[item.attrs["quantity"]] = msg
+		}
+		msg, err := pluralMessage(pound, cases)
+		if err != nil {
+			return nil, fmt.Errorf("mobile: key %q: %w", p.Name, err)
+		}
+		out[p.Name] = msg
+	}
+	return out, nil
+}
+
+// sourceOrder returns the arguments of a source value in order of appearance.
+func sourceOrder(source string) []string {
+	return argumentOrder(nil, source)
+}
+
+// sourcePlural returns the argument order of a source value and the argument of its plural. A
+// source without plural falls back to its first argument, or to "count".
+func sourcePlural(source string) ([]string, string) {
+	order := sourceOrder(source)
+	if nodes, err := icu.Parse(source); err == nil {
+		for _, n := range nodes {
+			if p, ok := n.(icu.Plural); ok {
+				return order, p.Name
+			}
+		}
+	}
+	if len(order) == 0 {
+		order = []string{"count"}
+	}
+	return order, order[0]
+}
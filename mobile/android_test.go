@@ -0,0 +1,123 @@
+package mobile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportAndroid(t *testing.T) {
+	sources := map[string]string{
+		"cart.summary": "{name} has {n, number} items",
+		"cart.files":   "{count, plural, one {# file in {dir}} other {# files in {dir}}}",
+	}
+	data, err := ExportAndroid(map[string]string{
+		"cart.title":   "It's \"50%\" off",
+		"cart.summary": "{n, number} Artikel für {name} (100%)",
+		"cart.files":   "{count, plural, one {# Datei in {dir}} other {# Dateien in {dir}}}",
+		"cart.gender":  "{g, select, female {Sie} other {Er}}",
+		"cart.spaces":  "  <b>@home</b>",
+		"cart.ask":     "?\nNew line",
+	}, sources)
+	if err != nil {
+		t.Fatalf("ExportAndroid failed: %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="cart.ask">\?\nNew line</string>
+    <plurals name="cart.files">
+        <item quantity="one">%1$d Datei in %2$s</item>
+        <item quantity="other">%1$d Dateien in %2$s</item>
+    </plurals>
+    <string name="cart.gender">{g, select, female {Sie} other {Er}}</string>
+    <string name="cart.spaces">"  &lt;b&gt;@home&lt;/b&gt;"</string>
+    <string name="cart.summary">%2$d Artikel für %1$s (100%%)</string>
+    <string name="cart.title">It\'s \"50%\" off</string>
+</resources>
+`
+	if string(data) != want {
+		t.Errorf("ExportAndroid =\n%s\nwant\n%s", data, want)
+	}
+
+	if _, err := ExportAndroid(map[string]string{"cart-title": "x", "cart_title": "y"}, nil); err == nil || !strings.Contains(err.Error(), `keys "cart-title" and "cart_title" are both resource "cart_title"`) {
+		t.Errorf("Expected keys with the same resource name to fail, got %v", err)
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	for key, want := range map[string]string{
+		"checkout.pay_now": "checkout.pay_now",
+		"checkout-pay now": "checkout_pay_now",
+		"1st.place":        "_st.place",
+		"uuid.29e9-842f":   "uuid.29e9_842f",
+	} {
+		if got := ResourceName(key); got != want {
+			t.Errorf("ResourceName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestImportAndroid(t *testing.T) {
+	sources := map[string]string{
+		"cart.summary": "{name} has {n, number} items",
+		"cart.files":   "{count, plural, one {# file in {dir}} other {# files in {dir}}}",
+	}
+	values, err := ImportAndroid([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <string name="cart.title">It\'s   \"50%\"
+        off</string>
+    <string name="cart.summary">%2$d Artikel für %1$s (100%%)</string>
+    <string name="cart.markup">Hello <b>%s</b>, été "  spaced  "</string>
+    <string name="cart.gender">{g, select, female {Sie} other {Er}}</string>
+    <string name="cart.braces">{literal}</string>
+    <string name="app.id" translatable="false">com.example</string>
+    <plurals name="cart.files">
+        <item quantity="one">%1$d Datei in %2$s</item>
+        <item quantity="other">%1$d Dateien in %2$s</item>
+    </plurals>
+</resources>`), sources)
+	if err != nil {
+		t.Fatalf("ImportAndroid failed: %v", err)
+	}
+
+	want := map[string]string{
+		"cart.title":   "It's \"50%\" off",
+		"cart.summary": "{n, number} Artikel für {name} (100%)",
+		"cart.markup":  "Hello <b>{arg1}</b>, été   spaced  ",
+		"cart.gender":  "{g, select, female {Sie} other {Er}}",
+		"cart.braces":  "'{'literal'}'",
+		"cart.files":   "{count, plural, one {# Datei in {dir}} other {# Dateien in {dir}}}",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("ImportAndroid =\n%q\nwant\n%q", values, want)
+	}
+
+	if _, err := ImportAndroid([]byte(`<resources><plurals name="x"><item quantity="one">%d</item></plurals></resources>`), nil); err == nil || !strings.Contains(err.Error(), "no other form") {
+		t.Errorf("Expected plural without other form to fail, got %v", err)
+	}
+}
+
+func TestAndroidRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"a": "Hello {name}, you have {n, number, integer} new",
+		"b": "{n, plural, one {# message from {name}} other {# messages from {name}}}",
+		"c": "It's '{'literal'}' 100%",
+		"d": "  padded  ",
+		"e": "Don't go",
+		"f": "it's {n, number}",
+	}
+	sources := map[string]string{"a": "{n, number} new for {name}", "b": "{name}: {n, plural, one {#} other {#}}", "f": "it's {n, number}"}
+	data, err := ExportAndroid(values, sources)
+	if err != nil {
+		t.Fatalf("ExportAndroid failed: %v", err)
+	}
+	got, err := ImportAndroid(data, sources)
+	if err != nil {
+		t.Fatalf("ImportAndroid failed: %v\n%s", err, data)
+	}
+	values["a"] = "Hello {name}, you have {n, number} new"
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("Round trip =\n%q\nwant\n%q\nvia\n%s", got, values, data)
+	}
+}
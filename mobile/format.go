@@ -0,0 +1,190 @@
+// Package mobile converts between ICU MessageFormat values and the string resources of mobile
+// platforms: Android strings.xml and iOS Localizable.strings with .stringsdict plurals.
+//
+// Both platforms format with printf-style placeholders, which refer to arguments by position
+// rather than by name. Positions follow the order arguments first appear in the source value of
+// a key, so every language of an app passes its arguments the same way.
+package mobile
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/icu"
+)
+
+// verbs are the printf conversions a platform formats arguments with.
+type verbs struct {
+	text   string // Conversion of untyped arguments, e.g., "s" on Android
+	number string // Conversion of {n, number} arguments and plural counts, e.g., "d" on Android
+}
+
+// placeholder matches printf conversions. The space flag is left out, so texts like "100% sure"
+// are not taken for one.
+var placeholder = regexp.MustCompile(`%(?:(\d+)\$)?[-+0#]*\d*(?:\.\d+)?(?:hh|h|ll|l|q|z|t|j|L)?([sSdiuxXoeEfgGaAcC@])`)
+
+// argumentOrder returns the arguments of the source value in order of appearance, followed by
+// those only msg uses.
+func argumentOrder(nodes []icu.Node, source string) []string {
+	var order []string
+	if sourceNodes, err := icu.Parse(source); err == nil {
+		order = icu.Arguments(sourceNodes)
+	}
+	for _, name := range icu.Arguments(nodes) {
+		if position(order, name) == 0 {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// position returns the 1-based position of name in order, 0 when it is not there.
+func position(order []string, name string) int {
+	for i, n := range order {
+		if n == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// printf renders nodes as a printf format string with positional placeholders, where # stands
+// for the argument pound. It reports false for plurals, selects and typed arguments other than
+// plain numbers, which the caller has to handle. A % is doubled only in messages with arguments,
+// since platforms show texts without arguments unformatted.
+func printf(nodes []icu.Node, order []string, v verbs, pound string, formatted bool) (string, bool) {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case icu.Text:
+			if formatted {
+				b.WriteString(strings.ReplaceAll(n.Value, "%", "%%"))
+			} else {
+				b.WriteString(n.Value)
+			}
+		case icu.Argument:
+			verb := v.text
+			switch {
+			case n.Type == "number" && (n.Style == "" || n.Style == "integer"):
+				verb = v.number
+			case n.Type != "":
+				return "", false
+			}
+			fmt.Fprintf(&b, "%%%d$%s", position(order, n.Name), verb)
+		case icu.Pound:
+			fmt.Fprintf(&b, "%%%d$%s", position(order, pound), v.number)
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// pluralCases returns the cases of p by category, reporting false when p cannot be written as
+// platform plurals: ordinals, offsets, exact matches and nested plurals or selects.
+func pluralCases(p icu.Plural) (map[string][]icu.Node, bool) {
+	if p.Ordinal || p.Offset != 0 {
+		return nil, false
+	}
+	cases := make(map[string][]icu.Node, len(p.Cases))
+	for _, c := range p.Cases {
+		if strings.HasPrefix(c.Key, "=") {
+			return nil, false
+		}
+		for _, n := range c.Message {
+			switch n.(type) {
+			case icu.Plural, icu.Select:
+				return nil, false
+			}
+		}
+		cases[c.Key] = c.Message
+	}
+	return cases, true
+}
+
+// message turns a printf format string back into ICU MessageFormat, naming placeholders after
+// their position in order, or "arg<position>" past its end. Inside a plural, the argument pound
+// becomes #. The %% escape is resolved in formatted strings, which are those with placeholders
+// unless the caller knows better. A text without placeholders that is written in ICU syntax, as
+// exports do for values a platform has no syntax for, is kept.
+func message(s string, order []string, pound string, inPlural, formatted bool) (string, error) {
+	matches := placeholder.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 && !formatted {
+		if nodes, err := icu.Parse(s); err == nil && usesICU(nodes) {
+			return s, nil
+		}
+		return icu.Escape(s, inPlural), nil
+	}
+
+	var b strings.Builder
+	text := func(t string) {
+		b.WriteString(icu.Escape(strings.ReplaceAll(t, "%%", "%"), inPlural))
+	}
+	last, next := 0, 1
+	for _, m := range matches {
+		// A placeholder right after an odd number of % is literal text.
+		if percents := len(s[:m[0]]) - len(strings.TrimRight(s[:m[0]], "%")); percents%2 == 1 {
+			continue
+		}
+		i := next
+		if m[2] >= 0 {
+			i, _ = strconv.Atoi(s[m[2]:m[3]])
+		}
+		if i < 1 {
+			return "", fmt.Errorf("invalid placeholder %q", s[m[0]:m[1]])
+		}
+		next = i + 1
+		name := "arg" + strconv.Itoa(i)
+		if i <= len(order) {
+			name = order[i-1]
+		}
+
+		text(s[last:m[0]])
+		switch verb := s[m[4]:m[5]]; {
+		case inPlural && name == pound:
+			b.WriteString("#")
+		case verb == "s" || verb == "S" || verb == "@":
+			b.WriteString("{" + name + "}")
+		default:
+			b.WriteString("{" + name + ", number}")
+		}
+		last = m[1]
+	}
+	text(s[last:])
+	return b.String(), nil
+}
+
+// usesICU reports whether nodes contain ICU syntax exports keep unchanged: plurals, selects and
+// typed arguments. Plain {name} placeholders are always exported as printf ones, so braces in a
+// text without placeholders are literal.
+func usesICU(nodes []icu.Node) bool {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case icu.Plural, icu.Select:
+			return true
+		case icu.Argument:
+			if n.Type != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pluralMessage joins plural case messages, by category, into an ICU plural of name.
+func pluralMessage(name string, cases map[string]string) (string, error) {
+	if _, ok := cases["other"]; !ok {
+		return "", fmt.Errorf("plural of %q has no other form", name)
+	}
+	var b strings.Builder
+	b.WriteString("{" + name + ", plural,")
+	for _, category := range icu.PluralCategories {
+		if text, ok := cases[category]; ok {
+			b.WriteString(" " + category + " {" + text + "}")
+		}
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
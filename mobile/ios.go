@@ -0,0 +1,418 @@
+package mobile
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rah-0/meisterwerk/icu"
+)
+
+var (
+	iosVerbs = verbs{text: "@", number: "lld"}
+
+	// variable matches the %#@name@ references of a .stringsdict format key.
+	variable = regexp.MustCompile(`%(?:(\d+)\$)?#@([^@]+)@`)
+)
+
+const (
+	formatKey     = "NSStringLocalizedFormatKey"
+	specTypeKey   = "NSStringFormatSpecTypeKey"
+	valueTypeKey  = "NSStringFormatValueTypeKey"
+	pluralRuleKey = "NSStringPluralRuleType"
+)
+
+// ExportIOS writes values, by key string, as a Localizable.strings file and a .stringsdict file
+// holding the values with plurals. sources holds the source values by key string, which decide
+// the placeholder positions. Values using ICU features iOS has no syntax for, such as selects,
+// are written unchanged to the strings file.
+func ExportIOS(values, sources map[string]string) (stringsFile, stringsdict []byte) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var s, d strings.Builder
+	d.WriteString(xml.Header)
+	d.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	d.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	for _, name := range names {
+		msg := values[name]
+		nodes, err := icu.Parse(msg)
+		if err != nil {
+			s.WriteString(quoteStrings(name) + " = " + quoteStrings(msg) + ";\n")
+			continue
+		}
+		order := argumentOrder(nodes, sources[name])
+		if entry, ok := stringsdictEntry(nodes, order); ok {
+			d.WriteString("\t<key>" + escapeXML(name) + "</key>\n" + entry)
+			continue
+		}
+		text, ok := printf(nodes, order, iosVerbs, "", len(icu.Arguments(nodes)) > 0)
+		if !ok {
+			text = msg
+		}
+		s.WriteString(quoteStrings(name) + " = " + quoteStrings(text) + ";\n")
+	}
+	d.WriteString("</dict>\n</plist>\n")
+	return []byte(s.String()), []byte(d.String())
+}
+
+// stringsdictEntry renders the dict of a value with plurals, where the format key holds the
+// text around them and each plural becomes a variable named after its argument.
+func stringsdictEntry(nodes []icu.Node, order []string) (string, bool) {
+	var format strings.Builder
+	var vars strings.Builder
+	written := make(map[string]bool)
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case icu.Plural:
+			cases, ok := pluralCases(n)
+			if !ok {
+				return "", false
+			}
+			fmt.Fprintf(&format, "%%%d$#@%s@", position(order, n.Name), n.Name)
+			if written[n.Name] {
+				continue
+			}
+			written[n.Name] = true
+			vars.WriteString("\t\t<key>" + escapeXML(n.Name) + "</key>\n\t\t<dict>\n")
+			vars.WriteString("\t\t\t<key>" + specTypeKey + "</key>\n\t\t\t<string>" + pluralRuleKey + "</string>\n")
+			vars.WriteString("\t\t\t<key>" + valueTypeKey + "</key>\n\t\t\t<string>" + iosVerbs.number + "</string>\n")
+			for _, category := range icu.PluralCategories {
+				msg, ok := cases[category]
+				if !ok {
+					continue
+				}
+				text, ok := printf(msg, order, iosVerbs, n.Name, true)
+				if !ok {
+					return "", false
+				}
+				vars.WriteString("\t\t\t<key>" + category + "</key>\n\t\t\t<string>" + escapeXML(text) + "</string>\n")
+			}
+			vars.WriteString("\t\t</dict>\n")
+		default:
+			text, ok := printf([]icu.Node{n}, order, iosVerbs, "", true)
+			if !ok {
+				return "", false
+			}
+			format.WriteString(text)
+		}
+	}
+	if len(written) == 0 {
+		return "", false
+	}
+	return "\t<dict>\n\t\t<key>" + formatKey + "</key>\n\t\t<string>" + escapeXML(format.String()) + "</string>\n" +
+		vars.String() + "\t</dict>\n", true
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// quoteStrings quotes s for a .strings file.
+func quoteStrings(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// ImportIOS reads a Localizable.strings file and an optional .stringsdict file into ICU
+// MessageFormat values by key string, naming placeholders after the arguments of the source
+// values in sources. Entries of the .stringsdict file take precedence.
+func ImportIOS(stringsFile, stringsdict []byte, sources map[string]string) (map[string]string, error) {
+	entries, err := parseStrings(stringsFile)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(entries))
+	for name, text := range entries {
+		msg, err := message(text, sourceOrder(sources[name]), "", false, false)
+		if err != nil {
+			return nil, fmt.Errorf("mobile: key %q: %w", name, err)
+		}
+		out[name] = msg
+	}
+	if len(bytes.TrimSpace(stringsdict)) == 0 {
+		return out, nil
+	}
+
+	dict, err := parsePlist(stringsdict)
+	if err != nil {
+		return nil, err
+	}
+	for name, v := range dict {
+		entry, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("mobile: key %q: expected a dict", name)
+		}
+		msg, err := pluralEntry(entry, sourceOrder(sources[name]))
+		if err != nil {
+			return nil, fmt.Errorf("mobile: key %q: %w", name, err)
+		}
+		out[name] = msg
+	}
+	return out, nil
+}
+
+// pluralEntry turns the dict of a .stringsdict entry into an ICU message, replacing each
+// variable of the format key with a plural of the argument at its position.
+func pluralEntry(entry map[string]any, order []string) (string, error) {
+	format, ok := entry[formatKey].(string)
+	if !ok {
+		return "", fmt.Errorf("no %s", formatKey)
+	}
+
+	// Variables are swapped for markers while the text around them is converted.
+	var plurals []string
+	next := 1
+	var failed error
+	marked := variable.ReplaceAllStringFunc(format, func(ref string) string {
+		m := variable.FindStringSubmatch(ref)
+		name := m[2]
+		i := next
+		if m[1] != "" {
+			i, _ = strconv.Atoi(m[1])
+		}
+		next = i + 1
+		for len(order) < i {
+			order = append(order, "arg"+strconv.Itoa(len(order)+1))
+		}
+		if i >= 1 {
+			order[i-1] = name
+		}
+
+		v, ok := entry[name].(map[string]any)
+		if !ok || v[specTypeKey] != pluralRuleKey {
+			failed = fmt.Errorf("variable %q is not a plural rule", name)
+			return ref
+		}
+		cases := make(map[string]string)
+		for _, category := range icu.PluralCategories {
+			text, ok := v[category].(string)
+			if !ok {
+				continue
+			}
+			msg, err := message(text, order, name, true, true)
+			if err != nil {
+				failed = err
+				return ref
+			}
+			cases[category] = msg
+		}
+		msg, err := pluralMessage(name, cases)
+		if err != nil {
+			failed = err
+			return ref
+		}
+		plurals = append(plurals, msg)
+		return marker(len(plurals) - 1)
+	})
+	if failed != nil {
+		return "", failed
+	}
+
+	msg, err := message(marked, order, "", false, true)
+	if err != nil {
+		return "", err
+	}
+	for i, p := range plurals {
+		msg = strings.Replace(msg, marker(i), p, 1)
+	}
+	return msg, nil
+}
+
+// marker stands for the i-th plural of a format key, in characters of the private use area that
+// message leaves alone.
+func marker(i int) string {
+	return "\uE000" + strconv.Itoa(i) + "\uE001"
+}
+
+// parseStrings reads the "key" = "value"; pairs of a .strings file, skipping comments.
+func parseStrings(data []byte) (map[string]string, error) {
+	s := strings.TrimPrefix(string(data), "\ufeff")
+	pos := 0
+	errorf := func(format string, args ...any) error {
+		line := strings.Count(s[:pos], "\n") + 1
+		return fmt.Errorf("mobile: strings line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+	skip := func() error {
+		for pos < len(s) {
+			switch {
+			case s[pos] == ' ' || s[pos] == '\t' || s[pos] == '\n' || s[pos] == '\r':
+				pos++
+			case strings.HasPrefix(s[pos:], "//"):
+				if end := strings.IndexByte(s[pos:], '\n'); end >= 0 {
+					pos += end
+				} else {
+					pos = len(s)
+				}
+			case strings.HasPrefix(s[pos:], "/*"):
+				end := strings.Index(s[pos+2:], "*/")
+				if end < 0 {
+					return errorf("unterminated comment")
+				}
+				pos += end + 4
+			default:
+				return nil
+			}
+		}
+		return nil
+	}
+	token := func() (string, error) {
+		if pos == len(s) {
+			return "", errorf("unexpected end of file")
+		}
+		if s[pos] != '"' {
+			start := pos
+			for pos < len(s) && (isWordByte(s[pos])) {
+				pos++
+			}
+			if start == pos {
+				return "", errorf("unexpected %q", s[pos])
+			}
+			return s[start:pos], nil
+		}
+		var b strings.Builder
+		for pos++; pos < len(s); pos++ {
+			c := s[pos]
+			switch {
+			case c == '"':
+				pos++
+				return b.String(), nil
+			case c == '\\' && pos+1 < len(s):
+				pos++
+				switch s[pos] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				case 'r':
+					b.WriteByte('\r')
+				case 'u', 'U':
+					r, err := strconv.ParseUint(s[pos+1:min(pos+5, len(s))], 16, 32)
+					if err != nil || !utf8.ValidRune(rune(r)) {
+						return "", errorf("invalid unicode escape")
+					}
+					b.WriteRune(rune(r))
+					pos += 4
+				default:
+					b.WriteByte(s[pos])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errorf("unterminated string")
+	}
+	expect := func(c byte) error {
+		if err := skip(); err != nil {
+			return err
+		}
+		if pos == len(s) || s[pos] != c {
+			return errorf("expected %q", c)
+		}
+		pos++
+		return skip()
+	}
+
+	out := make(map[string]string)
+	for {
+		if err := skip(); err != nil {
+			return nil, err
+		}
+		if pos == len(s) {
+			return out, nil
+		}
+		key, err := token()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect('='); err != nil {
+			return nil, err
+		}
+		value, err := token()
+		if err != nil {
+			return nil, err
+		}
+		if err := expect(';'); err != nil {
+			return nil, err
+		}
+		out[key] = value
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '$' || c == ':' || c == '/' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// parsePlist reads the top-level dict of a property list, holding strings and nested dicts.
+// Other values are skipped.
+func parsePlist(data []byte) (map[string]any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("mobile: stringsdict: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "dict" {
+			dict, err := parseDict(d)
+			if err != nil {
+				return nil, fmt.Errorf("mobile: stringsdict: %w", err)
+			}
+			return dict, nil
+		}
+	}
+}
+
+func parseDict(d *xml.Decoder) (map[string]any, error) {
+	out := make(map[string]any)
+	key := ""
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch tok.Name.Local {
+			case "key":
+				if err := d.DecodeElement(&key, &tok); err != nil {
+					return nil, err
+				}
+				continue
+			case "string":
+				var s string
+				if err := d.DecodeElement(&s, &tok); err != nil {
+					return nil, err
+				}
+				out[key] = s
+			case "dict":
+				dict, err := parseDict(d)
+				if err != nil {
+					return nil, err
+				}
+				out[key] = dict
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+			if key == "" {
+				return nil, errors.New("value without key")
+			}
+			key = ""
+		case xml.EndElement:
+			return out, nil
+		}
+	}
+}
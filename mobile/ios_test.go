@@ -0,0 +1,124 @@
+package mobile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportIOS(t *testing.T) {
+	sources := map[string]string{
+		"inbox.summary": "{name}: {n, plural, one {# message} other {# messages}}",
+	}
+	stringsFile, stringsdict := ExportIOS(map[string]string{
+		"inbox.title":   "Say \"hi\"\n50% off",
+		"inbox.greet":   "Hallo {name}, {n, number} neu (100%)",
+		"inbox.summary": "{n, plural, one {# Nachricht} other {# Nachrichten}} für {name}",
+		"inbox.when":    "{d, date, short}",
+	}, sources)
+
+	wantStrings := `"inbox.greet" = "Hallo %1$@, %2$lld neu (100%%)";
+"inbox.title" = "Say \"hi\"\n50% off";
+"inbox.when" = "{d, date, short}";
+`
+	if string(stringsFile) != wantStrings {
+		t.Errorf("ExportIOS strings =\n%s\nwant\n%s", stringsFile, wantStrings)
+	}
+	for _, s := range []string{
+		"<key>inbox.summary</key>\n\t<dict>\n\t\t<key>NSStringLocalizedFormatKey</key>\n\t\t<string>%2$#@n@ für %1$@</string>",
+		"<key>NSStringFormatValueTypeKey</key>\n\t\t\t<string>lld</string>",
+		"<key>one</key>\n\t\t\t<string>%2$lld Nachricht</string>",
+		"<key>other</key>\n\t\t\t<string>%2$lld Nachrichten</string>",
+	} {
+		if !strings.Contains(string(stringsdict), s) {
+			t.Errorf("Expected stringsdict to contain %q, got\n%s", s, stringsdict)
+		}
+	}
+}
+
+func TestImportIOS(t *testing.T) {
+	sources := map[string]string{
+		"inbox.greet":   "Hello {name}, {n, number} new",
+		"inbox.summary": "{name}: {n, plural, one {# message} other {# messages}}",
+	}
+	values, err := ImportIOS([]byte("\ufeff"+`/* Greeting */
+"inbox.greet" = "Hallo %1$@, %2$lld neu (100%%)";
+// Title
+"inbox.title" = "Say \"hi\"\n50% off \U00e9";
+inbox_plain = "{braces}";
+`), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>inbox.summary</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%2$#@n@ für %1$@ (100%%)</string>
+		<key>n</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>lld</string>
+			<key>one</key>
+			<string>%2$lld Nachricht</string>
+			<key>other</key>
+			<string>%2$lld Nachrichten</string>
+		</dict>
+	</dict>
+	<key>inbox.unknown</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@files@</string>
+		<key>files</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>one</key>
+			<string>%d file</string>
+			<key>other</key>
+			<string>%d files</string>
+		</dict>
+	</dict>
+</dict>
+</plist>`), sources)
+	if err != nil {
+		t.Fatalf("ImportIOS failed: %v", err)
+	}
+
+	want := map[string]string{
+		"inbox.greet":   "Hallo {name}, {n, number} neu (100%)",
+		"inbox.title":   "Say \"hi\"\n50% off é",
+		"inbox_plain":   "'{'braces'}'",
+		"inbox.summary": "{n, plural, one {# Nachricht} other {# Nachrichten}} für {name} (100%)",
+		"inbox.unknown": "{files, plural, one {# file} other {# files}}",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("ImportIOS =\n%q\nwant\n%q", values, want)
+	}
+
+	for _, bad := range []string{`"a" = "b"`, `"a" "b";`, `"a = "b";`, `/* open`} {
+		if _, err := ImportIOS([]byte(bad), nil, nil); err == nil {
+			t.Errorf("ImportIOS(%q) should fail", bad)
+		}
+	}
+}
+
+func TestIOSRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"a": "Hello {name}, you have {n, number} new",
+		"b": "You have {n, plural, one {# message} other {# messages}} and {m, plural, one {# call} other {# calls}}",
+		"c": "{g, select, female {She} other {They}}",
+		"d": "Don't go",
+		"e": "it's {n, number}",
+	}
+	sources := map[string]string{"a": "{n, number} new for {name}", "e": "it's {n, number}"}
+	stringsFile, stringsdict := ExportIOS(values, sources)
+	got, err := ImportIOS(stringsFile, stringsdict, sources)
+	if err != nil {
+		t.Fatalf("ImportIOS failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("Round trip =\n%q\nwant\n%q\nvia\n%s\n%s", got, values, stringsFile, stringsdict)
+	}
+}
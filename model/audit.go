@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	EntityLanguage      = "language"
+	EntityLanguageKey   = "language_key"
+	EntityLanguageValue = "language_value"
+	EntityComment       = "comment"
+
+	OperationInsert  = "insert"
+	OperationUpdate  = "update"
+	OperationDelete  = "delete"
+	OperationRestore = "restore" // Taken back out of the trash
+	OperationPurge   = "purge"   // Removed from the trash for good
+)
+
+type AuditEntry struct {
+	Uuid       string    // Audit entry ID
+	Timestamp  time.Time // When the change happened
+	Actor      string    // Who made the change
+	Entity     string    // e.g., EntityLanguageValue
+	EntityUuid string    // UUID of the changed record
+	Operation  string    // e.g., OperationUpdate
+	Previous   any       // Record before the change, nil on insert
+	Current    any       // Record after the change, nil on delete
+}
+
+type AuditQuery struct {
+	Entity     string    // Optional, matches AuditEntry.Entity
+	EntityUuid string    // Optional, matches AuditEntry.EntityUuid
+	Actor      string    // Optional, matches AuditEntry.Actor
+	From       time.Time // Optional, inclusive lower bound
+	To         time.Time // Optional, exclusive upper bound
+}
@@ -0,0 +1,23 @@
+package model
+
+type BatchOperation struct {
+	Entity    string // EntityLanguage, EntityLanguageKey or EntityLanguageValue
+	Operation string // OperationInsert, OperationUpdate or OperationDelete
+	Record    any    // Language, LanguageKey or LanguageValue matching Entity
+}
+
+type BatchRequest struct {
+	Operations []BatchOperation
+}
+
+type BatchResult struct {
+	Index  int    // Position of the operation in the request
+	Status int    // 200 when applied, otherwise the reason it was not
+	Error  string // Set when Status is not 200
+	Data   any    // Stored record after the operation, or the deleted record
+}
+
+type BatchResponse struct {
+	Applied bool // False when any operation failed and the whole batch was rolled back
+	Results []BatchResult
+}
@@ -0,0 +1,28 @@
+package model
+
+type BundleRequest struct {
+	Prefix        string   // Language.Prefix, e.g., "de-DE"
+	Namespaces    []string // Optional, only keys in these namespaces, e.g., ["checkout.payment"]
+	IncludeDrafts bool     // Also serve values that are not approved yet, for preview environments
+}
+
+type Bundle struct {
+	Prefix     string
+	Values     map[string]string // Translated text by LanguageKey.Value
+	Deprecated map[string]string // Replacement LanguageKey.Value by deprecated LanguageKey.Value, empty when there is none
+}
+
+type ResolveRequest struct {
+	Prefix        string   // Language.Prefix, e.g., "de-DE"
+	Keys          []string // LanguageKey.Value of each key to resolve
+	IncludeDrafts bool     // Also serve values that are not approved yet, for preview environments
+}
+
+type ResolveResult struct {
+	Key        string // As requested
+	Found      bool   // Whether a servable value exists
+	Value      string // Translated text, empty when not found
+	RenamedTo  string // Current LanguageKey.Value when Key is an alias of a renamed key
+	ReplacedBy string // LanguageKey.Value served instead when Key is deprecated
+	Warning    string // Set when Key is deprecated, e.g., `key "btn.ok" is deprecated, use "common.ok"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+type Comment struct {
+	Uuid        string // Comment ID
+	FirstInsert time.Time
+	LastUpdate  time.Time
+	Revision    int    // Incremented on every update, used for optimistic concurrency
+	Entity      string // EntityLanguageKey or EntityLanguageValue
+	EntityUuid  string // UUID of the commented key or value
+	UuidParent  string // Comment this one replies to, empty when it starts a thread
+	Author      string // Actor who wrote the comment; set on insert
+	Text        string // e.g., "Is 'Post' a verb or a noun here?"
+	Resolved    bool   // Set once the question has been answered
+}
+
+type CommentQuery struct {
+	Entity     string // Optional, matches Comment.Entity
+	EntityUuid string // Optional, matches Comment.EntityUuid
+	Unresolved bool   // Only comments that are not resolved yet
+}
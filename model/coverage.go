@@ -0,0 +1,33 @@
+package model
+
+const (
+	CoverageTranslated = "translated"
+	CoverageMissing    = "missing"
+	CoverageStale      = "stale"
+)
+
+type CoverageQuery struct {
+	UuidLanguage string // Optional, report only this language
+}
+
+type LanguageCoverage struct {
+	UuidLanguage string
+	Prefix       string        // e.g., "ja-JP"
+	Total        int           // Number of keys
+	Translated   int           // Keys with an up-to-date value
+	Missing      int           // Keys without a value, or with an empty one
+	Stale        int           // Keys whose value needs review because the source value changed
+	Percent      float64       // Translated / Total * 100, 100 when there are no keys
+	MissingKeys  []LanguageKey // Keys counted in Missing, sorted by Value; empty in a CoverageMatrix
+}
+
+type CoverageRow struct {
+	UuidLanguageKey string
+	Key             string            // LanguageKey.Value
+	States          map[string]string // CoverageTranslated, CoverageMissing or CoverageStale by Language UUID
+}
+
+type CoverageMatrix struct {
+	Languages []LanguageCoverage // Per-language totals, sorted by Prefix
+	Rows      []CoverageRow      // One row per key, sorted by Key
+}
@@ -0,0 +1,45 @@
+package model
+
+type ExportRequest struct {
+	Prefix        string // Language.Prefix, e.g., "de-DE"; empty for every language
+	IncludeDrafts bool   // Also export values that are not approved yet
+}
+
+type ExportFile struct {
+	Path string // Where the file belongs, e.g., "de-DE/translation.json"
+	Data []byte
+}
+
+type XliffExportRequest struct {
+	Source  string // Language.Prefix of the source, the configured source language when empty
+	Target  string // Language.Prefix of the target, e.g., "de-DE"
+	Version string // XLIFF version, "1.2" (default) or "2.0"
+}
+
+type ImportRequest struct {
+	Prefix  string // Language.Prefix of the file, e.g., "de-DE"; XLIFF files may leave it to the file
+	Data    []byte // File contents
+	Plurals []byte // Optional companion file holding plurals, e.g., the .stringsdict of Localizable.strings
+	DryRun  bool   // Only report the changes the import would make
+}
+
+type ImportChange struct {
+	Key       string // LanguageKey.Value, created when it does not exist yet
+	Operation string // OperationInsert or OperationUpdate of the value
+	Previous  string // Stored text, empty on insert
+	Current   string // Imported text
+}
+
+type ImportResult struct {
+	Applied   bool             // False for a dry run
+	Changes   []ImportChange   // Sorted by Key, unchanged values are left out
+	Skipped   []string         // Entries the import left alone, by gettext msgid or XLIFF unit ID
+	Conflicts []ImportConflict // Targets not applied because the stored data changed since export
+}
+
+type ImportConflict struct {
+	Key      string // LanguageKey.Value
+	Reason   string // e.g., "target changed since export"
+	Current  string // Stored text
+	Imported string // Text of the file
+}
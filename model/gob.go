@@ -5,6 +5,7 @@ import (
 	"encoding/gob"
 	"reflect"
 	"sync"
+	"time"
 )
 
 func init() {
@@ -12,8 +13,60 @@ func init() {
 	PreloadGob([]Language{})
 	PreloadGob(LanguageKey{})
 	PreloadGob([]LanguageKey{})
+	PreloadGob(LanguageKeyQuery{})
+	PreloadGob(LanguageKeyRename{})
+	PreloadGob(LanguageKeyAlias{})
+	PreloadGob([]LanguageKeyAlias{})
+	PreloadGob(NamespaceRequest{})
+	PreloadGob(Namespace{})
+	PreloadGob([]Namespace{})
+	PreloadGob(NamespaceRename{})
 	PreloadGob(LanguageValue{})
 	PreloadGob([]LanguageValue{})
+	PreloadGob(LanguageValueRevision{})
+	PreloadGob([]LanguageValueRevision{})
+	PreloadGob(LanguageValueRevisionRequest{})
+	PreloadGob(LanguageValueFormatRequest{})
+	PreloadGob(LanguageValueQuery{})
+	PreloadGob(LanguageValueTransition{})
+	PreloadGob(time.Time{}) // allowed as a format argument
+	PreloadGob(DiffSegment{})
+	PreloadGob([]DiffSegment{})
+	PreloadGob(BatchOperation{})
+	PreloadGob(BatchRequest{})
+	PreloadGob(BatchResult{})
+	PreloadGob(BatchResponse{})
+	PreloadGob(LanguageValueUpsert{})
+	PreloadGob([]LanguageValueUpsert{})
+	PreloadGob(UpsertResult{})
+	PreloadGob([]UpsertResult{})
+	PreloadGob(AuditEntry{})
+	PreloadGob([]AuditEntry{})
+	PreloadGob(AuditQuery{})
+	PreloadGob(Comment{})
+	PreloadGob([]Comment{})
+	PreloadGob(CommentQuery{})
+	PreloadGob(QAIssue{})
+	PreloadGob([]QAIssue{})
+	PreloadGob(QAQuery{})
+	PreloadGob(CoverageQuery{})
+	PreloadGob(LanguageCoverage{})
+	PreloadGob([]LanguageCoverage{})
+	PreloadGob(CoverageRow{})
+	PreloadGob(CoverageMatrix{})
+	PreloadGob(BundleRequest{})
+	PreloadGob(Bundle{})
+	PreloadGob(ResolveRequest{})
+	PreloadGob(ResolveResult{})
+	PreloadGob([]ResolveResult{})
+	PreloadGob(ExportRequest{})
+	PreloadGob(ExportFile{})
+	PreloadGob([]ExportFile{})
+	PreloadGob(ImportRequest{})
+	PreloadGob(ImportChange{})
+	PreloadGob(ImportResult{})
+	PreloadGob(ImportConflict{})
+	PreloadGob(XliffExportRequest{})
 }
 
 var (
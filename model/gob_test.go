@@ -57,6 +57,7 @@ func TestGobEncodingDecodingLanguage(t *testing.T) {
 		Uuid:        id,
 		FirstInsert: now,
 		LastUpdate:  now,
+		Revision:    3,
 		Prefix:      prefix,
 		Lang:        lang,
 		Title:       title,
@@ -84,6 +85,9 @@ func TestGobEncodingDecodingLanguage(t *testing.T) {
 	if !decoded.LastUpdate.Equal(now) {
 		t.Errorf("LastUpdate mismatch: got %v, want %v", decoded.LastUpdate, now)
 	}
+	if decoded.Revision != 3 {
+		t.Errorf("Revision mismatch: got %d, want %d", decoded.Revision, 3)
+	}
 	if decoded.Prefix != prefix {
 		t.Errorf("Prefix mismatch: got %s, want %s", decoded.Prefix, prefix)
 	}
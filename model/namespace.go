@@ -0,0 +1,16 @@
+package model
+
+type NamespaceRequest struct {
+	Prefix string // Dotted key path, e.g., "checkout.payment"; empty for the root
+}
+
+type Namespace struct {
+	Path string // Dotted key path, e.g., "checkout.payment"
+	Keys int    // Keys at or below Path
+}
+
+type NamespaceRename struct {
+	From  string // Namespace to rename, e.g., "btn"
+	To    string // New path of the namespace, e.g., "common.actions"
+	Alias bool   // Keep the old key strings resolving for a migration window
+}
@@ -0,0 +1,14 @@
+package model
+
+type QAIssue struct {
+	UuidLanguageValue string // The translation with the problem
+	UuidLanguage      string // FK to Language
+	UuidLanguageKey   string // FK to LanguageKey
+	Check             string // e.g., "placeholders" or "markup"
+	Message           string // e.g., "missing placeholder {name}"
+}
+
+type QAQuery struct {
+	UuidLanguage    string // Optional, only check translations into this language
+	UuidLanguageKey string // Optional, only check translations of this key
+}
@@ -8,25 +8,111 @@ type Language struct {
 	Uuid        string    // Language UUID
 	FirstInsert time.Time // Timestamp of first insert
 	LastUpdate  time.Time // Timestamp of last update
+	Revision    int       // Incremented on every update, used for optimistic concurrency
 	Prefix      string    // e.g., "en-US"
 	Lang        string    // e.g., "English"
 	Title       string    // e.g., "English" (native name)
 	Img         string    // e.g., "/static/img/flags/us.png"
 	MonthsShort string    // e.g., "Jan,Feb,Mar,Apr,..."
+
+	PluralCategories  string // CLDR cardinal categories derived from Prefix, e.g., "one,other"
+	OrdinalCategories string // CLDR ordinal categories derived from Prefix, e.g., "one,two,few,other"
+
+	Deleted time.Time // Set by the store while the record is in the trash
 }
 
 type LanguageKey struct {
 	Uuid        string // Key ID (UUID)
 	FirstInsert time.Time
 	LastUpdate  time.Time
-	Value       string // Semantic key string (e.g., "hello")
+	Revision    int       // Incremented on every update, used for optimistic concurrency
+	Value       string    // Semantic key string (e.g., "hello")
+	Description string    // What the text is for, written by developers for translators
+	Context     string    // Where the text appears, e.g., "Button on the checkout page"
+	MaxLength   int       // Maximum characters of a translation, 0 when unlimited
+	Tags        []string  // e.g., ["checkout", "mobile"]
+	SourceFile  string    // File that uses the key, e.g., "web/src/checkout/Pay.tsx:42"
+	Deprecated  bool      // Kept for old app versions, new code should not use the key
+	ReplacedBy  string    // Optional, FK to the LanguageKey served instead of a deprecated key
+	Deleted     time.Time // Set by the store while the record is in the trash
+}
+
+type LanguageKeyRename struct {
+	From  string // Current LanguageKey.Value, e.g., "btn.ok"
+	To    string // New LanguageKey.Value, e.g., "common.actions.ok"
+	Alias bool   // Keep From resolving to the renamed key for a migration window
+}
+
+type LanguageKeyAlias struct {
+	Alias           string    // Former LanguageKey.Value, e.g., "btn.ok"
+	UuidLanguageKey string    // FK to the renamed LanguageKey
+	Expires         time.Time // Alias stops resolving after this time
+}
+
+type LanguageKeyQuery struct {
+	Tags []string // Optional, keys must carry every one of these tags
 }
 
 type LanguageValue struct {
 	Uuid            string // Value row ID
 	FirstInsert     time.Time
 	LastUpdate      time.Time
-	UuidLanguage    string // FK to Language
-	UuidLanguageKey string // FK to LanguageKey
-	Value           string // Translated text
+	Revision        int       // Incremented on every update and status change, used for optimistic concurrency
+	UuidLanguage    string    // FK to Language
+	UuidLanguageKey string    // FK to LanguageKey
+	Value           string    // Translated text
+	SourceRevision  int       // Revision of the source-language value this translation was made against; set by the store
+	NeedsReview     bool      // Set by the store when the source value changes, cleared when this value is saved again or approved
+	Status          string    // Workflow state, e.g., ValueStatusApproved; changed through transitions, reset to draft when Value changes
+	StatusComment   string    // Comment given with the last transition, e.g., why a value was rejected
+	Deleted         time.Time // Set by the store while the record is in the trash
+}
+
+const (
+	ValueStatusDraft       = "draft"
+	ValueStatusNeedsReview = "needs_review"
+	ValueStatusApproved    = "approved"
+	ValueStatusPublished   = "published"
+)
+
+type LanguageValueTransition struct {
+	Uuid     string // LanguageValue UUID
+	Revision int    // Optional, must match the stored revision when set
+	Comment  string // Optional, stored as LanguageValue.StatusComment
+}
+
+type LanguageValueQuery struct {
+	UuidLanguage    string // Optional, matches LanguageValue.UuidLanguage
+	UuidLanguageKey string // Optional, matches LanguageValue.UuidLanguageKey
+	NeedsReview     bool   // Only values whose source changed since they were translated
+	Status          string // Optional, matches LanguageValue.Status
+}
+
+type LanguageValueRevision struct {
+	Revision  int       // Revision of the value it was stored at, starting at 1; status changes add one too
+	Timestamp time.Time // When the revision was stored
+	Value     string    // Translated text at this revision
+}
+
+type LanguageValueRevisionRequest struct {
+	Uuid     string // LanguageValue UUID
+	Revision int    // Revision to act upon (revert), or the older side of a diff
+	Other    int    // Newer side of a diff
+	Expected int    // Optional for a revert, must match the stored revision when set
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+type DiffSegment struct {
+	Operation string // DiffEqual, DiffInsert or DiffDelete
+	Text      string
+}
+
+type LanguageValueFormatRequest struct {
+	Uuid string         // LanguageValue UUID
+	Args map[string]any // ICU MessageFormat arguments by name
 }
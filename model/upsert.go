@@ -0,0 +1,21 @@
+package model
+
+const (
+	UpsertCreated   = "created"
+	UpsertChanged   = "changed"
+	UpsertUnchanged = "unchanged"
+)
+
+// LanguageValueUpsert identifies a LanguageValue by natural identifiers instead of UUIDs.
+type LanguageValueUpsert struct {
+	Prefix string // Language.Prefix, e.g., "de-DE"
+	Key    string // LanguageKey.Value, e.g., "checkout.button.pay"
+	Value  string // Translated text
+}
+
+type UpsertResult struct {
+	Outcome  string // UpsertCreated, UpsertChanged or UpsertUnchanged; empty when Error is set
+	Error    string
+	Previous any // Stored record before the upsert, nil when created
+	Data     any // Stored record after the upsert
+}
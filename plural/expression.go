@@ -0,0 +1,157 @@
+package plural
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// integerSamples are the whole numbers tried to find the categories integers can fall into:
+// every rule in CLDR distinguishes them by n % 100, n % 1000 or n % 1000000.
+func integerSamples() []int64 {
+	out := make([]int64, 0, 1002)
+	for n := int64(0); n <= 1000; n++ {
+		out = append(out, n)
+	}
+	return append(out, 1000000)
+}
+
+// IntegerExpression returns the cardinal categories whole numbers fall into, in CLDR order,
+// and a C expression of n giving the index of the category of n in that list, as gettext
+// Plural-Forms headers need, e.g., "(n == 1) ? 0 : 1" for ["one", "other"].
+func (r *Rules) IntegerExpression() ([]string, string) {
+	reachable := make(map[string]bool)
+	for _, n := range integerSamples() {
+		reachable[r.Cardinal(Operands{N: float64(n), I: n})] = true
+	}
+	var cats []string
+	for _, c := range categoryOrder {
+		if reachable[c] {
+			cats = append(cats, c)
+		}
+	}
+	index := make(map[string]int, len(cats))
+	for i, c := range cats {
+		index[c] = i
+	}
+
+	// Rules are tried in order; whatever none matches falls into the last category.
+	var branches []string
+	for _, rl := range r.cardinal {
+		i, ok := index[rl.category]
+		if !ok || i == len(cats)-1 {
+			continue
+		}
+		expr, always, never := rl.condition.integerExpression()
+		if never {
+			continue
+		}
+		if always {
+			branches = append(branches, strconv.Itoa(i))
+			break
+		}
+		branches = append(branches, fmt.Sprintf("%s ? %d", parenthesize(expr), i))
+	}
+
+	branches = append(branches, strconv.Itoa(len(cats)-1))
+	return cats, strings.Join(branches, " : ")
+}
+
+// integerExpression renders c as a C expression for whole numbers, where n and i are the
+// number and every fraction or exponent operand is 0. Relations that are then constant are
+// folded away; always and never report a condition that folded completely.
+func (c condition) integerExpression() (expr string, always, never bool) {
+	var ors []string
+	for _, and := range c {
+		var ands []string
+		matches := true
+		for _, rel := range and {
+			e, constant, value := rel.integerExpression()
+			if constant {
+				if !value {
+					matches = false
+					break
+				}
+				continue
+			}
+			ands = append(ands, e)
+		}
+
+		if !matches {
+			continue
+		}
+		if len(ands) == 0 {
+			return "", true, false
+		}
+		ors = append(ors, strings.Join(ands, " && "))
+	}
+	if len(ors) == 0 {
+		return "", false, true
+	}
+	if len(ors) == 1 {
+		return ors[0], false, false
+	}
+	for i, o := range ors {
+		if strings.Contains(o, "&&") {
+			ors[i] = "(" + o + ")"
+		}
+	}
+	return strings.Join(ors, " || "), false, false
+}
+
+func (r relation) integerExpression() (expr string, constant, value bool) {
+	if r.operand != 'n' && r.operand != 'i' {
+		in := false
+		for _, rg := range r.ranges {
+			in = in || rg.from <= 0 && 0 <= rg.to
+		}
+		return "", true, in != r.negate
+	}
+
+	x := "n"
+	if r.mod != 0 {
+		x = fmt.Sprintf("n %% %d", r.mod)
+	}
+	var parts []string
+	for _, rg := range r.ranges {
+		switch {
+		case rg.from == rg.to && r.negate:
+			parts = append(parts, fmt.Sprintf("%s != %d", x, rg.from))
+		case rg.from == rg.to:
+			parts = append(parts, fmt.Sprintf("%s == %d", x, rg.from))
+		case r.negate:
+			parts = append(parts, fmt.Sprintf("(%s < %d || %s > %d)", x, rg.from, x, rg.to))
+		default:
+			parts = append(parts, fmt.Sprintf("%s >= %d && %s <= %d", x, rg.from, x, rg.to))
+		}
+	}
+	if r.negate {
+		return strings.Join(parts, " && "), false, false
+	}
+	if len(parts) == 1 {
+		return parts[0], false, false
+	}
+	return "(" + strings.Join(parts, " || ") + ")", false, false
+}
+
+// parenthesize wraps expr in parentheses unless a pair already encloses all of it.
+func parenthesize(expr string) string {
+	if strings.HasPrefix(expr, "(") {
+		depth := 0
+		for i, c := range expr {
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				if i == len(expr)-1 {
+					return expr
+				}
+				break
+			}
+		}
+	}
+	return "(" + expr + ")"
+}
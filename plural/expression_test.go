@@ -0,0 +1,26 @@
+package plural
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIntegerExpression(t *testing.T) {
+	tests := []struct {
+		locale string
+		cats   []string
+		expr   string
+	}{
+		{"ja", []string{Other}, "0"},
+		{"en", []string{One, Other}, "(n == 1) ? 0 : 1"},
+		{"fr", []string{One, Many, Other}, "(n == 0 || n == 1) ? 0 : (n != 0 && n % 1000000 == 0) ? 1 : 2"},
+		{"ru", []string{One, Few, Many}, "(n % 10 == 1 && n % 100 != 11) ? 0 : (n % 10 >= 2 && n % 10 <= 4 && (n % 100 < 12 || n % 100 > 14)) ? 1 : 2"},
+		{"cs", []string{One, Few, Other}, "(n == 1) ? 0 : (n >= 2 && n <= 4) ? 1 : 2"},
+	}
+	for _, tt := range tests {
+		cats, expr := ForLocale(tt.locale).IntegerExpression()
+		if !reflect.DeepEqual(cats, tt.cats) || expr != tt.expr {
+			t.Errorf("IntegerExpression(%s) = %v, %q; want %v, %q", tt.locale, cats, expr, tt.cats, tt.expr)
+		}
+	}
+}
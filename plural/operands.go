@@ -0,0 +1,85 @@
+package plural
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Operands are the CLDR plural operands of a decimal number. They depend on how the number
+// is written: "1" and "1.0" have the same value but different V, and can fall into
+// different categories.
+type Operands struct {
+	N float64 // Absolute value
+	I int64   // Integer digits
+	V int     // Number of visible fraction digits, with trailing zeros
+	W int     // Number of visible fraction digits, without trailing zeros
+	F int64   // Visible fraction digits, with trailing zeros
+	T int64   // Visible fraction digits, without trailing zeros
+	E int     // Exponent of compact decimal notation, always 0 here
+}
+
+// NewOperands returns the operands of a decimal string such as "3", "-1.50" or "0.1".
+func NewOperands(s string) (Operands, error) {
+	s = strings.TrimSpace(s)
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(n, 0) || math.IsNaN(n) || strings.ContainsAny(s, "eEnNxXpP_") {
+		return Operands{}, fmt.Errorf("plural: invalid number %q", s)
+	}
+
+	s = strings.TrimLeft(s, "+-")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	op := Operands{N: math.Abs(n)}
+	if intPart != "" {
+		if op.I, err = strconv.ParseInt(intPart, 10, 64); err != nil {
+			// Too large for int64: keep the low digits, which is all mod arithmetic needs.
+			op.I, _ = strconv.ParseInt(intPart[len(intPart)-18:], 10, 64)
+		}
+	}
+	if fracPart != "" {
+		trimmed := strings.TrimRight(fracPart, "0")
+		op.V, op.W = len(fracPart), len(trimmed)
+		op.F = fractionDigits(fracPart)
+		op.T = fractionDigits(trimmed)
+	}
+	return op, nil
+}
+
+// FloatOperands returns the operands of n written in its shortest decimal form, so 1.0 has no
+// visible fraction digits. Use NewOperands to keep trailing zeros.
+func FloatOperands(n float64) Operands {
+	op, _ := NewOperands(strconv.FormatFloat(n, 'f', -1, 64))
+	return op
+}
+
+func fractionDigits(s string) int64 {
+	if len(s) > 18 {
+		s = s[:18]
+	}
+	if s == "" {
+		return 0
+	}
+	v, _ := strconv.ParseInt(s, 10, 64)
+	return v
+}
+
+func (op Operands) value(operand byte) (float64, bool) {
+	switch operand {
+	case 'n':
+		return op.N, true
+	case 'i':
+		return float64(op.I), true
+	case 'v':
+		return float64(op.V), true
+	case 'w':
+		return float64(op.W), true
+	case 'f':
+		return float64(op.F), true
+	case 't':
+		return float64(op.T), true
+	case 'c', 'e':
+		return float64(op.E), true
+	}
+	return 0, false
+}
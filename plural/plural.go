@@ -0,0 +1,187 @@
+// Package plural selects CLDR plural categories for numbers, using the plural rules embedded
+// from the Unicode CLDR supplemental data.
+package plural
+
+import (
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Categories in CLDR order. Every locale has "other"; most use only a subset of the rest.
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+var categoryOrder = []string{Zero, One, Two, Few, Many, Other}
+
+//go:embed plurals.xml
+var pluralsXML []byte
+
+// Rules are the cardinal and ordinal plural rules of one locale.
+type Rules struct {
+	Locale   string // CLDR locale the rules were found under, "root" when none matched
+	cardinal []rule
+	ordinal  []rule
+}
+
+type rule struct {
+	category  string
+	condition condition
+}
+
+var (
+	loadOnce sync.Once
+	loadErr  error
+	cardinal map[string][]rule
+	ordinal  map[string][]rule
+)
+
+type supplementalData struct {
+	Plurals []struct {
+		Type  string `xml:"type,attr"`
+		Rules []struct {
+			Locales string `xml:"locales,attr"`
+			Rules   []struct {
+				Count string `xml:"count,attr"`
+				Rule  string `xml:",chardata"`
+			} `xml:"pluralRule"`
+		} `xml:"pluralRules"`
+	} `xml:"plurals"`
+}
+
+func load() {
+	var data supplementalData
+	if loadErr = xml.Unmarshal(pluralsXML, &data); loadErr != nil {
+		return
+	}
+
+	cardinal = make(map[string][]rule)
+	ordinal = make(map[string][]rule)
+	for _, plurals := range data.Plurals {
+		target := cardinal
+		if plurals.Type == "ordinal" {
+			target = ordinal
+		}
+		for _, set := range plurals.Rules {
+			var rules []rule
+			for _, r := range set.Rules {
+				c, err := compile(r.Rule)
+				if err != nil {
+					loadErr = fmt.Errorf("plural: %s rule %q for %q: %w", plurals.Type, r.Count, set.Locales, err)
+					return
+				}
+				rules = append(rules, rule{category: r.Count, condition: c})
+			}
+			for _, locale := range strings.Fields(set.Locales) {
+				target[locale] = rules
+			}
+		}
+	}
+}
+
+// ForLocale returns the rules for a BCP 47 tag or language prefix such as "de", "pt-PT" or
+// "sr_Latn_RS". Subtags are dropped from the right until a CLDR locale matches; locales
+// without rules get the root rules, under which every number is "other".
+func ForLocale(locale string) *Rules {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		panic(loadErr)
+	}
+
+	parts := strings.FieldsFunc(locale, func(r rune) bool { return r == '-' || r == '_' })
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part) // region
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:]) // script
+		}
+	}
+	for i := len(parts); i > 0; i-- {
+		tag := strings.Join(parts[:i], "_")
+		c, hasCardinal := cardinal[tag]
+		o, hasOrdinal := ordinal[tag]
+		if hasCardinal || hasOrdinal {
+			if !hasCardinal && i > 1 {
+				c = cardinal[parts[0]]
+			}
+			if !hasOrdinal && i > 1 {
+				o = ordinal[parts[0]]
+			}
+			return &Rules{Locale: tag, cardinal: c, ordinal: o}
+		}
+	}
+	return &Rules{Locale: "root"}
+}
+
+// Cardinal returns the category of n for counting ("1 file", "2 files").
+func (r *Rules) Cardinal(n Operands) string {
+	return selectRule(r.cardinal, n)
+}
+
+// Ordinal returns the category of n for ranking ("1st", "2nd").
+func (r *Rules) Ordinal(n Operands) string {
+	return selectRule(r.ordinal, n)
+}
+
+// CardinalCategories lists the cardinal categories the locale distinguishes, in CLDR order.
+func (r *Rules) CardinalCategories() []string {
+	return categories(r.cardinal)
+}
+
+// OrdinalCategories lists the ordinal categories the locale distinguishes, in CLDR order.
+func (r *Rules) OrdinalCategories() []string {
+	return categories(r.ordinal)
+}
+
+// CountCategories lists the cardinal categories whole numbers below one million fall into, in
+// CLDR order: the ones a message counting things has to word differently. Categories only
+// exact millions or fractions reach, such as "many" in French, fall back to "other".
+func (r *Rules) CountCategories() []string {
+	reached := map[string]bool{Other: true}
+	for _, n := range integerSamples() {
+		if n < 1000000 {
+			reached[r.Cardinal(Operands{N: float64(n), I: n})] = true
+		}
+	}
+	var out []string
+	for _, c := range categoryOrder {
+		if reached[c] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func selectRule(rules []rule, n Operands) string {
+	for _, r := range rules {
+		if r.condition.match(n) {
+			return r.category
+		}
+	}
+	return Other
+}
+
+func categories(rules []rule) []string {
+	used := map[string]bool{Other: true}
+	for _, r := range rules {
+		used[r.category] = true
+	}
+	var out []string
+	for _, c := range categoryOrder {
+		if used[c] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
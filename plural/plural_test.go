@@ -0,0 +1,186 @@
+package plural
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForLocale_AllRulesCompile(t *testing.T) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		t.Fatalf("embedded rules failed to load: %v", loadErr)
+	}
+	if len(cardinal) < 100 || len(ordinal) < 50 {
+		t.Errorf("expected rules for many locales, got %d cardinal and %d ordinal", len(cardinal), len(ordinal))
+	}
+}
+
+func TestForLocale_Resolution(t *testing.T) {
+	tests := map[string]string{
+		"en":         "en",
+		"en-US":      "en",
+		"EN_gb":      "en",
+		"pt-PT":      "pt_PT",
+		"pt-pt":      "pt_PT",
+		"pt-BR":      "pt",
+		"sr-Latn-RS": "sr",
+		"zh-Hant":    "zh",
+		"xx":         "root",
+		"":           "root",
+	}
+	for locale, want := range tests {
+		if got := ForLocale(locale).Locale; got != want {
+			t.Errorf("ForLocale(%q).Locale = %q, want %q", locale, got, want)
+		}
+	}
+
+	// pt_PT has its own cardinal rules but shares Portuguese ordinals.
+	if got := ForLocale("pt-PT").OrdinalCategories(); !reflect.DeepEqual(got, []string{Other}) {
+		t.Errorf("pt-PT ordinal categories = %v", got)
+	}
+}
+
+func TestRules_Categories(t *testing.T) {
+	tests := []struct {
+		locale   string
+		cardinal []string
+		ordinal  []string
+		count    []string
+	}{
+		{"en", []string{One, Other}, []string{One, Two, Few, Other}, []string{One, Other}},
+		{"ja", []string{Other}, []string{Other}, []string{Other}},
+		{"fr", []string{One, Many, Other}, []string{One, Other}, []string{One, Other}},
+		{"es", []string{One, Many, Other}, []string{Other}, []string{One, Other}},
+		{"pl", []string{One, Few, Many, Other}, []string{Other}, []string{One, Few, Many, Other}},
+		{"ar", []string{Zero, One, Two, Few, Many, Other}, []string{Other}, []string{Zero, One, Two, Few, Many, Other}},
+		{"cy", []string{Zero, One, Two, Few, Many, Other}, []string{Zero, One, Two, Few, Many, Other}, []string{Zero, One, Two, Few, Many, Other}},
+	}
+	for _, tt := range tests {
+		r := ForLocale(tt.locale)
+		if got := r.CardinalCategories(); !reflect.DeepEqual(got, tt.cardinal) {
+			t.Errorf("%s cardinal categories = %v, want %v", tt.locale, got, tt.cardinal)
+		}
+		if got := r.OrdinalCategories(); !reflect.DeepEqual(got, tt.ordinal) {
+			t.Errorf("%s ordinal categories = %v, want %v", tt.locale, got, tt.ordinal)
+		}
+		if got := r.CountCategories(); !reflect.DeepEqual(got, tt.count) {
+			t.Errorf("%s count categories = %v, want %v", tt.locale, got, tt.count)
+		}
+	}
+}
+
+func TestRules_Cardinal(t *testing.T) {
+	tests := []struct {
+		locale string
+		number string
+		want   string
+	}{
+		{"en", "1", One},
+		{"en", "1.0", Other},
+		{"en", "0", Other},
+		{"en", "2", Other},
+		{"fr", "0", One},
+		{"fr", "1.5", One},
+		{"fr", "2", Other},
+		{"fr", "1000000", Many},
+		{"ru", "1", One},
+		{"ru", "21", One},
+		{"ru", "11", Many},
+		{"ru", "3", Few},
+		{"ru", "24", Few},
+		{"ru", "14", Many},
+		{"ru", "5", Many},
+		{"ru", "1.5", Other},
+		{"pl", "1", One},
+		{"pl", "22", Few},
+		{"pl", "12", Many},
+		{"pl", "21", Many},
+		{"cs", "3", Few},
+		{"cs", "0.5", Many},
+		{"ar", "0", Zero},
+		{"ar", "2", Two},
+		{"ar", "103", Few},
+		{"ar", "111", Many},
+		{"ar", "100", Other},
+		{"lv", "0", Zero},
+		{"lv", "0.1", One},
+		{"lt", "0.5", Many},
+		{"is", "21", One},
+		{"is", "0.1", One},
+		{"is", "11", Other},
+		{"da", "0.1", One},
+		{"ak", "0.5", Other},
+		{"ja", "1", Other},
+	}
+	for _, tt := range tests {
+		op, err := NewOperands(tt.number)
+		if err != nil {
+			t.Fatalf("NewOperands(%q) failed: %v", tt.number, err)
+		}
+		if got := ForLocale(tt.locale).Cardinal(op); got != tt.want {
+			t.Errorf("%s cardinal %s = %q, want %q", tt.locale, tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestRules_Ordinal(t *testing.T) {
+	tests := []struct {
+		locale string
+		number float64
+		want   string
+	}{
+		{"en", 1, One},
+		{"en", 2, Two},
+		{"en", 3, Few},
+		{"en", 4, Other},
+		{"en", 11, Other},
+		{"en", 12, Other},
+		{"en", 21, One},
+		{"en", 103, Few},
+		{"it", 8, Many},
+		{"it", 11, Many},
+		{"it", 2, Other},
+		{"sv", 2, One},
+		{"sv", 12, Other},
+		{"de", 1, Other},
+	}
+	for _, tt := range tests {
+		if got := ForLocale(tt.locale).Ordinal(FloatOperands(tt.number)); got != tt.want {
+			t.Errorf("%s ordinal %v = %q, want %q", tt.locale, tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestNewOperands(t *testing.T) {
+	tests := map[string]Operands{
+		"1":     {N: 1, I: 1},
+		"-1.50": {N: 1.5, I: 1, V: 2, W: 1, F: 50, T: 5},
+		"0.03":  {N: 0.03, V: 2, W: 2, F: 3, T: 3},
+		"1.0":   {N: 1, I: 1, V: 1},
+		"120":   {N: 120, I: 120},
+	}
+	for s, want := range tests {
+		got, err := NewOperands(s)
+		if err != nil {
+			t.Errorf("NewOperands(%q) failed: %v", s, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("NewOperands(%q) = %+v, want %+v", s, got, want)
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "1e3", "NaN", "Inf", "0x10"} {
+		if _, err := NewOperands(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, bad := range []string{"", "n", "x = 1", "n % 0 = 1", "n = a", "n = 3..1"} {
+		if _, err := compile(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
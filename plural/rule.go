@@ -0,0 +1,130 @@
+package plural
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// condition is a compiled CLDR plural rule: relations joined by "and", groups joined by "or".
+//
+//	condition  = and_condition ('or' and_condition)*
+//	and_condition = relation ('and' relation)*
+//	relation   = operand ('%' value)? ('=' | '!=') range_list
+//	range_list = (value | value'..'value) (',' range_list)*
+type condition [][]relation
+
+type relation struct {
+	operand byte
+	mod     int64 // 0 when there is no modulus
+	negate  bool
+	ranges  []valueRange
+}
+
+type valueRange struct {
+	from, to int64
+}
+
+func (c condition) match(op Operands) bool {
+	for _, and := range c {
+		matched := true
+		for _, r := range and {
+			if !r.match(op) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// match tests membership in the range list; like CLDR "in", a value with a fraction is in no
+// range, so "n = 1" is false for 1.5 while "n != 1" is true.
+func (r relation) match(op Operands) bool {
+	x, _ := op.value(r.operand)
+	if r.mod != 0 {
+		x = math.Mod(x, float64(r.mod))
+	}
+
+	in := false
+	if x == math.Trunc(x) {
+		for _, rg := range r.ranges {
+			if x >= float64(rg.from) && x <= float64(rg.to) {
+				in = true
+				break
+			}
+		}
+	}
+	return in != r.negate
+}
+
+// compile parses a rule, ignoring any "@integer"/"@decimal" samples after it.
+func compile(src string) (condition, error) {
+	if i := strings.IndexByte(src, '@'); i >= 0 {
+		src = src[:i]
+	}
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	var c condition
+	for _, or := range strings.Split(src, " or ") {
+		var and []relation
+		for _, rel := range strings.Split(or, " and ") {
+			r, err := compileRelation(strings.TrimSpace(rel))
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, r)
+		}
+		c = append(c, and)
+	}
+	return c, nil
+}
+
+func compileRelation(src string) (relation, error) {
+	var r relation
+
+	expr, list, ok := strings.Cut(src, "!=")
+	if ok {
+		r.negate = true
+	} else if expr, list, ok = strings.Cut(src, "="); !ok {
+		return r, fmt.Errorf("relation %q has no '=' or '!='", src)
+	}
+
+	operand, mod, hasMod := strings.Cut(strings.TrimSpace(expr), "%")
+	operand = strings.TrimSpace(operand)
+	if len(operand) != 1 || strings.IndexByte("nivwftce", operand[0]) < 0 {
+		return r, fmt.Errorf("unknown operand %q", operand)
+	}
+	r.operand = operand[0]
+	if hasMod {
+		m, err := strconv.ParseInt(strings.TrimSpace(mod), 10, 64)
+		if err != nil || m <= 0 {
+			return r, fmt.Errorf("invalid modulus %q", mod)
+		}
+		r.mod = m
+	}
+
+	for _, item := range strings.Split(list, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(item), "..")
+		if !isRange {
+			to = from
+		}
+		lo, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return r, fmt.Errorf("invalid value %q", item)
+		}
+		hi, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || hi < lo {
+			return r, fmt.Errorf("invalid range %q", item)
+		}
+		r.ranges = append(r.ranges, valueRange{from: lo, to: hi})
+	}
+	return r, nil
+}
@@ -0,0 +1,24 @@
+package qa
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Length reports a translation longer than limit characters; a limit of 0 or less means no
+// limit. Messages with plural or select arguments are measured per case, and placeholders
+// count as written, so "{name}" is 6 characters.
+func Length(target string, limit int) []Issue {
+	if limit <= 0 {
+		return nil
+	}
+
+	longest := 0
+	for _, text := range variants(target) {
+		longest = max(longest, utf8.RuneCountInString(text))
+	}
+	if longest <= limit {
+		return nil
+	}
+	return []Issue{{Check: CheckLength, Message: fmt.Sprintf("text is %d characters long, the limit is %d", longest, limit)}}
+}
@@ -0,0 +1,33 @@
+package qa
+
+import "testing"
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		target string
+		limit  int
+		want   string
+	}{
+		{"Pay now", 10, ""},
+		{"Jetzt bezahlen", 10, "text is 14 characters long, the limit is 10"},
+		{"Jetzt bezahlen", 0, ""},
+		{"Größe", 5, ""},
+		{"{n, plural, one {# Datei} other {# Dateien}}", 9, ""},
+		{"{n, plural, one {# Datei} other {# Dateien}}", 8, "text is 9 characters long, the limit is 8"},
+		{"Hi {name}", 8, "text is 9 characters long, the limit is 8"},
+	}
+
+	for _, tt := range tests {
+		issues := Length(tt.target, tt.limit)
+		got := ""
+		if len(issues) > 0 {
+			got = issues[0].Message
+			if issues[0].Check != CheckLength {
+				t.Errorf("unexpected check %q", issues[0].Check)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("Length(%q, %d) = %q, want %q", tt.target, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package qa
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/icu"
+)
+
+// KnownTags are the inline HTML tags translations may contain.
+var KnownTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "br": true, "code": true, "del": true, "em": true,
+	"i": true, "ins": true, "kbd": true, "mark": true, "q": true, "s": true, "small": true,
+	"span": true, "strong": true, "sub": true, "sup": true, "u": true,
+}
+
+// voidTags never have a closing tag.
+var voidTags = map[string]bool{"br": true, "hr": true, "img": true, "wbr": true}
+
+// referenceCase is the plural and select case used to compare the structure of two messages.
+const referenceCase = "other"
+
+var tagPattern = regexp.MustCompile(`<(/?)([A-Za-z][A-Za-z0-9-]*)(?:\s[^<>]*?)?(/?)>`)
+
+// Markup reports unbalanced or unknown tags in target, and tags that are missing or added
+// compared to source. Tags are compared by nesting, not by position, so a translation may
+// reorder them. In messages with plural or select arguments every case is checked for
+// balance, and the structure is compared on the "other" cases.
+func Markup(source, target string) []Issue {
+	var issues []Issue
+	seen := make(map[string]bool)
+	add := func(message string) {
+		if !seen[message] {
+			seen[message] = true
+			issues = append(issues, Issue{Check: CheckMarkup, Message: message})
+		}
+	}
+
+	for _, text := range variants(target) {
+		_, names, problems := scanTags(text)
+		for _, p := range problems {
+			add(p)
+		}
+		for _, name := range names {
+			if !KnownTags[name] {
+				add("unknown tag <" + name + ">")
+			}
+		}
+	}
+
+	want, _, _ := scanTags(render(parseOrText(source), referenceCase))
+	got, _, _ := scanTags(render(parseOrText(target), referenceCase))
+	for _, path := range sortedKeys(want) {
+		if got[path] < want[path] {
+			add("missing " + describePath(path))
+		}
+	}
+	for _, path := range sortedKeys(got) {
+		if got[path] > want[path] {
+			add("unexpected " + describePath(path))
+		}
+	}
+	return issues
+}
+
+// scanTags returns the count of each element by nesting path (e.g., "a/b" for <b> inside <a>),
+// the names of all tags, and balance problems.
+func scanTags(s string) (map[string]int, []string, []string) {
+	paths := make(map[string]int)
+	var names, problems, stack []string
+
+	for _, m := range tagPattern.FindAllStringSubmatch(s, -1) {
+		closing, name, selfClosing := m[1] == "/", strings.ToLower(m[2]), m[3] == "/"
+		names = append(names, name)
+
+		switch {
+		case closing && voidTags[name]:
+		case closing:
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				problems = append(problems, "unexpected closing tag </"+name+">")
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			paths[strings.Join(append(stack[:len(stack):len(stack)], name), "/")]++
+			if !selfClosing && !voidTags[name] {
+				stack = append(stack, name)
+			}
+		}
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		problems = append(problems, "unclosed tag <"+stack[i]+">")
+	}
+	return paths, names, problems
+}
+
+func describePath(path string) string {
+	parts := strings.Split(path, "/")
+	s := "tag <" + parts[len(parts)-1] + ">"
+	if len(parts) > 1 {
+		s += " inside <" + strings.Join(parts[:len(parts)-1], "><") + ">"
+	}
+	return s
+}
+
+// variants renders msg once for every case key it uses, falling back to "other" where a
+// plural or select has no such case.
+func variants(msg string) []string {
+	nodes := parseOrText(msg)
+
+	keys := map[string]bool{referenceCase: true}
+	caseKeys(nodes, keys)
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	out := make([]string, len(sorted))
+	for i, k := range sorted {
+		out[i] = render(nodes, k)
+	}
+	return out
+}
+
+func parseOrText(msg string) []icu.Node {
+	nodes, err := icu.Parse(msg)
+	if err != nil {
+		return []icu.Node{icu.Text{Value: msg}}
+	}
+	return nodes
+}
+
+func caseKeys(nodes []icu.Node, keys map[string]bool) {
+	for _, n := range nodes {
+		for _, c := range cases(n) {
+			keys[c.Key] = true
+			caseKeys(c.Message, keys)
+		}
+	}
+}
+
+// render writes nodes as text, choosing the case named key in every plural and select.
+func render(nodes []icu.Node, key string) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case icu.Text:
+			b.WriteString(n.Value)
+		case icu.Argument:
+			b.WriteString("{" + n.Name + "}")
+		case icu.Pound:
+			b.WriteString("#")
+		case icu.Plural, icu.Select:
+			b.WriteString(render(pick(cases(n), key), key))
+		}
+	}
+	return b.String()
+}
+
+func cases(n icu.Node) []icu.Case {
+	switch n := n.(type) {
+	case icu.Plural:
+		return n.Cases
+	case icu.Select:
+		return n.Cases
+	}
+	return nil
+}
+
+func pick(cases []icu.Case, key string) []icu.Node {
+	var other []icu.Node
+	for _, c := range cases {
+		if c.Key == key {
+			return c.Message
+		}
+		if c.Key == referenceCase {
+			other = c.Message
+		}
+	}
+	return other
+}
@@ -0,0 +1,51 @@
+package qa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkup(t *testing.T) {
+	tests := []struct {
+		source, target string
+		want           []string
+	}{
+		{"Click <a href=\"{url}\">here</a>", "Klicken Sie <a href=\"{url}\">hier</a>", nil},
+		{"<b>Bold</b> and <i>italic</i>", "<i>Kursiv</i> und <b>fett</b>", nil},
+		{"Line<br>break", "Zeilen<br/>umbruch", nil},
+		{"<b>Bold</b>", "<b>Fett", []string{"unclosed tag <b>"}},
+		{"<b>Bold</b>", "Fett</b>", []string{"unexpected closing tag </b>", "missing tag <b>"}},
+		{"<b><i>x</i></b>", "<b><i>x</b></i>", []string{"unexpected closing tag </b>", "unclosed tag <b>"}},
+		{"<a>link</a>", "<a><b>link</b></a>", []string{"unexpected tag <b> inside <a>"}},
+		{"<b>x</b>", "<blink>x</blink>", []string{"unknown tag <blink>", "missing tag <b>", "unexpected tag <blink>"}},
+		{
+			"{n, plural, one {<b>#</b> file} other {<b>#</b> files}}",
+			"{n, plural, one {<b>#</b> plik} few {<b>#</b> pliki} many {<b># plików} other {<b>#</b> pliku}}",
+			[]string{"unclosed tag <b>"},
+		},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, issue := range Markup(tt.source, tt.target) {
+			if issue.Check != CheckMarkup {
+				t.Errorf("unexpected check %q", issue.Check)
+			}
+			got = append(got, issue.Message)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Markup(%q, %q) = %v, want %v", tt.source, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	issues := Check("Hello <b>{name}</b>", "Hallo <b>{nmae}")
+	checks := make(map[string]int)
+	for _, issue := range issues {
+		checks[issue.Check]++
+	}
+	if checks[CheckPlaceholders] != 2 || checks[CheckMarkup] != 1 {
+		t.Errorf("Unexpected issues: %+v", issues)
+	}
+}
@@ -0,0 +1,109 @@
+package qa
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/rah-0/meisterwerk/icu"
+)
+
+// printfVerb matches printf-style placeholders such as %d, %s, %1$s or %.2f, but not %%.
+var printfVerb = regexp.MustCompile(`%(?:\d+\$)?[-+ #0]*\d*(?:\.\d+)?[sdifuxXeEgGcoqvtp]`)
+
+// Placeholders reports ICU arguments and printf verbs that appear in source but not in target,
+// or in target but not in source. ICU arguments are compared by name, so {n} in one text
+// matches {n, plural, ...} in the other; printf verbs are compared with their count.
+func Placeholders(source, target string) []Issue {
+	want := placeholders(source)
+	got := placeholders(target)
+
+	var issues []Issue
+	for _, p := range sortedKeys(want) {
+		if got[p] < want[p] {
+			issues = append(issues, Issue{Check: CheckPlaceholders, Message: "missing placeholder " + p})
+		}
+	}
+	for _, p := range sortedKeys(got) {
+		if got[p] > want[p] {
+			issues = append(issues, Issue{Check: CheckPlaceholders, Message: "unexpected placeholder " + p})
+		}
+	}
+	return issues
+}
+
+// placeholders counts the placeholders of s. Text that is not valid ICU MessageFormat is
+// only scanned for printf verbs.
+func placeholders(s string) map[string]int {
+	nodes, err := icu.Parse(s)
+	if err != nil {
+		out := make(map[string]int)
+		addPrintf(out, s)
+		return out
+	}
+
+	names := make(map[string]bool)
+	out := collect(nodes, names)
+	for name := range names {
+		out["{"+name+"}"] = 1
+	}
+	return out
+}
+
+// collect counts printf verbs in nodes and records argument names. Only one case of a plural
+// or select is ever shown, so a verb counts as often as the case using it most.
+func collect(nodes []icu.Node, names map[string]bool) map[string]int {
+	out := make(map[string]int)
+	for _, n := range nodes {
+		var cases []icu.Case
+		switch n := n.(type) {
+		case icu.Text:
+			addPrintf(out, n.Value)
+		case icu.Argument:
+			names[n.Name] = true
+		case icu.Plural:
+			names[n.Name] = true
+			cases = n.Cases
+		case icu.Select:
+			names[n.Name] = true
+			cases = n.Cases
+		}
+
+		branch := make(map[string]int)
+		for _, c := range cases {
+			for p, count := range collect(c.Message, names) {
+				branch[p] = max(branch[p], count)
+			}
+		}
+		for p, count := range branch {
+			out[p] += count
+		}
+	}
+	return out
+}
+
+func addPrintf(out map[string]int, s string) {
+	for _, loc := range printfVerb.FindAllStringIndex(s, -1) {
+		if escapedPercent(s, loc[0]) {
+			continue
+		}
+		out[s[loc[0]:loc[1]]]++
+	}
+}
+
+// escapedPercent reports whether the % at i is the second half of a %% escape.
+func escapedPercent(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '%'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
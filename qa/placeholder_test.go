@@ -0,0 +1,37 @@
+package qa
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		source, target string
+		want           []string
+	}{
+		{"Hello {name}", "Hallo {name}", nil},
+		{"Hello {name}", "Hallo {nmae}", []string{"missing placeholder {name}", "unexpected placeholder {nmae}"}},
+		{"Hello {name}", "Hallo", []string{"missing placeholder {name}"}},
+		{"{count} files", "{count, plural, one {# Datei} other {# Dateien}}", nil},
+		{"%d of %s", "%s: %d", nil},
+		{"%s and %s", "%s", []string{"missing placeholder %s"}},
+		{"100%% done", "100%% fertig", nil},
+		{"Saved %1$s", "Gespeichert %s", []string{"missing placeholder %1$s", "unexpected placeholder %s"}},
+		{"{n, plural, one {%d item} other {%d items}}", "{n, plural, one {%d plik} few {%d pliki} many {%d plików} other {%d pliku}}", nil},
+		{"Open {", "Öffnen {", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, issue := range Placeholders(tt.source, tt.target) {
+			if issue.Check != CheckPlaceholders {
+				t.Errorf("unexpected check %q", issue.Check)
+			}
+			got = append(got, issue.Message)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Placeholders(%q, %q) = %v, want %v", tt.source, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// Package qa compares a translation with its source text and reports problems a translator
+// is likely to have introduced.
+package qa
+
+// Check names, used as Issue.Check.
+const (
+	CheckPlaceholders = "placeholders"
+	CheckMarkup       = "markup"
+	CheckLength       = "length"
+)
+
+// Issue is one problem found in a translation.
+type Issue struct {
+	Check   string // Name of the check that found it, e.g., CheckPlaceholders
+	Message string // Human readable description, e.g., "missing placeholder {name}"
+}
+
+// Check runs every check on a translation of source.
+func Check(source, target string) []Issue {
+	return append(Placeholders(source, target), Markup(source, target)...)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// AuditStore is an append-only trail of every mutation made through the service.
+type AuditStore struct {
+	mu      sync.RWMutex
+	entries []model.AuditEntry
+}
+
+func NewAuditStore() *AuditStore {
+	return &AuditStore{}
+}
+
+func (s *AuditStore) Record(actor, entity, entityUuid, operation string, previous, current any) model.AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := model.AuditEntry{
+		Uuid:       uuid.NewString(),
+		Timestamp:  time.Now().Truncate(time.Microsecond),
+		Actor:      actor,
+		Entity:     entity,
+		EntityUuid: entityUuid,
+		Operation:  operation,
+		Previous:   previous,
+		Current:    current,
+	}
+	s.entries = append(s.entries, e)
+	return e
+}
+
+// Query returns the entries matching every non-zero field of q, oldest first.
+func (s *AuditStore) Query(q model.AuditQuery) []model.AuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.AuditEntry, 0)
+	for _, e := range s.entries {
+		if q.Entity != "" && e.Entity != q.Entity {
+			continue
+		}
+		if q.EntityUuid != "" && e.EntityUuid != q.EntityUuid {
+			continue
+		}
+		if q.Actor != "" && e.Actor != q.Actor {
+			continue
+		}
+		if !q.From.IsZero() && e.Timestamp.Before(q.From) {
+			continue
+		}
+		if !q.To.IsZero() && !e.Timestamp.Before(q.To) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestAuditStore_RecordAndQuery(t *testing.T) {
+	store := NewAuditStore()
+
+	id := uuid.NewString()
+	previous := model.Language{Uuid: id, Lang: "Old"}
+	current := model.Language{Uuid: id, Lang: "New"}
+
+	e := store.Record("alice", model.EntityLanguage, id, model.OperationUpdate, previous, current)
+	if e.Uuid == "" || e.Timestamp.IsZero() {
+		t.Fatalf("Record did not assign Uuid/Timestamp: %+v", e)
+	}
+
+	got := store.Query(model.AuditQuery{EntityUuid: id})
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(got))
+	}
+	if got[0].Actor != "alice" || got[0].Operation != model.OperationUpdate {
+		t.Errorf("Unexpected entry: %+v", got[0])
+	}
+	if got[0].Previous.(model.Language).Lang != "Old" || got[0].Current.(model.Language).Lang != "New" {
+		t.Errorf("Previous/Current not preserved: %+v", got[0])
+	}
+}
+
+func TestAuditStore_QueryFilters(t *testing.T) {
+	store := NewAuditStore()
+
+	store.Record("alice", model.EntityLanguage, uuid.NewString(), model.OperationInsert, nil, model.Language{})
+	store.Record("bob", model.EntityLanguageKey, uuid.NewString(), model.OperationInsert, nil, model.LanguageKey{})
+	store.Record("alice", model.EntityLanguageValue, uuid.NewString(), model.OperationDelete, model.LanguageValue{}, nil)
+
+	if got := store.Query(model.AuditQuery{}); len(got) != 3 {
+		t.Errorf("Expected 3 entries without filter, got %d", len(got))
+	}
+	if got := store.Query(model.AuditQuery{Actor: "alice"}); len(got) != 2 {
+		t.Errorf("Expected 2 entries for alice, got %d", len(got))
+	}
+	if got := store.Query(model.AuditQuery{Entity: model.EntityLanguageKey}); len(got) != 1 {
+		t.Errorf("Expected 1 language_key entry, got %d", len(got))
+	}
+	if got := store.Query(model.AuditQuery{Actor: "bob", Entity: model.EntityLanguage}); len(got) != 0 {
+		t.Errorf("Expected no entries, got %d", len(got))
+	}
+}
+
+func TestAuditStore_QueryTimeRange(t *testing.T) {
+	store := NewAuditStore()
+
+	store.Record("alice", model.EntityLanguage, uuid.NewString(), model.OperationInsert, nil, model.Language{})
+	time.Sleep(2 * time.Millisecond)
+	middle := time.Now()
+	time.Sleep(2 * time.Millisecond)
+	store.Record("alice", model.EntityLanguage, uuid.NewString(), model.OperationInsert, nil, model.Language{})
+
+	if got := store.Query(model.AuditQuery{From: middle}); len(got) != 1 {
+		t.Errorf("Expected 1 entry after middle, got %d", len(got))
+	}
+	if got := store.Query(model.AuditQuery{To: middle}); len(got) != 1 {
+		t.Errorf("Expected 1 entry before middle, got %d", len(got))
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rah-0/meisterwerk/icu"
+	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
+)
+
+var errBatchRolledBack = errors.New("not applied, batch rolled back")
+
+// batchChange describes one applied operation, used for auditing after the batch commits.
+type batchChange struct {
+	entity    string
+	uuid      string
+	operation string
+	previous  any
+	current   any
+}
+
+type batch struct {
+	languages *LanguageStore
+	keys      *LanguageKeyStore
+	values    *LanguageValueStore
+	undo      []func()
+}
+
+// applyBatch validates every operation before touching any store, then applies them in
+// order while holding the write locks of all three stores. If any operation fails, the
+// ones applied before it are rolled back and nothing is changed.
+func applyBatch(ls *LanguageStore, ks *LanguageKeyStore, vs *LanguageValueStore, ops []model.BatchOperation) (model.BatchResponse, []batchChange) {
+	resp := model.BatchResponse{Results: make([]model.BatchResult, len(ops))}
+	for i := range ops {
+		resp.Results[i].Index = i
+	}
+
+	invalid := false
+	for i, op := range ops {
+		if err := validateBatchOperation(op); err != nil {
+			resp.Results[i].Status = 400
+			resp.Results[i].Error = err.Error()
+			invalid = true
+		}
+	}
+	if invalid {
+		markNotApplied(resp.Results)
+		return resp, nil
+	}
+
+	// Lock order is languages, keys, values everywhere multiple stores are held.
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	b := &batch{languages: ls, keys: ks, values: vs}
+	changes := make([]batchChange, 0, len(ops))
+	for i, op := range ops {
+		change, err := b.apply(op)
+		if err != nil {
+			resp.Results[i].Status = 500
+			var conflict *util.ConflictError
+			if errors.As(err, &conflict) {
+				resp.Results[i].Status = 409
+				resp.Results[i].Data = conflict.Current
+			}
+			resp.Results[i].Error = err.Error()
+			b.rollback()
+			for j := range resp.Results[:i] {
+				resp.Results[j].Data = nil
+			}
+			markNotApplied(resp.Results)
+			return resp, nil
+		}
+
+		resp.Results[i].Status = 200
+		resp.Results[i].Data = change.current
+		if change.operation == model.OperationDelete {
+			resp.Results[i].Data = change.previous
+		}
+		changes = append(changes, change)
+	}
+
+	resp.Applied = true
+	return resp, changes
+}
+
+func validateBatchOperation(op model.BatchOperation) error {
+	switch op.Operation {
+	case model.OperationInsert, model.OperationUpdate, model.OperationDelete:
+	default:
+		return fmt.Errorf("unknown operation %q", op.Operation)
+	}
+
+	var uuid string
+	switch r := op.Record.(type) {
+	case model.Language:
+		if op.Entity != model.EntityLanguage {
+			return fmt.Errorf("record %T does not match entity %q", op.Record, op.Entity)
+		}
+		uuid = r.Uuid
+	case model.LanguageKey:
+		if op.Entity != model.EntityLanguageKey {
+			return fmt.Errorf("record %T does not match entity %q", op.Record, op.Entity)
+		}
+		uuid = r.Uuid
+	case model.LanguageValue:
+		if op.Entity != model.EntityLanguageValue {
+			return fmt.Errorf("record %T does not match entity %q", op.Record, op.Entity)
+		}
+		uuid = r.Uuid
+	default:
+		return fmt.Errorf("unsupported record type %T", op.Record)
+	}
+
+	if op.Operation == model.OperationInsert {
+		_, err := util.UuidEnsure(uuid)
+		return err
+	}
+	if uuid == "" {
+		return errors.New("record has no Uuid")
+	}
+	return nil
+}
+
+// markNotApplied flags every result that does not already carry an error.
+func markNotApplied(results []model.BatchResult) {
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Status = 424
+			results[i].Error = errBatchRolledBack.Error()
+		}
+	}
+}
+
+func (b *batch) apply(op model.BatchOperation) (batchChange, error) {
+	c := batchChange{entity: op.Entity, operation: op.Operation}
+
+	switch r := op.Record.(type) {
+	case model.Language:
+		if op.Operation == model.OperationInsert {
+			id, err := util.UuidEnsure(r.Uuid)
+			if err != nil {
+				return c, err
+			}
+			r.Uuid = id
+		}
+		c.uuid = r.Uuid
+		restore := b.languages.snapshot(r.Uuid)
+		if previous, ok := b.languages.items[r.Uuid]; ok {
+			c.previous = previous
+		}
+
+		var err error
+		switch op.Operation {
+		case model.OperationInsert:
+			_, err = b.languages.insert(r)
+		case model.OperationUpdate:
+			_, err = b.languages.update(r.Uuid, r)
+		case model.OperationDelete:
+			_, err = b.languages.delete(r.Uuid, r.Revision)
+		}
+		if err != nil {
+			return c, err
+		}
+
+		source := b.values.sourceLanguage
+		b.undo = append(b.undo, func() {
+			restore()
+			b.values.sourceLanguage = source
+		})
+		if current, ok := b.languages.items[r.Uuid]; ok {
+			c.current = current
+		}
+		// The source language may have been added, changed or removed for the values that follow.
+		b.values.sourceLanguage = sourceLanguageIn(b.languages)
+
+	case model.LanguageKey:
+		if op.Operation == model.OperationInsert {
+			id, err := util.UuidEnsure(r.Uuid)
+			if err != nil {
+				return c, err
+			}
+			r.Uuid = id
+		}
+		c.uuid = r.Uuid
+		restore := b.keys.snapshot(r.Uuid)
+		if previous, ok := b.keys.items[r.Uuid]; ok {
+			c.previous = previous
+		}
+
+		var err error
+		switch op.Operation {
+		case model.OperationInsert:
+			_, err = b.keys.insert(r)
+		case model.OperationUpdate:
+			_, err = b.keys.update(r.Uuid, r)
+		case model.OperationDelete:
+			_, err = b.keys.delete(r.Uuid, r.Revision)
+		}
+		if err != nil {
+			return c, err
+		}
+
+		b.undo = append(b.undo, restore)
+		if current, ok := b.keys.items[r.Uuid]; ok {
+			c.current = current
+		}
+
+	case model.LanguageValue:
+		if op.Operation == model.OperationInsert {
+			id, err := util.UuidEnsure(r.Uuid)
+			if err != nil {
+				return c, err
+			}
+			r.Uuid = id
+		}
+		c.uuid = r.Uuid
+		if op.Operation != model.OperationDelete {
+			if err := b.checkValue(r); err != nil {
+				return c, err
+			}
+		}
+		restore := b.values.snapshot(r.Uuid, r.UuidLanguageKey)
+		if previous, ok := b.values.items[r.Uuid]; ok {
+			c.previous = previous
+		}
+
+		var err error
+		switch op.Operation {
+		case model.OperationInsert:
+			_, err = b.values.insert(r)
+		case model.OperationUpdate:
+			_, err = b.values.update(r.Uuid, r)
+		case model.OperationDelete:
+			_, err = b.values.delete(r.Uuid, r.Revision)
+		}
+		if err != nil {
+			return c, err
+		}
+
+		b.undo = append(b.undo, restore)
+		if current, ok := b.values.items[r.Uuid]; ok {
+			c.current = current
+		}
+	}
+
+	return c, nil
+}
+
+// checkValue runs the checks of checkLanguageValue against the stores the batch holds locked.
+func (b *batch) checkValue(v model.LanguageValue) error {
+	if lang, ok := b.languages.items[v.UuidLanguage]; ok {
+		if err := icu.ValidatePlurals(v.Value, lang.Prefix); err != nil {
+			return err
+		}
+	}
+	if key, ok := b.keys.items[v.UuidLanguageKey]; ok {
+		if err := checkMaxLength(v.Value, key); err != nil {
+			return err
+		}
+	}
+
+	source := b.values.sourceLanguage
+	if !settings.EnforcePlaceholders || source == "" || v.UuidLanguage == source {
+		return nil
+	}
+	if sourceValue, ok := b.values.findByLanguageKey(source, v.UuidLanguageKey); ok {
+		return matchPlaceholders(sourceValue.Value, v.Value)
+	}
+	return nil
+}
+
+// rollback undoes every applied operation, newest first.
+func (b *batch) rollback() {
+	for i := len(b.undo) - 1; i >= 0; i-- {
+		b.undo[i]()
+	}
+	b.undo = nil
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestApplyBatch(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	lang := model.Language{Uuid: uuid.NewString(), Prefix: "de-DE", Lang: "German"}
+	key := model.LanguageKey{Uuid: uuid.NewString(), Value: "checkout.button.pay"}
+	val := model.LanguageValue{Uuid: uuid.NewString(), UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: "Bezahlen"}
+
+	resp, changes := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: lang},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: key},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: val},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationUpdate, Record: model.LanguageValue{Uuid: val.Uuid, Value: "Jetzt bezahlen"}},
+	})
+
+	if !resp.Applied {
+		t.Fatalf("Expected batch to apply, got %+v", resp.Results)
+	}
+	if len(changes) != 4 {
+		t.Errorf("Expected 4 changes, got %d", len(changes))
+	}
+	for _, r := range resp.Results {
+		if r.Status != 200 {
+			t.Errorf("Operation %d failed: %d %s", r.Index, r.Status, r.Error)
+		}
+	}
+	if got, _ := vs.Get(val.Uuid); got.Value != "Jetzt bezahlen" || got.Revision != 2 {
+		t.Errorf("Unexpected stored value: %+v", got)
+	}
+	if stored, ok := resp.Results[3].Data.(model.LanguageValue); !ok || stored.Revision != 2 {
+		t.Errorf("Expected result to carry stored record, got %+v", resp.Results[3].Data)
+	}
+}
+
+func TestApplyBatch_RollsBackOnFailure(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	existing := model.LanguageKey{Uuid: uuid.NewString(), Value: "common.ok"}
+	ks.Insert(existing)
+	val := model.LanguageValue{Uuid: uuid.NewString(), Value: "OK"}
+	vs.Insert(val)
+
+	lang := model.Language{Uuid: uuid.NewString(), Prefix: "pl-PL"}
+	resp, changes := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: lang},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationUpdate, Record: model.LanguageKey{Uuid: existing.Uuid, Value: "common.okay"}},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationDelete, Record: model.LanguageValue{Uuid: val.Uuid}},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: model.LanguageKey{Uuid: uuid.NewString(), Value: "common.okay"}},
+	})
+
+	if resp.Applied {
+		t.Fatal("Expected batch to be rolled back")
+	}
+	if changes != nil {
+		t.Errorf("Expected no changes, got %d", len(changes))
+	}
+	if resp.Results[3].Status != 500 {
+		t.Errorf("Expected failing operation to report 500, got %d", resp.Results[3].Status)
+	}
+	for _, r := range resp.Results[:3] {
+		if r.Status != 424 {
+			t.Errorf("Expected operation %d to be reported as not applied, got %d", r.Index, r.Status)
+		}
+	}
+
+	if _, err := ls.Get(lang.Uuid); err == nil {
+		t.Error("Inserted language should have been rolled back")
+	}
+	if got, _ := ks.Get(existing.Uuid); got.Value != "common.ok" || got.Revision != 1 {
+		t.Errorf("Updated key should have been rolled back, got %+v", got)
+	}
+	if _, err := ks.GetByValue("common.ok"); err != nil {
+		t.Error("Key value index should have been restored")
+	}
+	if _, err := ks.GetByValue("common.okay"); err == nil {
+		t.Error("Key value index should not keep rolled back value")
+	}
+	if _, err := vs.Get(val.Uuid); err != nil {
+		t.Error("Deleted value should have been restored")
+	}
+	if revs, _ := vs.Revisions(val.Uuid); len(revs) != 1 {
+		t.Errorf("Value revisions should have been restored, got %+v", revs)
+	}
+}
+
+func TestApplyBatch_ValidatesBeforeApplying(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	lang := model.Language{Uuid: uuid.NewString()}
+	resp, _ := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: lang},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: model.LanguageValue{Uuid: uuid.NewString()}},
+		{Entity: model.EntityLanguage, Operation: "upsert", Record: model.Language{Uuid: uuid.NewString()}},
+	})
+
+	if resp.Applied {
+		t.Fatal("Expected invalid batch to be rejected")
+	}
+	if resp.Results[0].Status != 424 || resp.Results[1].Status != 400 || resp.Results[2].Status != 400 {
+		t.Errorf("Unexpected statuses: %+v", resp.Results)
+	}
+	if len(ls.List()) != 0 {
+		t.Error("Nothing should be applied when validation fails")
+	}
+}
+
+func TestApplyBatch_GeneratesUuidOnInsert(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	resp, changes := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: model.LanguageKey{Value: "batch.generated"}},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: model.LanguageKey{Uuid: "bad", Value: "batch.bad"}},
+	})
+	if resp.Applied || resp.Results[1].Status != 400 {
+		t.Fatalf("Expected malformed Uuid to fail validation, got %+v", resp.Results)
+	}
+
+	resp, changes = applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: model.LanguageKey{Value: "batch.generated"}},
+	})
+	if !resp.Applied || len(changes) != 1 || changes[0].uuid == "" {
+		t.Fatalf("Expected insert with generated Uuid, got %+v", resp.Results)
+	}
+	if got, err := ks.GetByValue("batch.generated"); err != nil || got.Uuid != changes[0].uuid {
+		t.Errorf("Stored key does not match change: %+v (%v)", got, err)
+	}
+}
+
+func TestApplyBatch_ChecksPluralCoverage(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	lang := model.Language{Uuid: uuid.NewString(), Prefix: "cs-CZ"}
+	resp, _ := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: lang},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: model.LanguageValue{
+			UuidLanguage: lang.Uuid,
+			Value:        "{n, plural, one {# soubor} other {# souborů}}",
+		}},
+	})
+
+	if resp.Applied {
+		t.Fatal("Expected batch with incomplete plural to be rolled back")
+	}
+	if resp.Results[1].Status != 500 || !strings.Contains(resp.Results[1].Error, "missing few for cs-CZ") {
+		t.Errorf("Expected missing categories to be reported, got %+v", resp.Results[1])
+	}
+}
+
+func TestApplyBatch_ChecksMaxLength(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	key := model.LanguageKey{Uuid: uuid.NewString(), Value: "checkout.pay", MaxLength: 5}
+	resp, _ := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: key},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: model.LanguageValue{
+			UuidLanguageKey: key.Uuid,
+			Value:           "Jetzt bezahlen",
+		}},
+	})
+
+	if resp.Applied {
+		t.Fatal("Expected batch with an overlong value to be rolled back")
+	}
+	if !strings.Contains(resp.Results[1].Error, "the limit is 5") {
+		t.Errorf("Expected limit to be reported, got %+v", resp.Results[1])
+	}
+}
+
+func TestApplyBatch_ChecksPlaceholders(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	previous := settings
+	settings.SourceLanguage = "en-US"
+	settings.EnforcePlaceholders = true
+	defer func() { settings = previous }()
+
+	source := model.Language{Uuid: uuid.NewString(), Prefix: "en-US"}
+	target := model.Language{Uuid: uuid.NewString(), Prefix: "de-DE"}
+	key := uuid.NewString()
+	resp, _ := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: source},
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: target},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: key, Value: "Hello {name}"}},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: key, Value: "Hallo"}},
+	})
+
+	if resp.Applied {
+		t.Fatal("Expected batch with a dropped placeholder to be rolled back")
+	}
+	if !strings.Contains(resp.Results[3].Error, "missing placeholder {name}") {
+		t.Errorf("Expected missing placeholder to be reported, got %+v", resp.Results[3])
+	}
+}
+
+func TestApplyBatch_RollbackRestoresReviewFlags(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+
+	previous := settings
+	settings.SourceLanguage = "en-US"
+	defer func() { settings = previous }()
+
+	source, _ := ls.Insert(model.Language{Prefix: "en-US"})
+	target, _ := ls.Insert(model.Language{Prefix: "de-DE"})
+	vs.SetSourceLanguage(source.Uuid)
+	key := uuid.NewString()
+	sourceValue, _ := vs.Insert(model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: key, Value: "Save"})
+	translation, _ := vs.Insert(model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: key, Value: "Speichern"})
+
+	sourceValue.Value = "Save all"
+	resp, _ := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguageValue, Operation: model.OperationUpdate, Record: sourceValue},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationDelete, Record: model.LanguageValue{Uuid: uuid.NewString()}},
+	})
+	if resp.Applied {
+		t.Fatal("Expected batch to be rolled back")
+	}
+	if got, _ := vs.Get(translation.Uuid); got.NeedsReview {
+		t.Error("Review flag set by a rolled back source change should have been cleared")
+	}
+
+	resp, _ = applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguageValue, Operation: model.OperationUpdate, Record: sourceValue},
+	})
+	if !resp.Applied {
+		t.Fatalf("Expected batch to apply, got %+v", resp.Results)
+	}
+	if got, _ := vs.Get(translation.Uuid); !got.NeedsReview {
+		t.Error("Expected translation to need review after the source changed")
+	}
+}
+
+func TestApplyBatch_RollbackRestoresTrash(t *testing.T) {
+	ls, ks, vs := NewLanguageStore(), NewLanguageKeyStore(), NewLanguageValueStore()
+	key, _ := ks.Insert(model.LanguageKey{Value: "batch.trash"})
+
+	resp, _ := applyBatch(ls, ks, vs, []model.BatchOperation{
+		{Entity: model.EntityLanguageKey, Operation: model.OperationDelete, Record: model.LanguageKey{Uuid: key.Uuid}},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationDelete, Record: model.LanguageKey{Uuid: uuid.NewString()}},
+	})
+
+	if resp.Applied {
+		t.Fatal("Expected batch with a missing key to be rolled back")
+	}
+	if _, err := ks.Get(key.Uuid); err != nil || len(ks.Trash()) != 0 {
+		t.Errorf("Expected rollback to take the key back out of the trash, got %+v", ks.Trash())
+	}
+}
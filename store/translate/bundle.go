@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// servable reports whether v may be served: approved and published values always are,
+// values still in the workflow only to preview environments.
+func servable(v model.LanguageValue, includeDrafts bool) bool {
+	switch v.Status {
+	case model.ValueStatusApproved, model.ValueStatusPublished:
+		return true
+	}
+	return includeDrafts
+}
+
+// buildBundle maps the key strings of lang to their servable values. Values of unknown keys
+// are left out. Deprecated keys serve the value of their replacement when it has one.
+func buildBundle(lang model.Language, keys []model.LanguageKey, values []model.LanguageValue, includeDrafts bool) model.Bundle {
+	byUuid := make(map[string]model.LanguageKey, len(keys))
+	for _, k := range keys {
+		byUuid[k.Uuid] = k
+	}
+	texts := make(map[string]string)
+	for _, v := range values {
+		if v.UuidLanguage == lang.Uuid && servable(v, includeDrafts) {
+			texts[v.UuidLanguageKey] = v.Value
+		}
+	}
+
+	b := model.Bundle{Prefix: lang.Prefix, Values: make(map[string]string), Deprecated: make(map[string]string)}
+	for _, k := range keys {
+		target := replacement(k, byUuid)
+		if k.Deprecated {
+			b.Deprecated[k.Value] = ""
+			if target.Uuid != k.Uuid {
+				b.Deprecated[k.Value] = target.Value
+			}
+		}
+		if text, ok := texts[target.Uuid]; ok {
+			b.Values[k.Value] = text
+		} else if text, ok = texts[k.Uuid]; ok {
+			b.Values[k.Value] = text
+		}
+	}
+	return b
+}
+
+// replacement follows the ReplacedBy chain of a deprecated key to the key served in its place,
+// which is k itself when it has no replacement in keys.
+func replacement(k model.LanguageKey, keys map[string]model.LanguageKey) model.LanguageKey {
+	for steps := 0; k.Deprecated && k.ReplacedBy != "" && steps < len(keys); steps++ {
+		next, ok := keys[k.ReplacedBy]
+		if !ok {
+			break
+		}
+		k = next
+	}
+	return k
+}
+
+// filterNamespaces drops the entries of b whose key string lies outside every namespace.
+func filterNamespaces(b model.Bundle, namespaces []string) {
+	inAny := func(value string) bool {
+		for _, ns := range namespaces {
+			if inNamespace(value, ns) {
+				return true
+			}
+		}
+		return false
+	}
+	for k := range b.Values {
+		if !inAny(k) {
+			delete(b.Values, k)
+		}
+	}
+	for k := range b.Deprecated {
+		if !inAny(k) {
+			delete(b.Deprecated, k)
+		}
+	}
+}
+
+// addAliases serves the value of each renamed key under its alias too, so clients still using
+// the old key strings keep working. targets maps aliases to current key strings.
+func addAliases(b model.Bundle, targets map[string]string) {
+	for alias, target := range targets {
+		if v, ok := b.Values[target]; ok {
+			b.Values[alias] = v
+		}
+	}
+}
+
+// resolveKeys looks up each requested key string in a bundle, reporting the current key string
+// of aliases found in targets and warning about deprecated keys.
+func resolveKeys(b model.Bundle, keys []string, targets map[string]string) []model.ResolveResult {
+	out := make([]model.ResolveResult, len(keys))
+	for i, k := range keys {
+		v, ok := b.Values[k]
+		out[i] = model.ResolveResult{Key: k, Found: ok, Value: v, RenamedTo: targets[k]}
+
+		name := k
+		if out[i].RenamedTo != "" {
+			name = out[i].RenamedTo
+		}
+		if replacedBy, deprecated := b.Deprecated[name]; deprecated {
+			out[i].ReplacedBy = replacedBy
+			out[i].Warning = fmt.Sprintf("key %q is deprecated", name)
+			if replacedBy != "" {
+				out[i].Warning += fmt.Sprintf(", use %q", replacedBy)
+			}
+		}
+	}
+	return out
+}
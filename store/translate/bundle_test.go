@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestBuildBundle(t *testing.T) {
+	lang := model.Language{Uuid: uuid.NewString(), Prefix: "de-DE"}
+	ok := model.LanguageKey{Uuid: uuid.NewString(), Value: "common.ok"}
+	cancel := model.LanguageKey{Uuid: uuid.NewString(), Value: "common.cancel"}
+	save := model.LanguageKey{Uuid: uuid.NewString(), Value: "common.save"}
+	keys := []model.LanguageKey{ok, cancel, save}
+
+	values := []model.LanguageValue{
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: ok.Uuid, Value: "OK", Status: model.ValueStatusApproved},
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: cancel.Uuid, Value: "Abbrechen", Status: model.ValueStatusPublished},
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: save.Uuid, Value: "Speichern", Status: model.ValueStatusDraft},
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: uuid.NewString(), Value: "Verwaist", Status: model.ValueStatusApproved},
+		{UuidLanguage: uuid.NewString(), UuidLanguageKey: ok.Uuid, Value: "D'accord", Status: model.ValueStatusApproved},
+	}
+
+	b := buildBundle(lang, keys, values, false)
+	if b.Prefix != "de-DE" || len(b.Values) != 2 || b.Values["common.ok"] != "OK" || b.Values["common.cancel"] != "Abbrechen" {
+		t.Errorf("Expected only approved and published values, got %+v", b)
+	}
+
+	preview := buildBundle(lang, keys, values, true)
+	if len(preview.Values) != 3 || preview.Values["common.save"] != "Speichern" {
+		t.Errorf("Expected drafts in preview bundle, got %+v", preview)
+	}
+
+	results := resolveKeys(b, []string{"common.ok", "common.save"}, nil)
+	if !results[0].Found || results[0].Value != "OK" || results[1].Found || results[1].Key != "common.save" {
+		t.Errorf("Unexpected resolve results: %+v", results)
+	}
+}
+
+func TestAddAliases(t *testing.T) {
+	b := model.Bundle{Prefix: "de-DE", Values: map[string]string{"common.actions.ok": "OK"}}
+	targets := map[string]string{"btn.ok": "common.actions.ok", "btn.cancel": "common.actions.cancel"}
+
+	addAliases(b, targets)
+	if len(b.Values) != 2 || b.Values["btn.ok"] != "OK" {
+		t.Errorf("Expected only the alias of a served key, got %+v", b.Values)
+	}
+
+	results := resolveKeys(b, []string{"btn.ok", "common.actions.ok"}, targets)
+	if !results[0].Found || results[0].RenamedTo != "common.actions.ok" || results[1].RenamedTo != "" {
+		t.Errorf("Unexpected resolve results: %+v", results)
+	}
+}
+
+func TestBuildBundle_Deprecated(t *testing.T) {
+	lang := model.Language{Uuid: uuid.NewString(), Prefix: "de-DE"}
+	current := model.LanguageKey{Uuid: uuid.NewString(), Value: "common.ok"}
+	middle := model.LanguageKey{Uuid: uuid.NewString(), Value: "dialog.ok", Deprecated: true, ReplacedBy: current.Uuid}
+	old := model.LanguageKey{Uuid: uuid.NewString(), Value: "btn.ok", Deprecated: true, ReplacedBy: middle.Uuid}
+	retired := model.LanguageKey{Uuid: uuid.NewString(), Value: "btn.help", Deprecated: true}
+	keys := []model.LanguageKey{current, middle, old, retired}
+
+	values := []model.LanguageValue{
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: current.Uuid, Value: "OK", Status: model.ValueStatusApproved},
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: old.Uuid, Value: "Okay", Status: model.ValueStatusApproved},
+		{UuidLanguage: lang.Uuid, UuidLanguageKey: retired.Uuid, Value: "Hilfe", Status: model.ValueStatusApproved},
+	}
+
+	b := buildBundle(lang, keys, values, false)
+	if b.Values["btn.ok"] != "OK" || b.Values["dialog.ok"] != "OK" || b.Values["btn.help"] != "Hilfe" {
+		t.Errorf("Expected deprecated keys to serve their replacement, got %+v", b.Values)
+	}
+	if b.Deprecated["btn.ok"] != "common.ok" || b.Deprecated["btn.help"] != "" || len(b.Deprecated) != 3 {
+		t.Errorf("Unexpected deprecations: %+v", b.Deprecated)
+	}
+
+	results := resolveKeys(b, []string{"btn.ok", "btn.help", "common.ok"}, nil)
+	if results[0].Value != "OK" || results[0].ReplacedBy != "common.ok" || results[0].Warning != `key "btn.ok" is deprecated, use "common.ok"` {
+		t.Errorf("Unexpected result for btn.ok: %+v", results[0])
+	}
+	if results[1].Warning != `key "btn.help" is deprecated` || results[2].Warning != "" {
+		t.Errorf("Unexpected warnings: %+v", results[1:])
+	}
+
+	filterNamespaces(b, []string{"btn"})
+	if len(b.Values) != 2 || len(b.Deprecated) != 2 {
+		t.Errorf("Expected only the btn namespace, got %+v", b)
+	}
+}
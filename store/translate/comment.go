@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
+)
+
+// CommentStore keeps discussion threads attached to keys and values.
+type CommentStore struct {
+	mu    sync.RWMutex
+	items map[string]model.Comment
+}
+
+func NewCommentStore() *CommentStore {
+	return &CommentStore{
+		items: make(map[string]model.Comment),
+	}
+}
+
+// Insert stores c, assigning a Uuid when it has none, and returns the stored record. A reply
+// must be attached to the same key or value as the comment it replies to.
+func (s *CommentStore) Insert(c model.Comment) (model.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := util.UuidEnsure(c.Uuid)
+	if err != nil {
+		return model.Comment{}, err
+	}
+	c.Uuid = id
+
+	if _, exists := s.items[c.Uuid]; exists {
+		return model.Comment{}, errors.New("comment already exists")
+	}
+	if err = validateComment(c); err != nil {
+		return model.Comment{}, err
+	}
+	if c.UuidParent != "" {
+		parent, ok := s.items[c.UuidParent]
+		if !ok {
+			return model.Comment{}, errors.New("parent comment not found")
+		}
+		if parent.Entity != c.Entity || parent.EntityUuid != c.EntityUuid {
+			return model.Comment{}, errors.New("reply must be attached to the same record as its parent")
+		}
+	}
+
+	c.FirstInsert = time.Now().Truncate(time.Microsecond)
+	c.LastUpdate = c.FirstInsert
+	c.Revision = 1
+	s.items[c.Uuid] = c
+	return c, nil
+}
+
+func (s *CommentStore) Get(uuid string) (model.Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.items[uuid]
+	if !ok {
+		return model.Comment{}, errors.New("comment not found")
+	}
+	return c, nil
+}
+
+// List returns the comments matching every set field of q, oldest first.
+func (s *CommentStore) List(q model.CommentQuery) []model.Comment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.Comment, 0)
+	for _, c := range s.items {
+		if q.Entity != "" && c.Entity != q.Entity {
+			continue
+		}
+		if q.EntityUuid != "" && c.EntityUuid != q.EntityUuid {
+			continue
+		}
+		if q.Unresolved && c.Resolved {
+			continue
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FirstInsert.Before(out[j].FirstInsert) })
+	return out
+}
+
+// Update changes the text or resolved flag of a comment and returns the record it replaced
+// and the stored record. The record it is attached to, its parent and its author cannot
+// change. A non-zero updated.Revision must match the stored revision.
+func (s *CommentStore) Update(uuid string, updated model.Comment) (model.Comment, model.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.items[uuid]
+	if !exists {
+		return model.Comment{}, model.Comment{}, errors.New("comment not found")
+	}
+	if updated.Revision != 0 && updated.Revision != previous.Revision {
+		return model.Comment{}, model.Comment{}, &util.ConflictError{Current: previous}
+	}
+
+	current := previous
+	current.Text = updated.Text
+	current.Resolved = updated.Resolved
+	if err := validateComment(current); err != nil {
+		return model.Comment{}, model.Comment{}, err
+	}
+	current.Revision++
+	current.LastUpdate = time.Now().Truncate(time.Microsecond)
+	s.items[uuid] = current
+	return previous, current, nil
+}
+
+// Delete removes a comment without replies and returns the deleted record. A non-zero
+// expectedRevision must match the stored revision.
+func (s *CommentStore) Delete(uuid string, expectedRevision int) (model.Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.items[uuid]
+	if !exists {
+		return model.Comment{}, errors.New("comment not found")
+	}
+	if expectedRevision != 0 && expectedRevision != current.Revision {
+		return model.Comment{}, &util.ConflictError{Current: current}
+	}
+	for _, c := range s.items {
+		if c.UuidParent == uuid {
+			return model.Comment{}, errors.New("comment has replies")
+		}
+	}
+	delete(s.items, uuid)
+	return current, nil
+}
+
+func validateComment(c model.Comment) error {
+	if c.Entity != model.EntityLanguageKey && c.Entity != model.EntityLanguageValue {
+		return errors.New("comments can only be attached to keys and values")
+	}
+	if c.EntityUuid == "" {
+		return errors.New("comment is not attached to a record")
+	}
+	if c.Text == "" {
+		return errors.New("comment text is empty")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
+)
+
+func TestCommentStore_InsertAndList(t *testing.T) {
+	store := NewCommentStore()
+	keyUuid, valueUuid := uuid.NewString(), uuid.NewString()
+
+	question, err := store.Insert(model.Comment{Entity: model.EntityLanguageKey, EntityUuid: keyUuid, Author: "ana", Text: "Verb or noun?"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if question.Uuid == "" || question.Revision != 1 || question.FirstInsert.IsZero() {
+		t.Errorf("Expected stored comment with Uuid and revision 1, got %+v", question)
+	}
+
+	answer, err := store.Insert(model.Comment{Entity: model.EntityLanguageKey, EntityUuid: keyUuid, UuidParent: question.Uuid, Author: "dev", Text: "Verb"})
+	if err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	store.Insert(model.Comment{Entity: model.EntityLanguageValue, EntityUuid: valueUuid, Text: "Typo"})
+
+	thread := store.List(model.CommentQuery{Entity: model.EntityLanguageKey, EntityUuid: keyUuid})
+	if len(thread) != 2 || thread[0].Uuid != question.Uuid || thread[1].Uuid != answer.Uuid {
+		t.Errorf("Expected question and answer oldest first, got %+v", thread)
+	}
+	if all := store.List(model.CommentQuery{}); len(all) != 3 {
+		t.Errorf("Expected 3 comments, got %d", len(all))
+	}
+}
+
+func TestCommentStore_InsertInvalid(t *testing.T) {
+	store := NewCommentStore()
+	parent, _ := store.Insert(model.Comment{Entity: model.EntityLanguageKey, EntityUuid: uuid.NewString(), Text: "Question"})
+
+	invalid := []model.Comment{
+		{Entity: model.EntityLanguage, EntityUuid: uuid.NewString(), Text: "Wrong entity"},
+		{Entity: model.EntityLanguageKey, Text: "Not attached"},
+		{Entity: model.EntityLanguageKey, EntityUuid: uuid.NewString()},
+		{Entity: model.EntityLanguageKey, EntityUuid: uuid.NewString(), UuidParent: uuid.NewString(), Text: "Orphan reply"},
+		{Entity: model.EntityLanguageKey, EntityUuid: uuid.NewString(), UuidParent: parent.Uuid, Text: "Reply elsewhere"},
+		{Uuid: parent.Uuid, Entity: model.EntityLanguageKey, EntityUuid: parent.EntityUuid, Text: "Duplicate"},
+	}
+	for _, c := range invalid {
+		if _, err := store.Insert(c); err == nil {
+			t.Errorf("Expected %+v to be rejected", c)
+		}
+	}
+}
+
+func TestCommentStore_UpdateResolves(t *testing.T) {
+	store := NewCommentStore()
+	c, _ := store.Insert(model.Comment{Entity: model.EntityLanguageKey, EntityUuid: uuid.NewString(), Author: "ana", Text: "Verb or noun?"})
+
+	_, updated, err := store.Update(c.Uuid, model.Comment{Revision: 1, Author: "mallory", EntityUuid: uuid.NewString(), Text: "Verb or noun here?", Resolved: true})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if !updated.Resolved || updated.Text != "Verb or noun here?" || updated.Revision != 2 {
+		t.Errorf("Expected resolved comment at revision 2, got %+v", updated)
+	}
+	if updated.Author != "ana" || updated.EntityUuid != c.EntityUuid {
+		t.Errorf("Author and attachment must not change, got %+v", updated)
+	}
+	if open := store.List(model.CommentQuery{Unresolved: true}); len(open) != 0 {
+		t.Errorf("Expected no unresolved comments, got %+v", open)
+	}
+
+	var conflict *util.ConflictError
+	if _, _, err := store.Update(c.Uuid, model.Comment{Revision: 1, Text: "Stale"}); !errors.As(err, &conflict) {
+		t.Errorf("Expected conflict for stale revision, got %v", err)
+	}
+}
+
+func TestCommentStore_Delete(t *testing.T) {
+	store := NewCommentStore()
+	keyUuid := uuid.NewString()
+	parent, _ := store.Insert(model.Comment{Entity: model.EntityLanguageKey, EntityUuid: keyUuid, Text: "Question"})
+	reply, _ := store.Insert(model.Comment{Entity: model.EntityLanguageKey, EntityUuid: keyUuid, UuidParent: parent.Uuid, Text: "Answer"})
+
+	if _, err := store.Delete(parent.Uuid, 0); err == nil {
+		t.Error("Expected comment with replies not to be deletable")
+	}
+	if _, err := store.Delete(reply.Uuid, 0); err != nil {
+		t.Fatalf("Delete reply failed: %v", err)
+	}
+	deleted, err := store.Delete(parent.Uuid, 1)
+	if err != nil || deleted.Uuid != parent.Uuid {
+		t.Fatalf("Delete failed: %+v (%v)", deleted, err)
+	}
+	if _, err := store.Get(parent.Uuid); err == nil {
+		t.Error("Expected deleted comment to be gone")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// config holds service settings read from the environment at startup.
+type config struct {
+	IdempotencyWindow   time.Duration // MEISTERWERK_IDEMPOTENCY_WINDOW, how long idempotency keys are remembered
+	SourceLanguage      string        // MEISTERWERK_SOURCE_LANGUAGE, Prefix of the language translations are made from
+	EnforcePlaceholders bool          // MEISTERWERK_ENFORCE_PLACEHOLDERS, reject translations whose placeholders differ from the source
+	AliasWindow         time.Duration // MEISTERWERK_ALIAS_WINDOW, how long old key strings keep resolving after a rename
+	TrashRetention      time.Duration // MEISTERWERK_TRASH_RETENTION, how long deleted records can be restored before they are purged
+}
+
+func loadConfig() (config, error) {
+	c := config{
+		IdempotencyWindow: 10 * time.Minute,
+		SourceLanguage:    "en-US",
+		AliasWindow:       30 * 24 * time.Hour,
+		TrashRetention:    30 * 24 * time.Hour,
+	}
+
+	if v := os.Getenv("MEISTERWERK_IDEMPOTENCY_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return c, err
+		}
+		c.IdempotencyWindow = d
+	}
+
+	if v := os.Getenv("MEISTERWERK_SOURCE_LANGUAGE"); v != "" {
+		c.SourceLanguage = v
+	}
+
+	if v := os.Getenv("MEISTERWERK_ENFORCE_PLACEHOLDERS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, err
+		}
+		c.EnforcePlaceholders = b
+	}
+
+	if v := os.Getenv("MEISTERWERK_ALIAS_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return c, err
+		}
+		c.AliasWindow = d
+	}
+
+	if v := os.Getenv("MEISTERWERK_TRASH_RETENTION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return c, err
+		}
+		c.TrashRetention = d
+	}
+
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	t.Setenv("MEISTERWERK_IDEMPOTENCY_WINDOW", "")
+	t.Setenv("MEISTERWERK_SOURCE_LANGUAGE", "")
+	t.Setenv("MEISTERWERK_ENFORCE_PLACEHOLDERS", "")
+	t.Setenv("MEISTERWERK_ALIAS_WINDOW", "")
+	t.Setenv("MEISTERWERK_TRASH_RETENTION", "")
+
+	c, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if c.IdempotencyWindow != 10*time.Minute {
+		t.Errorf("Expected default idempotency window of 10m, got %v", c.IdempotencyWindow)
+	}
+	if c.SourceLanguage != "en-US" || c.EnforcePlaceholders {
+		t.Errorf("Unexpected source language defaults: %q, %v", c.SourceLanguage, c.EnforcePlaceholders)
+	}
+	if c.AliasWindow != 30*24*time.Hour {
+		t.Errorf("Expected default alias window of 30 days, got %v", c.AliasWindow)
+	}
+	if c.TrashRetention != 30*24*time.Hour {
+		t.Errorf("Expected default trash retention of 30 days, got %v", c.TrashRetention)
+	}
+}
+
+func TestLoadConfig_FromEnv(t *testing.T) {
+	t.Setenv("MEISTERWERK_IDEMPOTENCY_WINDOW", "90s")
+	t.Setenv("MEISTERWERK_SOURCE_LANGUAGE", "de-DE")
+	t.Setenv("MEISTERWERK_ENFORCE_PLACEHOLDERS", "true")
+	t.Setenv("MEISTERWERK_ALIAS_WINDOW", "48h")
+	t.Setenv("MEISTERWERK_TRASH_RETENTION", "168h")
+
+	c, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if c.IdempotencyWindow != 90*time.Second {
+		t.Errorf("Expected idempotency window of 90s, got %v", c.IdempotencyWindow)
+	}
+	if c.SourceLanguage != "de-DE" || !c.EnforcePlaceholders {
+		t.Errorf("Unexpected source language settings: %q, %v", c.SourceLanguage, c.EnforcePlaceholders)
+	}
+	if c.AliasWindow != 48*time.Hour {
+		t.Errorf("Expected alias window of 48h, got %v", c.AliasWindow)
+	}
+	if c.TrashRetention != 168*time.Hour {
+		t.Errorf("Expected trash retention of 168h, got %v", c.TrashRetention)
+	}
+
+	t.Setenv("MEISTERWERK_ENFORCE_PLACEHOLDERS", "maybe")
+	if _, err := loadConfig(); err == nil {
+		t.Error("Expected error for invalid boolean")
+	}
+	t.Setenv("MEISTERWERK_ENFORCE_PLACEHOLDERS", "")
+
+	t.Setenv("MEISTERWERK_IDEMPOTENCY_WINDOW", "soon")
+	if _, err := loadConfig(); err == nil {
+		t.Error("Expected error for invalid duration")
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rah-0/meisterwerk/i18next"
+	"github.com/rah-0/meisterwerk/icu"
+	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
+)
+
+// exportLanguages returns the language with the given Prefix, or every language sorted by
+// Prefix when it is empty.
+func exportLanguages(prefix string) ([]model.Language, error) {
+	if prefix != "" {
+		lang, err := languageStore.GetByPrefix(prefix)
+		if err != nil {
+			return nil, err
+		}
+		return []model.Language{lang}, nil
+	}
+	langs := languageStore.List()
+	sortLanguages(langs)
+	return langs, nil
+}
+
+// i18nextExport writes one translation.json per language, holding the values a bundle of
+// that language would serve.
+func i18nextExport(req model.ExportRequest) ([]model.ExportFile, error) {
+	langs, err := exportLanguages(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := languageKeyStore.List()
+
+	files := make([]model.ExportFile, 0, len(langs))
+	for _, lang := range langs {
+		values := languageValueStore.Query(model.LanguageValueQuery{UuidLanguage: lang.Uuid})
+		data, err := i18next.Export(buildBundle(lang, keys, values, req.IncludeDrafts).Values)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, model.ExportFile{Path: lang.Prefix + "/translation.json", Data: data})
+	}
+	return files, nil
+}
+
+// i18nextImport reads an i18next file into the language with req.Prefix.
+func i18nextImport(req model.ImportRequest) (model.ImportResult, []batchChange, error) {
+	lang, err := languageStore.GetByPrefix(req.Prefix)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	texts, err := i18next.Import(req.Data)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	return importTexts(lang, texts, nil, req.DryRun)
+}
+
+// importTexts creates or updates the values of lang from texts by key string, creating
+// missing keys. A value whose key is in reviewed and whose text is unchanged is saved again
+// when it needs review, which confirms it against the current source value. Every change goes
+// through one batch, so either all of them are applied or none is. A dry run only reports the
+// changes.
+func importTexts(lang model.Language, texts map[string]string, reviewed map[string]bool, dryRun bool) (model.ImportResult, []batchChange, error) {
+	names := make([]string, 0, len(texts))
+	for name := range texts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := model.ImportResult{Changes: make([]model.ImportChange, 0)}
+	var ops []model.BatchOperation
+	var opKeys []string // Key string of each operation, for error messages
+	for _, name := range names {
+		text := texts[name]
+		key, err := languageKeyStore.GetByValue(name)
+		if err != nil {
+			if key.Uuid, err = util.UuidEnsure(""); err != nil {
+				return model.ImportResult{}, nil, err
+			}
+			key.Value = name
+			ops = append(ops, model.BatchOperation{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: key})
+			opKeys = append(opKeys, name)
+		}
+
+		current, err := languageValueStore.GetByLanguageKey(lang.Uuid, key.Uuid)
+		switch {
+		case err != nil:
+			ops = append(ops, model.BatchOperation{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: model.LanguageValue{
+				UuidLanguage:    lang.Uuid,
+				UuidLanguageKey: key.Uuid,
+				Value:           text,
+			}})
+			result.Changes = append(result.Changes, model.ImportChange{Key: name, Operation: model.OperationInsert, Current: text})
+		case !icu.Equal(current.Value, text):
+			updated := current
+			updated.Value = text
+			ops = append(ops, model.BatchOperation{Entity: model.EntityLanguageValue, Operation: model.OperationUpdate, Record: updated})
+			result.Changes = append(result.Changes, model.ImportChange{Key: name, Operation: model.OperationUpdate, Previous: current.Value, Current: text})
+		case reviewed[name] && current.NeedsReview:
+			ops = append(ops, model.BatchOperation{Entity: model.EntityLanguageValue, Operation: model.OperationUpdate, Record: current})
+			result.Changes = append(result.Changes, model.ImportChange{Key: name, Operation: model.OperationUpdate, Previous: current.Value, Current: current.Value})
+		default:
+			continue
+		}
+		opKeys = append(opKeys, name)
+	}
+
+	if dryRun || len(ops) == 0 {
+		result.Applied = !dryRun
+		return result, nil, nil
+	}
+
+	resp, changes := applyBatch(languageStore, languageKeyStore, languageValueStore, ops)
+	if !resp.Applied {
+		for i, r := range resp.Results {
+			if r.Status != 200 && r.Error != errBatchRolledBack.Error() {
+				return model.ImportResult{}, nil, fmt.Errorf("key %q: %s", opKeys[i], r.Error)
+			}
+		}
+		return model.ImportResult{}, nil, errBatchRolledBack
+	}
+	result.Applied = true
+	return result, changes, nil
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/gettext"
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// gettextMessage is one msgid of the source language. Keys with the same context and source
+// text share it, as gettext cannot tell them apart.
+type gettextMessage struct {
+	entry gettext.Entry       // Comments, context and msgid, without translation
+	arg   string              // Argument of a plural source value, empty otherwise
+	keys  []model.LanguageKey // Sorted by Value
+}
+
+// gettextMessages returns the messages of every key with a source value, sorted by the first
+// key of each.
+func gettextMessages() ([]*gettextMessage, error) {
+	source, err := sourceLanguage()
+	if err != nil {
+		return nil, err
+	}
+	categories, _ := gettext.PluralForms(source.Prefix)
+	keys := languageKeyStore.List()
+	sortKeys(keys)
+
+	var out []*gettextMessage
+	byID := make(map[[3]string]*gettextMessage)
+	for _, k := range keys {
+		v, err := languageValueStore.GetByLanguageKey(source.Uuid, k.Uuid)
+		if err != nil {
+			continue
+		}
+
+		entry := gettext.Entry{Context: k.Context, ID: v.Value}
+		arg, forms, isPlural := gettext.SplitPlural(v.Value, categories)
+		if isPlural {
+			entry.ID, entry.IDPlural = forms[0], forms[len(forms)-1]
+		}
+		id := [3]string{entry.Context, entry.ID, entry.IDPlural}
+		m, ok := byID[id]
+		if !ok {
+			m = &gettextMessage{entry: entry, arg: arg}
+			byID[id] = m
+			out = append(out, m)
+		}
+		m.keys = append(m.keys, k)
+		if k.Description != "" {
+			m.entry.ExtractedComments = append(m.entry.ExtractedComments, strings.Split(k.Description, "\n")...)
+		}
+		if k.SourceFile != "" {
+			m.entry.References = append(m.entry.References, k.SourceFile)
+		}
+	}
+	return out, nil
+}
+
+// emptyForms returns an empty msgstr for each form of entry, n of them for plural entries.
+func emptyForms(entry gettext.Entry, n int) []string {
+	if entry.IDPlural == "" {
+		n = 1
+	}
+	return make([]string, n)
+}
+
+// gettextTemplate writes the POT file translators start a new language from.
+func gettextTemplate() (model.ExportFile, error) {
+	messages, err := gettextMessages()
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+	f := gettext.File{Headers: []gettext.Header{
+		{Name: "MIME-Version", Value: "1.0"},
+		{Name: "Content-Type", Value: "text/plain; charset=UTF-8"},
+		{Name: "Content-Transfer-Encoding", Value: "8bit"},
+		{Name: "Plural-Forms", Value: "nplurals=INTEGER; plural=EXPRESSION;"},
+	}}
+	for _, m := range messages {
+		entry := m.entry
+		entry.Str = emptyForms(entry, 2)
+		f.Entries = append(f.Entries, entry)
+	}
+	return model.ExportFile{Path: "messages.pot", Data: gettext.Write(f)}, nil
+}
+
+// gettextExport writes one PO file per language with the values a bundle of that language
+// would serve. Values whose source changed are marked fuzzy and unresolved comments become
+// translator comments.
+func gettextExport(req model.ExportRequest) ([]model.ExportFile, error) {
+	langs, err := exportLanguages(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := gettextMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]model.ExportFile, 0, len(langs))
+	for _, lang := range langs {
+		locale := strings.ReplaceAll(lang.Prefix, "-", "_")
+		categories, pluralForms := gettext.PluralForms(lang.Prefix)
+		f := gettext.File{Headers: []gettext.Header{
+			{Name: "Language", Value: locale},
+			{Name: "MIME-Version", Value: "1.0"},
+			{Name: "Content-Type", Value: "text/plain; charset=UTF-8"},
+			{Name: "Content-Transfer-Encoding", Value: "8bit"},
+			{Name: "Plural-Forms", Value: pluralForms},
+		}}
+		for _, m := range messages {
+			f.Entries = append(f.Entries, gettextEntry(m, lang, categories, req.IncludeDrafts))
+		}
+		files = append(files, model.ExportFile{Path: locale + "/LC_MESSAGES/messages.po", Data: gettext.Write(f)})
+	}
+	return files, nil
+}
+
+// gettextEntry fills in the translation of m in lang from the first of its keys that has one.
+func gettextEntry(m *gettextMessage, lang model.Language, categories []string, includeDrafts bool) gettext.Entry {
+	entry := m.entry
+	entry.Str = emptyForms(entry, len(categories))
+	for _, k := range m.keys {
+		entry.TranslatorComments = append(entry.TranslatorComments, commentLines(model.EntityLanguageKey, k.Uuid)...)
+	}
+
+	for _, k := range m.keys {
+		v, err := languageValueStore.GetByLanguageKey(lang.Uuid, k.Uuid)
+		if err != nil || !servable(v, includeDrafts) {
+			continue
+		}
+		entry.TranslatorComments = append(entry.TranslatorComments, commentLines(model.EntityLanguageValue, v.Uuid)...)
+		fuzzy := v.NeedsReview
+		switch _, forms, ok := gettext.SplitPlural(v.Value, categories); {
+		case entry.IDPlural == "":
+			entry.Str = []string{v.Value}
+		case ok:
+			entry.Str = forms
+		default:
+			// A plural translated without plural syntax: keep the text for the translator to
+			// fix, but out of the way of imports.
+			for i := range entry.Str {
+				entry.Str[i] = v.Value
+			}
+			fuzzy = true
+		}
+		if fuzzy {
+			entry.Flags = append(entry.Flags, "fuzzy")
+		}
+		break
+	}
+	return entry
+}
+
+// commentLines returns the unresolved comments on a record as translator comment lines.
+func commentLines(entity, uuid string) []string {
+	var out []string
+	for _, c := range commentStore.List(model.CommentQuery{Entity: entity, EntityUuid: uuid, Unresolved: true}) {
+		lines := strings.Split(c.Text, "\n")
+		lines[0] = c.Author + ": " + lines[0]
+		out = append(out, lines...)
+	}
+	return out
+}
+
+// gettextImport reads a PO file into the language with req.Prefix. Entries are matched to keys
+// by msgctxt and msgid of the source language; untranslated, fuzzy, obsolete and unknown
+// entries are skipped. The Plural-Forms header only has to fit the language when the file
+// has plural entries.
+func gettextImport(req model.ImportRequest) (model.ImportResult, []batchChange, error) {
+	lang, err := languageStore.GetByPrefix(req.Prefix)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	f, err := gettext.Parse(req.Data)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	categories, _ := gettext.PluralForms(lang.Prefix)
+	var formsErr error
+	if header := f.Header("Plural-Forms"); header != "" {
+		n, err := gettext.NPlurals(header)
+		if err == nil {
+			var ok bool
+			if categories, ok = gettext.FormCategories(lang.Prefix, n); !ok {
+				err = fmt.Errorf("file has %d plural forms, which %s cannot use", n, lang.Prefix)
+			}
+		}
+		formsErr = err
+	}
+	messages, err := gettextMessages()
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	byID := make(map[[3]string]*gettextMessage, len(messages))
+	for _, m := range messages {
+		byID[[3]string{m.entry.Context, m.entry.ID, m.entry.IDPlural}] = m
+	}
+
+	texts := make(map[string]string)
+	var skipped []string
+	for _, e := range f.Entries {
+		m, ok := byID[[3]string{e.Context, e.ID, e.IDPlural}]
+		if !ok || e.Obsolete || e.Fuzzy() || !e.Translated() {
+			skipped = append(skipped, e.ID)
+			continue
+		}
+		text := e.Str[0]
+		if e.IDPlural != "" {
+			if formsErr != nil {
+				return model.ImportResult{}, nil, formsErr
+			}
+			text = gettext.JoinPlural(m.arg, categories, e.Str)
+		}
+		for _, k := range m.keys {
+			texts[k.Value] = text
+		}
+	}
+
+	result, changes, err := importTexts(lang, texts, nil, req.DryRun)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	result.Skipped = skipped
+	return result, changes, nil
+}
@@ -2,34 +2,58 @@ package main
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/plural"
+	"github.com/rah-0/meisterwerk/util"
 )
 
 type LanguageStore struct {
 	mu    sync.RWMutex
 	items map[string]model.Language
+	trash map[string]model.Language // Deleted languages, kept until purged
 }
 
 func NewLanguageStore() *LanguageStore {
 	return &LanguageStore{
 		items: make(map[string]model.Language),
+		trash: make(map[string]model.Language),
 	}
 }
 
-func (s *LanguageStore) Insert(l model.Language) error {
+// Insert stores l, assigning a Uuid when it has none, and returns the stored record.
+func (s *LanguageStore) Insert(l model.Language) (model.Language, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.insert(l)
+}
+
+func (s *LanguageStore) insert(l model.Language) (model.Language, error) {
+	id, err := util.UuidEnsure(l.Uuid)
+	if err != nil {
+		return model.Language{}, err
+	}
+	l.Uuid = id
+
 	if _, exists := s.items[l.Uuid]; exists {
-		return errors.New("language already exists")
+		return model.Language{}, errors.New("language already exists")
+	}
+	if _, exists := s.trash[l.Uuid]; exists {
+		return model.Language{}, errors.New("language is in the trash")
 	}
 
 	l.FirstInsert = time.Now().Truncate(time.Microsecond)
+	l.LastUpdate = l.FirstInsert
+	l.Revision = 1
+	l.Deleted = time.Time{}
+	setPluralCategories(&l)
 	s.items[l.Uuid] = l
-	return nil
+	return l, nil
 }
 
 func (s *LanguageStore) Get(uuid string) (model.Language, error) {
@@ -54,28 +78,183 @@ func (s *LanguageStore) List() []model.Language {
 	return out
 }
 
-func (s *LanguageStore) Update(uuid string, updated model.Language) error {
+// Update replaces a language and returns the record it replaced and the stored record. A non-zero
+// updated.Revision must match the stored revision.
+func (s *LanguageStore) Update(uuid string, updated model.Language) (model.Language, model.Language, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	previous := s.items[uuid]
+	current, err := s.update(uuid, updated)
+	if err != nil {
+		return model.Language{}, model.Language{}, err
+	}
+	return previous, current, nil
+}
+
+func (s *LanguageStore) update(uuid string, updated model.Language) (model.Language, error) {
 	current, exists := s.items[uuid]
 	if !exists {
-		return errors.New("language not found")
+		return model.Language{}, errors.New("language not found")
+	}
+	if updated.Revision != 0 && updated.Revision != current.Revision {
+		return model.Language{}, &util.ConflictError{Current: current}
 	}
 
 	updated.FirstInsert = current.FirstInsert // preserve insert timestamp
+	updated.Revision = current.Revision + 1
 	updated.LastUpdate = time.Now().Truncate(time.Microsecond)
+	updated.Deleted = time.Time{}
+	setPluralCategories(&updated)
 	s.items[uuid] = updated
-	return nil
+	return updated, nil
 }
 
-func (s *LanguageStore) Delete(uuid string) error {
+// Delete moves a language to the trash and returns the deleted record. A non-zero
+// expectedRevision must match the stored revision.
+func (s *LanguageStore) Delete(uuid string, expectedRevision int) (model.Language, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.items[uuid]; !exists {
-		return errors.New("language not found")
+	return s.delete(uuid, expectedRevision)
+}
+
+func (s *LanguageStore) delete(uuid string, expectedRevision int) (model.Language, error) {
+	current, exists := s.items[uuid]
+	if !exists {
+		return model.Language{}, errors.New("language not found")
+	}
+	if expectedRevision != 0 && expectedRevision != current.Revision {
+		return model.Language{}, &util.ConflictError{Current: current}
 	}
+	current.Deleted = time.Now().Truncate(time.Microsecond)
 	delete(s.items, uuid)
-	return nil
+	s.trash[uuid] = current
+	return current, nil
+}
+
+// Restore takes a language back out of the trash and returns the stored record.
+func (s *LanguageStore) Restore(uuid string) (model.Language, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, exists := s.trash[uuid]
+	if !exists {
+		return model.Language{}, errors.New("language not in trash")
+	}
+	l.Deleted = time.Time{}
+	l.Revision++
+	l.LastUpdate = time.Now().Truncate(time.Microsecond)
+	delete(s.trash, uuid)
+	s.items[uuid] = l
+	return l, nil
+}
+
+// Trash returns the deleted languages, oldest deletion first.
+func (s *LanguageStore) Trash() []model.Language {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.Language, 0, len(s.trash))
+	for _, l := range s.trash {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Deleted.Before(out[j].Deleted) })
+	return out
+}
+
+// Purge drops the languages deleted before cutoff from the trash and returns them.
+func (s *LanguageStore) Purge(cutoff time.Time) []model.Language {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []model.Language
+	for id, l := range s.trash {
+		if l.Deleted.Before(cutoff) {
+			delete(s.trash, id)
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// snapshot captures the stored state of uuid and returns a func that puts it back.
+// The caller must hold the write lock for both calls.
+func (s *LanguageStore) snapshot(uuid string) func() {
+	l, existed := s.items[uuid]
+	trashed, inTrash := s.trash[uuid]
+	return func() {
+		delete(s.items, uuid)
+		delete(s.trash, uuid)
+		if existed {
+			s.items[uuid] = l
+		}
+		if inTrash {
+			s.trash[uuid] = trashed
+		}
+	}
+}
+
+// GetByPrefix returns the language with the given Prefix.
+func (s *LanguageStore) GetByPrefix(prefix string) (model.Language, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := s.findByPrefix(prefix)
+	switch len(matches) {
+	case 0:
+		return model.Language{}, errors.New("language not found")
+	case 1:
+		return matches[0], nil
+	default:
+		return model.Language{}, errors.New("language prefix is ambiguous")
+	}
+}
+
+// Upsert inserts l, or updates the language sharing its Prefix when there is one.
+func (s *LanguageStore) Upsert(l model.Language) (model.UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := s.findByPrefix(l.Prefix)
+	switch len(matches) {
+	case 0:
+		created, err := s.insert(l)
+		if err != nil {
+			return model.UpsertResult{}, err
+		}
+		return model.UpsertResult{Outcome: model.UpsertCreated, Data: created}, nil
+	case 1:
+	default:
+		return model.UpsertResult{}, errors.New("language prefix is ambiguous")
+	}
+
+	current := matches[0]
+	if current.Lang == l.Lang && current.Title == l.Title && current.Img == l.Img && current.MonthsShort == l.MonthsShort {
+		return model.UpsertResult{Outcome: model.UpsertUnchanged, Previous: current, Data: current}, nil
+	}
+
+	l.Uuid = current.Uuid
+	updated, err := s.update(current.Uuid, l)
+	if err != nil {
+		return model.UpsertResult{}, err
+	}
+	return model.UpsertResult{Outcome: model.UpsertChanged, Previous: current, Data: updated}, nil
+}
+
+// setPluralCategories derives the plural categories of l from its Prefix, ignoring any sent by the client.
+func setPluralCategories(l *model.Language) {
+	rules := plural.ForLocale(l.Prefix)
+	l.PluralCategories = strings.Join(rules.CardinalCategories(), ",")
+	l.OrdinalCategories = strings.Join(rules.OrdinalCategories(), ",")
+}
+
+func (s *LanguageStore) findByPrefix(prefix string) []model.Language {
+	var out []model.Language
+	for _, l := range s.items {
+		if l.Prefix == prefix {
+			out = append(out, l)
+		}
+	}
+	return out
 }
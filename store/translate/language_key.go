@@ -2,40 +2,75 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
 )
 
 type LanguageKeyStore struct {
 	mu      sync.RWMutex
 	items   map[string]model.LanguageKey
-	byValue map[string]string // map[Value]Uuid for uniqueness check
+	byValue map[string]string                 // map[Value]Uuid for uniqueness check
+	tree    *namespaceNode                    // Key values split on "." for namespace queries
+	aliases map[string]model.LanguageKeyAlias // map[former Value]alias recorded by renames
+	trash   map[string]model.LanguageKey      // Deleted keys, kept until purged
 }
 
 func NewLanguageKeyStore() *LanguageKeyStore {
 	return &LanguageKeyStore{
 		items:   make(map[string]model.LanguageKey),
 		byValue: make(map[string]string),
+		tree:    newNamespaceNode(),
+		aliases: make(map[string]model.LanguageKeyAlias),
+		trash:   make(map[string]model.LanguageKey),
 	}
 }
 
-func (s *LanguageKeyStore) Insert(k model.LanguageKey) error {
+// Insert stores k, assigning a Uuid when it has none, and returns the stored record.
+func (s *LanguageKeyStore) Insert(k model.LanguageKey) (model.LanguageKey, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.insert(k)
+}
+
+func (s *LanguageKeyStore) insert(k model.LanguageKey) (model.LanguageKey, error) {
+	id, err := util.UuidEnsure(k.Uuid)
+	if err != nil {
+		return model.LanguageKey{}, err
+	}
+	k.Uuid = id
+
 	if _, exists := s.items[k.Uuid]; exists {
-		return errors.New("key already exists")
+		return model.LanguageKey{}, errors.New("key already exists")
+	}
+	if _, exists := s.trash[k.Uuid]; exists {
+		return model.LanguageKey{}, errors.New("key is in the trash")
 	}
 	if _, exists := s.byValue[k.Value]; exists {
-		return errors.New("key value must be unique")
+		return model.LanguageKey{}, errors.New("key value must be unique")
+	}
+	if err = validateLanguageKey(k); err != nil {
+		return model.LanguageKey{}, err
+	}
+	if err = s.validateReplacement(k); err != nil {
+		return model.LanguageKey{}, err
 	}
 
 	k.FirstInsert = time.Now().Truncate(time.Microsecond)
+	k.LastUpdate = k.FirstInsert
+	k.Revision = 1
+	k.Deleted = time.Time{}
 	s.items[k.Uuid] = k
 	s.byValue[k.Value] = k.Uuid
-	return nil
+	s.tree.add(k.Value, k.Uuid)
+	return k, nil
 }
 
 func (s *LanguageKeyStore) Get(uuid string) (model.LanguageKey, error) {
@@ -75,38 +110,437 @@ func (s *LanguageKeyStore) List() []model.LanguageKey {
 	return out
 }
 
-func (s *LanguageKeyStore) Update(uuid string, updated model.LanguageKey) error {
+// Update replaces a key and returns the record it replaced and the stored record. A non-zero
+// updated.Revision must match the stored revision.
+func (s *LanguageKeyStore) Update(uuid string, updated model.LanguageKey) (model.LanguageKey, model.LanguageKey, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	previous := s.items[uuid]
+	current, err := s.update(uuid, updated)
+	if err != nil {
+		return model.LanguageKey{}, model.LanguageKey{}, err
+	}
+	return previous, current, nil
+}
+
+func (s *LanguageKeyStore) update(uuid string, updated model.LanguageKey) (model.LanguageKey, error) {
 	current, exists := s.items[uuid]
 	if !exists {
-		return errors.New("key not found")
+		return model.LanguageKey{}, errors.New("key not found")
+	}
+	if updated.Revision != 0 && updated.Revision != current.Revision {
+		return model.LanguageKey{}, &util.ConflictError{Current: current}
+	}
+	if err := validateLanguageKey(updated); err != nil {
+		return model.LanguageKey{}, err
+	}
+	updated.Uuid = uuid
+	if err := s.validateReplacement(updated); err != nil {
+		return model.LanguageKey{}, err
 	}
 
 	if current.Value != updated.Value {
 		if _, exists := s.byValue[updated.Value]; exists {
-			return errors.New("key value must be unique")
+			return model.LanguageKey{}, errors.New("key value must be unique")
 		}
 		delete(s.byValue, current.Value)
 		s.byValue[updated.Value] = uuid
+		s.tree.remove(current.Value)
+		s.tree.add(updated.Value, uuid)
 	}
 
 	updated.FirstInsert = current.FirstInsert
+	updated.Revision = current.Revision + 1
 	updated.LastUpdate = time.Now().Truncate(time.Microsecond)
+	updated.Deleted = time.Time{}
 	s.items[uuid] = updated
-	return nil
+	return updated, nil
 }
 
-func (s *LanguageKeyStore) Delete(uuid string) error {
+// Delete moves a key to the trash and returns the deleted record. A non-zero
+// expectedRevision must match the stored revision.
+func (s *LanguageKeyStore) Delete(uuid string, expectedRevision int) (model.LanguageKey, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.delete(uuid, expectedRevision)
+}
+
+func (s *LanguageKeyStore) delete(uuid string, expectedRevision int) (model.LanguageKey, error) {
 	k, exists := s.items[uuid]
 	if !exists {
-		return errors.New("key not found")
+		return model.LanguageKey{}, errors.New("key not found")
 	}
-	delete(s.items, uuid)
+	if expectedRevision != 0 && expectedRevision != k.Revision {
+		return model.LanguageKey{}, &util.ConflictError{Current: k}
+	}
+	return s.discard(k, time.Now().Truncate(time.Microsecond)), nil
+}
+
+// discard moves k to the trash, freeing its Value for other keys.
+func (s *LanguageKeyStore) discard(k model.LanguageKey, at time.Time) model.LanguageKey {
+	k.Deleted = at
+	delete(s.items, k.Uuid)
 	delete(s.byValue, k.Value)
+	s.tree.remove(k.Value)
+	s.trash[k.Uuid] = k
+	return k
+}
+
+// Restore takes a key back out of the trash and returns the stored record. It fails when
+// another key has taken its Value in the meantime.
+func (s *LanguageKeyStore) Restore(uuid string) (model.LanguageKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, exists := s.trash[uuid]
+	if !exists {
+		return model.LanguageKey{}, errors.New("key not in trash")
+	}
+	if _, exists = s.byValue[k.Value]; exists {
+		return model.LanguageKey{}, errors.New("key value must be unique")
+	}
+	k.Deleted = time.Time{}
+	k.Revision++
+	k.LastUpdate = time.Now().Truncate(time.Microsecond)
+	delete(s.trash, uuid)
+	s.items[uuid] = k
+	s.byValue[k.Value] = uuid
+	s.tree.add(k.Value, uuid)
+	return k, nil
+}
+
+// Trash returns the deleted keys, oldest deletion first.
+func (s *LanguageKeyStore) Trash() []model.LanguageKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.LanguageKey, 0, len(s.trash))
+	for _, k := range s.trash {
+		out = append(out, k)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Deleted.Before(out[j].Deleted) })
+	return out
+}
+
+// Purge drops the keys deleted before cutoff from the trash and returns them.
+func (s *LanguageKeyStore) Purge(cutoff time.Time) []model.LanguageKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []model.LanguageKey
+	for id, k := range s.trash {
+		if k.Deleted.Before(cutoff) {
+			delete(s.trash, id)
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// snapshot captures the stored state of uuid and returns a func that puts it back.
+// The caller must hold the write lock for both calls.
+func (s *LanguageKeyStore) snapshot(uuid string) func() {
+	k, existed := s.items[uuid]
+	trashed, inTrash := s.trash[uuid]
+	return func() {
+		if current, ok := s.items[uuid]; ok {
+			delete(s.byValue, current.Value)
+			s.tree.remove(current.Value)
+		}
+		delete(s.items, uuid)
+		delete(s.trash, uuid)
+		if existed {
+			s.items[uuid] = k
+			s.byValue[k.Value] = uuid
+			s.tree.add(k.Value, uuid)
+		}
+		if inTrash {
+			s.trash[uuid] = trashed
+		}
+	}
+}
+
+// Upsert inserts k, or updates the key sharing its Value when there is one.
+func (s *LanguageKeyStore) Upsert(k model.LanguageKey) (model.UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, exists := s.byValue[k.Value]
+	if !exists {
+		created, err := s.insert(k)
+		if err != nil {
+			return model.UpsertResult{}, err
+		}
+		return model.UpsertResult{Outcome: model.UpsertCreated, Data: created}, nil
+	}
+
+	current := s.items[id]
+	if languageKeyContentEqual(current, k) {
+		return model.UpsertResult{Outcome: model.UpsertUnchanged, Previous: current, Data: current}, nil
+	}
+
+	k.Uuid = current.Uuid
+	updated, err := s.update(current.Uuid, k)
+	if err != nil {
+		return model.UpsertResult{}, err
+	}
+	return model.UpsertResult{Outcome: model.UpsertChanged, Previous: current, Data: updated}, nil
+}
+
+// languageKeyContentEqual compares the user-editable fields of two keys.
+func languageKeyContentEqual(a, b model.LanguageKey) bool {
+	return a.Value == b.Value &&
+		a.Description == b.Description &&
+		a.Context == b.Context &&
+		a.MaxLength == b.MaxLength &&
+		slices.Equal(a.Tags, b.Tags) &&
+		a.SourceFile == b.SourceFile &&
+		a.Deprecated == b.Deprecated &&
+		a.ReplacedBy == b.ReplacedBy
+}
+
+func validateLanguageKey(k model.LanguageKey) error {
+	if k.MaxLength < 0 {
+		return errors.New("key max length must not be negative")
+	}
+	for _, tag := range k.Tags {
+		if strings.TrimSpace(tag) == "" {
+			return errors.New("key tags must not be empty")
+		}
+	}
 	return nil
 }
+
+// validateReplacement requires the replacement of k to be a stored key whose own replacements
+// never lead back to k.
+func (s *LanguageKeyStore) validateReplacement(k model.LanguageKey) error {
+	if k.ReplacedBy == "" {
+		return nil
+	}
+	if !k.Deprecated {
+		return errors.New("only deprecated keys can have a replacement")
+	}
+	for id, steps := k.ReplacedBy, 0; id != "" && steps <= len(s.items); steps++ {
+		if id == k.Uuid {
+			return errors.New("key replacements must not loop")
+		}
+		r, ok := s.items[id]
+		if !ok {
+			if id == k.ReplacedBy {
+				return errors.New("replacement key not found")
+			}
+			break
+		}
+		id = r.ReplacedBy
+	}
+	return nil
+}
+
+// Query returns the keys matching every set field of q.
+func (s *LanguageKeyStore) Query(q model.LanguageKeyQuery) []model.LanguageKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.LanguageKey, 0)
+	for _, k := range s.items {
+		matches := true
+		for _, tag := range q.Tags {
+			if !slices.Contains(k.Tags, tag) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// ListNamespace returns the keys at or below the dotted prefix, sorted by Value. An empty
+// prefix lists every key.
+func (s *LanguageKeyStore) ListNamespace(prefix string) []model.LanguageKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listNamespace(prefix)
+}
+
+func (s *LanguageKeyStore) listNamespace(prefix string) []model.LanguageKey {
+	node := s.tree.find(prefix)
+	if node == nil {
+		return make([]model.LanguageKey, 0)
+	}
+	uuids := node.collect(nil)
+	out := make([]model.LanguageKey, len(uuids))
+	for i, id := range uuids {
+		out[i] = s.items[id]
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Value < out[j].Value })
+	return out
+}
+
+// CountNamespace returns the number of keys at or below the dotted prefix.
+func (s *LanguageKeyStore) CountNamespace(prefix string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if node := s.tree.find(prefix); node != nil {
+		return node.size
+	}
+	return 0
+}
+
+// Namespaces returns the direct children of the dotted prefix with their key counts.
+func (s *LanguageKeyStore) Namespaces(prefix string) []model.Namespace {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	node := s.tree.find(prefix)
+	if node == nil {
+		return make([]model.Namespace, 0)
+	}
+	return node.namespaces(prefix)
+}
+
+// RenameKey changes the Value of the key from to to. When aliasExpires is set, from keeps
+// resolving to the key until then.
+func (s *LanguageKeyStore) RenameKey(from, to string, aliasExpires time.Time) (model.LanguageKey, model.LanguageKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, exists := s.byValue[from]
+	if !exists {
+		return model.LanguageKey{}, model.LanguageKey{}, errors.New("key not found")
+	}
+	previous, renamed, err := s.rename([]model.LanguageKey{s.items[id]}, from, to, aliasExpires)
+	if err != nil {
+		return model.LanguageKey{}, model.LanguageKey{}, err
+	}
+	return previous[0], renamed[0], nil
+}
+
+// RenameNamespace moves every key at or below from to the same place below to, e.g.,
+// "btn.ok" becomes "common.actions.ok" when renaming "btn" to "common.actions". Either all
+// keys are renamed or none is; the records before and after are returned in the same order.
+// When aliasExpires is set, the old key strings keep resolving until then.
+func (s *LanguageKeyStore) RenameNamespace(from, to string, aliasExpires time.Time) ([]model.LanguageKey, []model.LanguageKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from == "" {
+		return nil, nil, errors.New("namespace must not be empty")
+	}
+	previous := s.listNamespace(from)
+	if len(previous) == 0 {
+		return nil, nil, errors.New("namespace not found")
+	}
+	return s.rename(previous, from, to, aliasExpires)
+}
+
+// rename replaces the leading from of each key value with to. Values stay attached since they
+// refer to keys by Uuid.
+func (s *LanguageKeyStore) rename(previous []model.LanguageKey, from, to string, aliasExpires time.Time) ([]model.LanguageKey, []model.LanguageKey, error) {
+	if to == "" {
+		return nil, nil, errors.New("key value must not be empty")
+	}
+	if to == from {
+		return nil, nil, errors.New("rename must change the key value")
+	}
+
+	moving := make(map[string]bool, len(previous))
+	for _, k := range previous {
+		moving[k.Uuid] = true
+	}
+	for _, k := range previous {
+		value := to + strings.TrimPrefix(k.Value, from)
+		if id, exists := s.byValue[value]; exists && !moving[id] {
+			return nil, nil, fmt.Errorf("key %q already exists", value)
+		}
+	}
+
+	for _, k := range previous {
+		s.tree.remove(k.Value)
+		delete(s.byValue, k.Value)
+	}
+	now := time.Now().Truncate(time.Microsecond)
+	renamed := make([]model.LanguageKey, len(previous))
+	for i, k := range previous {
+		k.Value = to + strings.TrimPrefix(k.Value, from)
+		k.Revision++
+		k.LastUpdate = now
+		s.items[k.Uuid] = k
+		s.byValue[k.Value] = k.Uuid
+		s.tree.add(k.Value, k.Uuid)
+		delete(s.aliases, k.Value)
+		renamed[i] = k
+	}
+
+	if !aliasExpires.IsZero() {
+		for _, k := range previous {
+			s.aliases[k.Value] = model.LanguageKeyAlias{Alias: k.Value, UuidLanguageKey: k.Uuid, Expires: aliasExpires}
+		}
+	}
+	for alias, a := range s.aliases {
+		if now.After(a.Expires) {
+			delete(s.aliases, alias)
+		}
+	}
+	return previous, renamed, nil
+}
+
+// Aliases returns the aliases that still resolve at now, sorted by Alias. Aliases whose key
+// is gone, or whose string is taken by a key again, do not resolve.
+func (s *LanguageKeyStore) Aliases(now time.Time) []model.LanguageKeyAlias {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.LanguageKeyAlias, 0)
+	for alias, a := range s.aliases {
+		if _, taken := s.byValue[alias]; taken || now.After(a.Expires) {
+			continue
+		}
+		if _, ok := s.items[a.UuidLanguageKey]; ok {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+	return out
+}
+
+// AliasTargets maps each alias that resolves at now to the current Value of its key.
+func (s *LanguageKeyStore) AliasTargets(now time.Time) map[string]string {
+	aliases := s.Aliases(now)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		if k, ok := s.items[a.UuidLanguageKey]; ok {
+			out[a.Alias] = k.Value
+		}
+	}
+	return out
+}
+
+// DeleteNamespace moves every key at or below the dotted prefix to the trash and returns them.
+func (s *LanguageKeyStore) DeleteNamespace(prefix string) ([]model.LanguageKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prefix == "" {
+		return nil, errors.New("namespace must not be empty")
+	}
+	deleted := s.listNamespace(prefix)
+	if len(deleted) == 0 {
+		return nil, errors.New("namespace not found")
+	}
+	now := time.Now().Truncate(time.Microsecond)
+	for i, k := range deleted {
+		deleted[i] = s.discard(k, now)
+	}
+	return deleted, nil
+}
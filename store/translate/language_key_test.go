@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
 )
 
 func TestLanguageKeyStore_InsertAndGet(t *testing.T) {
@@ -18,7 +21,7 @@ func TestLanguageKeyStore_InsertAndGet(t *testing.T) {
 		Value: val,
 	}
 
-	if err := store.Insert(key); err != nil {
+	if _, err := store.Insert(key); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
@@ -37,10 +40,10 @@ func TestLanguageKeyStore_InsertDuplicateUuid(t *testing.T) {
 	id := uuid.NewString()
 	key := model.LanguageKey{Uuid: id, Value: "foo"}
 
-	if err := store.Insert(key); err != nil {
+	if _, err := store.Insert(key); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
-	if err := store.Insert(key); err == nil {
+	if _, err := store.Insert(key); err == nil {
 		t.Error("Expected error on duplicate UUID insert, got nil")
 	}
 }
@@ -51,10 +54,10 @@ func TestLanguageKeyStore_InsertDuplicateValue(t *testing.T) {
 	key1 := model.LanguageKey{Uuid: uuid.NewString(), Value: "shared"}
 	key2 := model.LanguageKey{Uuid: uuid.NewString(), Value: "shared"}
 
-	if err := store.Insert(key1); err != nil {
+	if _, err := store.Insert(key1); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
-	if err := store.Insert(key2); err == nil {
+	if _, err := store.Insert(key2); err == nil {
 		t.Error("Expected error on duplicate Value insert, got nil")
 	}
 }
@@ -65,7 +68,7 @@ func TestLanguageKeyStore_GetByValue(t *testing.T) {
 	val := "product.title"
 	key := model.LanguageKey{Uuid: uuid.NewString(), Value: val}
 
-	if err := store.Insert(key); err != nil {
+	if _, err := store.Insert(key); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
@@ -99,12 +102,12 @@ func TestLanguageKeyStore_Update(t *testing.T) {
 
 	id := uuid.NewString()
 	key := model.LanguageKey{Uuid: id, Value: "before"}
-	if err := store.Insert(key); err != nil {
+	if _, err := store.Insert(key); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
 	updated := model.LanguageKey{Uuid: id, Value: "after"}
-	if err := store.Update(id, updated); err != nil {
+	if _, _, err := store.Update(id, updated); err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
 
@@ -126,7 +129,7 @@ func TestLanguageKeyStore_Update_DuplicateValue(t *testing.T) {
 	store.Insert(model.LanguageKey{Uuid: id2, Value: "val2"})
 
 	// Attempt to update id2 to use val1 → should fail
-	err := store.Update(id2, model.LanguageKey{Uuid: id2, Value: "val1"})
+	_, _, err := store.Update(id2, model.LanguageKey{Uuid: id2, Value: "val1"})
 	if err == nil {
 		t.Error("Expected error on value conflict during update")
 	}
@@ -139,7 +142,7 @@ func TestLanguageKeyStore_Delete(t *testing.T) {
 	key := model.LanguageKey{Uuid: id, Value: "deletable"}
 	store.Insert(key)
 
-	if err := store.Delete(id); err != nil {
+	if _, err := store.Delete(id, 0); err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
@@ -155,8 +158,224 @@ func TestLanguageKeyStore_Delete(t *testing.T) {
 func TestLanguageKeyStore_Delete_NotFound(t *testing.T) {
 	store := NewLanguageKeyStore()
 
-	err := store.Delete("nonexistent")
+	_, err := store.Delete("nonexistent", 0)
 	if err == nil {
 		t.Error("Expected error on delete of nonexistent key")
 	}
 }
+
+func TestLanguageKeyStore_Update_RevisionConflict(t *testing.T) {
+	store := NewLanguageKeyStore()
+
+	id := uuid.NewString()
+	store.Insert(model.LanguageKey{Uuid: id, Value: "conflict.first"})
+	store.Update(id, model.LanguageKey{Uuid: id, Value: "conflict.second", Revision: 1})
+
+	_, _, err := store.Update(id, model.LanguageKey{Uuid: id, Value: "conflict.stale", Revision: 1})
+	var conflict *util.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if conflict.Current.(model.LanguageKey).Value != "conflict.second" {
+		t.Errorf("Conflict should carry the stored record, got %+v", conflict.Current)
+	}
+	if _, err := store.GetByValue("conflict.stale"); err == nil {
+		t.Error("Rejected update must not touch the value index")
+	}
+}
+
+func TestLanguageKeyStore_Upsert(t *testing.T) {
+	store := NewLanguageKeyStore()
+
+	created, err := store.Upsert(model.LanguageKey{Value: "checkout.button.pay"})
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if created.Outcome != model.UpsertCreated || created.Data.(model.LanguageKey).Uuid == "" {
+		t.Errorf("Unexpected upsert result: %+v", created)
+	}
+
+	again, _ := store.Upsert(model.LanguageKey{Value: "checkout.button.pay"})
+	if again.Outcome != model.UpsertUnchanged {
+		t.Errorf("Expected outcome %s, got %s", model.UpsertUnchanged, again.Outcome)
+	}
+	if len(store.List()) != 1 {
+		t.Errorf("Expected 1 key, got %d", len(store.List()))
+	}
+}
+
+func TestLanguageKeyStore_Insert_GeneratesUuid(t *testing.T) {
+	store := NewLanguageKeyStore()
+
+	created, err := store.Insert(model.LanguageKey{Value: "generated.uuid"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if got, err := store.GetByValue("generated.uuid"); err != nil || got.Uuid != created.Uuid {
+		t.Errorf("Expected value index to point at generated Uuid, got %+v (%v)", got, err)
+	}
+	if _, err := store.Insert(model.LanguageKey{Uuid: "bad", Value: "bad.uuid"}); err == nil {
+		t.Error("Expected error for malformed Uuid")
+	}
+}
+
+func TestLanguageKeyStore_Metadata(t *testing.T) {
+	store := NewLanguageKeyStore()
+
+	created, err := store.Insert(model.LanguageKey{
+		Value:       "checkout.button.pay",
+		Description: "Confirms the payment",
+		Context:     "Button on the checkout page",
+		MaxLength:   20,
+		Tags:        []string{"checkout", "mobile"},
+		SourceFile:  "web/src/checkout/Pay.tsx:42",
+	})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if got, _ := store.Get(created.Uuid); got.Context != "Button on the checkout page" || got.MaxLength != 20 || len(got.Tags) != 2 {
+		t.Errorf("Metadata not stored: %+v", got)
+	}
+
+	if _, err := store.Insert(model.LanguageKey{Value: "negative", MaxLength: -1}); err == nil {
+		t.Error("Expected error for negative MaxLength")
+	}
+	if _, err := store.Insert(model.LanguageKey{Value: "blank.tag", Tags: []string{"checkout", " "}}); err == nil {
+		t.Error("Expected error for empty tag")
+	}
+
+	changed := created
+	changed.Tags = []string{"checkout"}
+	result, err := store.Upsert(changed)
+	if err != nil || result.Outcome != model.UpsertChanged {
+		t.Errorf("Expected tag change to update the key, got %+v (%v)", result, err)
+	}
+}
+
+func TestLanguageKeyStore_Query(t *testing.T) {
+	store := NewLanguageKeyStore()
+
+	store.Insert(model.LanguageKey{Value: "checkout.pay", Tags: []string{"checkout", "mobile"}})
+	store.Insert(model.LanguageKey{Value: "checkout.cancel", Tags: []string{"checkout"}})
+	store.Insert(model.LanguageKey{Value: "home.title"})
+
+	if got := store.Query(model.LanguageKeyQuery{}); len(got) != 3 {
+		t.Errorf("Expected empty query to match 3 keys, got %d", len(got))
+	}
+	if got := store.Query(model.LanguageKeyQuery{Tags: []string{"checkout"}}); len(got) != 2 {
+		t.Errorf("Expected 2 checkout keys, got %+v", got)
+	}
+	got := store.Query(model.LanguageKeyQuery{Tags: []string{"checkout", "mobile"}})
+	if len(got) != 1 || got[0].Value != "checkout.pay" {
+		t.Errorf("Expected only checkout.pay, got %+v", got)
+	}
+}
+
+func TestLanguageKeyStore_RenameKey(t *testing.T) {
+	store := NewLanguageKeyStore()
+	ok, _ := store.Insert(model.LanguageKey{Value: "btn.ok", Description: "Confirms a dialog"})
+	store.Insert(model.LanguageKey{Value: "btn.ok.tooltip"})
+	store.Insert(model.LanguageKey{Value: "common.actions.cancel"})
+
+	if _, _, err := store.RenameKey("btn.ok", "common.actions.cancel", time.Time{}); err == nil {
+		t.Error("Expected rename onto an existing key to fail")
+	}
+	if _, _, err := store.RenameKey("btn.missing", "common.actions.missing", time.Time{}); err == nil {
+		t.Error("Expected rename of an unknown key to fail")
+	}
+
+	now := time.Now()
+	previous, renamed, err := store.RenameKey("btn.ok", "common.actions.ok", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("RenameKey failed: %v", err)
+	}
+	if previous.Value != "btn.ok" || renamed.Uuid != ok.Uuid || renamed.Value != "common.actions.ok" || renamed.Description != "Confirms a dialog" {
+		t.Errorf("Unexpected rename result: %+v -> %+v", previous, renamed)
+	}
+	if _, err := store.GetByValue("btn.ok.tooltip"); err != nil {
+		t.Error("Expected key rename to leave keys below it alone")
+	}
+
+	aliases := store.Aliases(now)
+	if len(aliases) != 1 || aliases[0].Alias != "btn.ok" || aliases[0].UuidLanguageKey != ok.Uuid {
+		t.Errorf("Unexpected aliases: %+v", aliases)
+	}
+	if targets := store.AliasTargets(now); targets["btn.ok"] != "common.actions.ok" {
+		t.Errorf("Expected alias to point at the new key string, got %+v", targets)
+	}
+	if expired := store.Aliases(now.Add(2 * time.Hour)); len(expired) != 0 {
+		t.Errorf("Expected alias to expire, got %+v", expired)
+	}
+
+	store.RenameKey("common.actions.ok", "common.ok", now.Add(time.Hour))
+	if targets := store.AliasTargets(now); targets["btn.ok"] != "common.ok" || targets["common.actions.ok"] != "common.ok" {
+		t.Errorf("Expected chained aliases to follow the key, got %+v", targets)
+	}
+
+	store.Insert(model.LanguageKey{Value: "btn.ok"})
+	if targets := store.AliasTargets(now); len(targets) != 1 {
+		t.Errorf("Expected a new key to shadow its alias, got %+v", targets)
+	}
+}
+
+func TestLanguageKeyStore_Deprecation(t *testing.T) {
+	store := NewLanguageKeyStore()
+	current, _ := store.Insert(model.LanguageKey{Value: "common.ok"})
+
+	if _, err := store.Insert(model.LanguageKey{Value: "btn.ok", ReplacedBy: current.Uuid}); err == nil {
+		t.Error("Expected replacement on a key that is not deprecated to fail")
+	}
+	if _, err := store.Insert(model.LanguageKey{Value: "btn.ok", Deprecated: true, ReplacedBy: uuid.NewString()}); err == nil {
+		t.Error("Expected unknown replacement to fail")
+	}
+
+	old, err := store.Insert(model.LanguageKey{Value: "btn.ok", Deprecated: true, ReplacedBy: current.Uuid})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	current.Deprecated = true
+	current.ReplacedBy = old.Uuid
+	if _, _, err := store.Update(current.Uuid, current); err == nil {
+		t.Error("Expected a replacement loop to fail")
+	}
+	current.ReplacedBy = current.Uuid
+	if _, _, err := store.Update(current.Uuid, current); err == nil {
+		t.Error("Expected a key replacing itself to fail")
+	}
+}
+
+func TestLanguageKeyStore_TrashAndRestore(t *testing.T) {
+	store := NewLanguageKeyStore()
+	created, _ := store.Insert(model.LanguageKey{Value: "checkout.pay"})
+	store.Insert(model.LanguageKey{Value: "checkout.cancel"})
+
+	if _, err := store.Delete(created.Uuid, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if n := store.CountNamespace("checkout"); n != 1 {
+		t.Errorf("Expected deleted key to leave the namespace, got %d keys", n)
+	}
+
+	replacement, _ := store.Insert(model.LanguageKey{Value: "checkout.pay"})
+	if _, err := store.Restore(created.Uuid); err == nil {
+		t.Error("Expected restore to fail while another key has the same value")
+	}
+	store.Delete(replacement.Uuid, 0)
+
+	restored, err := store.Restore(created.Uuid)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got, err := store.GetByValue("checkout.pay"); err != nil || got.Uuid != created.Uuid || restored.Revision != 2 {
+		t.Errorf("Expected restored key to be found by value, got %+v (%v)", got, err)
+	}
+	if trash := store.Trash(); len(trash) != 1 || trash[0].Uuid != replacement.Uuid {
+		t.Errorf("Unexpected trash: %+v", trash)
+	}
+
+	deleted, _ := store.DeleteNamespace("checkout")
+	if len(deleted) != 2 || deleted[0].Deleted.IsZero() || len(store.Trash()) != 3 {
+		t.Errorf("Expected namespace delete to move keys to the trash, got %+v", deleted)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
 )
 
 func TestLanguageStore_InsertAndGet(t *testing.T) {
@@ -21,7 +24,7 @@ func TestLanguageStore_InsertAndGet(t *testing.T) {
 		MonthsShort: "Jan,Feb,Mar,Apr,May,Jun,Jul,Aug,Sep,Oct,Nov,Dec",
 	}
 
-	if err := store.Insert(lang); err != nil {
+	if _, err := store.Insert(lang); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
@@ -41,11 +44,11 @@ func TestLanguageStore_InsertDuplicate(t *testing.T) {
 	id := uuid.NewString()
 	lang := model.Language{Uuid: id, Lang: "English"}
 
-	if err := store.Insert(lang); err != nil {
+	if _, err := store.Insert(lang); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
-	if err := store.Insert(lang); err == nil {
+	if _, err := store.Insert(lang); err == nil {
 		t.Fatal("Expected error for duplicate insert, got nil")
 	}
 }
@@ -78,12 +81,12 @@ func TestLanguageStore_Update(t *testing.T) {
 
 	id := uuid.NewString()
 	original := model.Language{Uuid: id, Lang: "Old"}
-	if err := store.Insert(original); err != nil {
+	if _, err := store.Insert(original); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
 	updated := model.Language{Uuid: id, Lang: "Updated"}
-	if err := store.Update(id, updated); err != nil {
+	if _, _, err := store.Update(id, updated); err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
 
@@ -99,7 +102,7 @@ func TestLanguageStore_Update(t *testing.T) {
 func TestLanguageStore_Update_NotFound(t *testing.T) {
 	store := NewLanguageStore()
 
-	err := store.Update("nonexistent", model.Language{Uuid: "nonexistent", Lang: "Doesn't Matter"})
+	_, _, err := store.Update("nonexistent", model.Language{Uuid: "nonexistent", Lang: "Doesn't Matter"})
 	if err == nil {
 		t.Error("Expected error for update on nonexistent item")
 	}
@@ -111,7 +114,7 @@ func TestLanguageStore_Delete(t *testing.T) {
 	id := uuid.NewString()
 	store.Insert(model.Language{Uuid: id, Lang: "DeleteMe"})
 
-	if err := store.Delete(id); err != nil {
+	if _, err := store.Delete(id, 0); err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
@@ -123,8 +126,215 @@ func TestLanguageStore_Delete(t *testing.T) {
 func TestLanguageStore_Delete_NotFound(t *testing.T) {
 	store := NewLanguageStore()
 
-	err := store.Delete("not-there")
+	_, err := store.Delete("not-there", 0)
 	if err == nil {
 		t.Error("Expected error on deleting non-existent language")
 	}
 }
+
+func TestLanguageStore_Update_RevisionConflict(t *testing.T) {
+	store := NewLanguageStore()
+
+	id := uuid.NewString()
+	store.Insert(model.Language{Uuid: id, Lang: "First"})
+
+	if _, _, err := store.Update(id, model.Language{Uuid: id, Lang: "Second", Revision: 1}); err != nil {
+		t.Fatalf("Update with matching revision failed: %v", err)
+	}
+
+	_, _, err := store.Update(id, model.Language{Uuid: id, Lang: "Stale", Revision: 1})
+	var conflict *util.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	current, ok := conflict.Current.(model.Language)
+	if !ok || current.Lang != "Second" || current.Revision != 2 {
+		t.Errorf("Conflict should carry the stored record, got %+v", conflict.Current)
+	}
+}
+
+func TestLanguageStore_Delete_RevisionConflict(t *testing.T) {
+	store := NewLanguageStore()
+
+	id := uuid.NewString()
+	store.Insert(model.Language{Uuid: id, Lang: "Keep"})
+	store.Update(id, model.Language{Uuid: id, Lang: "Keep"})
+
+	var conflict *util.ConflictError
+	if _, err := store.Delete(id, 1); !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if _, err := store.Delete(id, 2); err != nil {
+		t.Fatalf("Delete with matching revision failed: %v", err)
+	}
+}
+
+func TestLanguageStore_Upsert(t *testing.T) {
+	store := NewLanguageStore()
+
+	created, err := store.Upsert(model.Language{Prefix: "de-DE", Lang: "German"})
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if created.Outcome != model.UpsertCreated {
+		t.Errorf("Expected outcome %s, got %s", model.UpsertCreated, created.Outcome)
+	}
+	id := created.Data.(model.Language).Uuid
+	if id == "" {
+		t.Fatal("Expected created language to get a Uuid")
+	}
+
+	unchanged, _ := store.Upsert(model.Language{Prefix: "de-DE", Lang: "German"})
+	if unchanged.Outcome != model.UpsertUnchanged {
+		t.Errorf("Expected outcome %s, got %s", model.UpsertUnchanged, unchanged.Outcome)
+	}
+
+	changed, _ := store.Upsert(model.Language{Prefix: "de-DE", Lang: "Deutsch"})
+	if changed.Outcome != model.UpsertChanged {
+		t.Errorf("Expected outcome %s, got %s", model.UpsertChanged, changed.Outcome)
+	}
+	if got := changed.Data.(model.Language); got.Uuid != id || got.Lang != "Deutsch" || got.Revision != 2 {
+		t.Errorf("Unexpected stored language: %+v", got)
+	}
+	if len(store.List()) != 1 {
+		t.Errorf("Expected 1 language, got %d", len(store.List()))
+	}
+}
+
+func TestLanguageStore_Upsert_AmbiguousPrefix(t *testing.T) {
+	store := NewLanguageStore()
+
+	store.Insert(model.Language{Uuid: uuid.NewString(), Prefix: "en-US"})
+	store.Insert(model.Language{Uuid: uuid.NewString(), Prefix: "en-US"})
+
+	if _, err := store.Upsert(model.Language{Prefix: "en-US"}); err == nil {
+		t.Error("Expected error when prefix matches several languages")
+	}
+	if _, err := store.GetByPrefix("en-US"); err == nil {
+		t.Error("Expected error when prefix matches several languages")
+	}
+}
+
+func TestLanguageStore_Insert_GeneratesUuid(t *testing.T) {
+	store := NewLanguageStore()
+
+	created, err := store.Insert(model.Language{Prefix: "sv-SE", Lang: "Swedish"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	parsed, err := uuid.Parse(created.Uuid)
+	if err != nil || parsed.Version() != 7 {
+		t.Errorf("Expected generated v7 Uuid, got %q", created.Uuid)
+	}
+	if created.FirstInsert.IsZero() || !created.LastUpdate.Equal(created.FirstInsert) || created.Revision != 1 {
+		t.Errorf("Expected stored timestamps and revision, got %+v", created)
+	}
+	if _, err := store.Get(created.Uuid); err != nil {
+		t.Errorf("Get with generated Uuid failed: %v", err)
+	}
+}
+
+func TestLanguageStore_Insert_MalformedUuid(t *testing.T) {
+	store := NewLanguageStore()
+
+	if _, err := store.Insert(model.Language{Uuid: "not-a-uuid", Lang: "Broken"}); err == nil {
+		t.Error("Expected error for malformed Uuid")
+	}
+	if len(store.List()) != 0 {
+		t.Error("Malformed insert must not be stored")
+	}
+}
+
+func TestLanguageStore_UpdateAndDelete_ReturnStoredRecord(t *testing.T) {
+	store := NewLanguageStore()
+
+	created, _ := store.Insert(model.Language{Lang: "Before"})
+
+	_, updated, err := store.Update(created.Uuid, model.Language{Uuid: created.Uuid, Lang: "After"})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.Lang != "After" || !updated.FirstInsert.Equal(created.FirstInsert) || updated.Revision != 2 || updated.LastUpdate.IsZero() {
+		t.Errorf("Update should return the stored record, got %+v", updated)
+	}
+
+	deleted, err := store.Delete(created.Uuid, 0)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if deleted.Deleted.IsZero() {
+		t.Error("Delete should set the deletion timestamp")
+	}
+	deleted.Deleted = time.Time{}
+	if deleted != updated {
+		t.Errorf("Delete should return the deleted record, got %+v, want %+v", deleted, updated)
+	}
+}
+
+func TestLanguageStore_PluralCategories(t *testing.T) {
+	store := NewLanguageStore()
+
+	created, err := store.Insert(model.Language{Prefix: "pl-PL", PluralCategories: "other"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if got := created.PluralCategories; got != "one,few,many,other" {
+		t.Errorf("Expected Polish cardinal categories, got %s", got)
+	}
+
+	created.Prefix = "en-US"
+	_, updated, err := store.Update(created.Uuid, created)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if got := updated.PluralCategories; got != "one,other" {
+		t.Errorf("Expected English cardinal categories after update, got %s", got)
+	}
+	if got := updated.OrdinalCategories; got != "one,two,few,other" {
+		t.Errorf("Expected English ordinal categories after update, got %s", got)
+	}
+}
+
+func TestLanguageStore_TrashAndRestore(t *testing.T) {
+	store := NewLanguageStore()
+	created, _ := store.Insert(model.Language{Prefix: "sv-SE", Lang: "Swedish"})
+
+	deleted, err := store.Delete(created.Uuid, 0)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(created.Uuid); err == nil {
+		t.Error("Expected deleted language to be hidden from Get")
+	}
+	if _, err := store.GetByPrefix("sv-SE"); err == nil || len(store.List()) != 0 {
+		t.Error("Expected deleted language to be hidden from lookups")
+	}
+	if trash := store.Trash(); len(trash) != 1 || trash[0].Uuid != created.Uuid || !trash[0].Deleted.Equal(deleted.Deleted) {
+		t.Errorf("Unexpected trash: %+v", trash)
+	}
+	if _, err := store.Insert(model.Language{Uuid: created.Uuid}); err == nil {
+		t.Error("Expected insert with the Uuid of a trashed language to fail")
+	}
+
+	restored, err := store.Restore(created.Uuid)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if !restored.Deleted.IsZero() || restored.Revision != 2 || restored.Lang != "Swedish" {
+		t.Errorf("Unexpected restored language: %+v", restored)
+	}
+	if len(store.Trash()) != 0 {
+		t.Error("Expected trash to be empty after restore")
+	}
+	if _, err := store.Restore(created.Uuid); err == nil {
+		t.Error("Expected restoring a language that is not in the trash to fail")
+	}
+
+	store.Delete(created.Uuid, 0)
+	if purged := store.Purge(time.Now().Add(-time.Minute)); len(purged) != 0 {
+		t.Errorf("Expected recent deletion to survive the purge, got %+v", purged)
+	}
+	if purged := store.Purge(time.Now().Add(time.Minute)); len(purged) != 1 || len(store.Trash()) != 0 {
+		t.Errorf("Expected deletion to be purged, got %+v", purged)
+	}
+}
@@ -2,34 +2,83 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/rah-0/meisterwerk/icu"
 	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
 )
 
+// valueTransitions lists the statuses each workflow status may move to.
+var valueTransitions = map[string][]string{
+	model.ValueStatusDraft:       {model.ValueStatusNeedsReview},
+	model.ValueStatusNeedsReview: {model.ValueStatusApproved, model.ValueStatusDraft},
+	model.ValueStatusApproved:    {model.ValueStatusPublished, model.ValueStatusDraft},
+	model.ValueStatusPublished:   {model.ValueStatusDraft},
+}
+
+// languageValueHistoryLimit is how many revisions are kept per value before the oldest are dropped.
+const languageValueHistoryLimit = 50
+
 type LanguageValueStore struct {
-	mu    sync.RWMutex
-	items map[string]model.LanguageValue
+	mu           sync.RWMutex
+	items        map[string]model.LanguageValue
+	revisions    map[string][]model.LanguageValueRevision // map[Uuid]revisions, oldest first, kept for trashed values too
+	trash        map[string]model.LanguageValue           // Deleted values, kept until purged
+	historyLimit int
+
+	sourceLanguage string // Uuid of the language translations are tracked against, "" when there is none
 }
 
 func NewLanguageValueStore() *LanguageValueStore {
 	return &LanguageValueStore{
-		items: make(map[string]model.LanguageValue),
+		items:        make(map[string]model.LanguageValue),
+		revisions:    make(map[string][]model.LanguageValueRevision),
+		trash:        make(map[string]model.LanguageValue),
+		historyLimit: languageValueHistoryLimit,
 	}
 }
 
-func (s *LanguageValueStore) Insert(v model.LanguageValue) error {
+// Insert stores v, assigning a Uuid when it has none, and returns the stored record.
+func (s *LanguageValueStore) Insert(v model.LanguageValue) (model.LanguageValue, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.insert(v)
+}
+
+func (s *LanguageValueStore) insert(v model.LanguageValue) (model.LanguageValue, error) {
+	id, err := util.UuidEnsure(v.Uuid)
+	if err != nil {
+		return model.LanguageValue{}, err
+	}
+	v.Uuid = id
+
 	if _, exists := s.items[v.Uuid]; exists {
-		return errors.New("value already exists")
+		return model.LanguageValue{}, errors.New("value already exists")
+	}
+	if _, exists := s.trash[v.Uuid]; exists {
+		return model.LanguageValue{}, errors.New("value is in the trash")
+	}
+	if err = icu.Validate(v.Value); err != nil {
+		return model.LanguageValue{}, err
 	}
 
 	v.FirstInsert = time.Now().Truncate(time.Microsecond)
+	v.LastUpdate = v.FirstInsert
+	v.Revision = 1
+	v.Status = model.ValueStatusDraft
+	v.StatusComment = ""
+	v.Deleted = time.Time{}
+	s.track(&v)
 	s.items[v.Uuid] = v
-	return nil
+	s.addRevision(v, v.FirstInsert)
+	s.markDependents(v)
+	return v, nil
 }
 
 func (s *LanguageValueStore) Get(uuid string) (model.LanguageValue, error) {
@@ -58,28 +107,393 @@ func (s *LanguageValueStore) List() ([]model.LanguageValue, error) {
 	return out, nil
 }
 
-func (s *LanguageValueStore) Update(uuid string, updated model.LanguageValue) error {
+// Update replaces a value and returns the record it replaced and the stored record. A non-zero
+// updated.Revision must match the stored revision.
+func (s *LanguageValueStore) Update(uuid string, updated model.LanguageValue) (model.LanguageValue, model.LanguageValue, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	previous := s.items[uuid]
+	current, err := s.update(uuid, updated)
+	if err != nil {
+		return model.LanguageValue{}, model.LanguageValue{}, err
+	}
+	return previous, current, nil
+}
+
+func (s *LanguageValueStore) update(uuid string, updated model.LanguageValue) (model.LanguageValue, error) {
 	current, exists := s.items[uuid]
 	if !exists {
-		return errors.New("value not found")
+		return model.LanguageValue{}, errors.New("value not found")
+	}
+	if updated.Revision != 0 && updated.Revision != current.Revision {
+		return model.LanguageValue{}, &util.ConflictError{Current: current}
+	}
+	if err := icu.Validate(updated.Value); err != nil {
+		return model.LanguageValue{}, err
 	}
 
 	updated.FirstInsert = current.FirstInsert
+	updated.Revision = current.Revision + 1
 	updated.LastUpdate = time.Now().Truncate(time.Microsecond)
+	updated.Status, updated.StatusComment = current.Status, current.StatusComment
+	if updated.Value != current.Value {
+		updated.Status, updated.StatusComment = model.ValueStatusDraft, ""
+	}
+	updated.Deleted = time.Time{}
+	s.track(&updated)
 	s.items[uuid] = updated
-	return nil
+	s.addRevision(updated, updated.LastUpdate)
+	if updated.Value != current.Value {
+		s.markDependents(updated)
+	}
+	return updated, nil
 }
 
-func (s *LanguageValueStore) Delete(uuid string) error {
+// Delete moves a value to the trash and returns the deleted record. Its revisions are kept
+// until it is purged. A non-zero expectedRevision must match the stored revision.
+func (s *LanguageValueStore) Delete(uuid string, expectedRevision int) (model.LanguageValue, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.items[uuid]; !exists {
-		return errors.New("value not found")
+	return s.delete(uuid, expectedRevision)
+}
+
+func (s *LanguageValueStore) delete(uuid string, expectedRevision int) (model.LanguageValue, error) {
+	current, exists := s.items[uuid]
+	if !exists {
+		return model.LanguageValue{}, errors.New("value not found")
 	}
+	if expectedRevision != 0 && expectedRevision != current.Revision {
+		return model.LanguageValue{}, &util.ConflictError{Current: current}
+	}
+	current.Deleted = time.Now().Truncate(time.Microsecond)
 	delete(s.items, uuid)
-	return nil
+	s.trash[uuid] = current
+	return current, nil
+}
+
+// Restore takes a value back out of the trash and returns the stored record. A translation
+// whose source text changed while it was in the trash is flagged for review.
+func (s *LanguageValueStore) Restore(uuid string) (model.LanguageValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, exists := s.trash[uuid]
+	if !exists {
+		return model.LanguageValue{}, errors.New("value not in trash")
+	}
+	v.Deleted = time.Time{}
+	v.Revision++
+	v.LastUpdate = time.Now().Truncate(time.Microsecond)
+	if s.sourceLanguage != "" && v.UuidLanguage != s.sourceLanguage {
+		if source, ok := s.findByLanguageKey(s.sourceLanguage, v.UuidLanguageKey); ok && s.sourceChanged(source, v.SourceRevision) {
+			v.NeedsReview = true
+		}
+	}
+	delete(s.trash, uuid)
+	s.items[uuid] = v
+	s.addRevision(v, v.LastUpdate)
+	return v, nil
+}
+
+// Trash returns the deleted values, oldest deletion first.
+func (s *LanguageValueStore) Trash() []model.LanguageValue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.LanguageValue, 0, len(s.trash))
+	for _, v := range s.trash {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Deleted.Before(out[j].Deleted) })
+	return out
+}
+
+// Purge drops the values deleted before cutoff from the trash, with their revisions, and
+// returns them.
+func (s *LanguageValueStore) Purge(cutoff time.Time) []model.LanguageValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []model.LanguageValue
+	for id, v := range s.trash {
+		if v.Deleted.Before(cutoff) {
+			delete(s.trash, id)
+			delete(s.revisions, id)
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Revisions returns the retained revisions of a value, oldest first.
+func (s *LanguageValueStore) Revisions(uuid string) ([]model.LanguageValueRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revs, ok := s.revisions[uuid]
+	if !ok {
+		return nil, errors.New("value not found")
+	}
+	return append([]model.LanguageValueRevision(nil), revs...), nil
+}
+
+func (s *LanguageValueStore) Revision(uuid string, revision int) (model.LanguageValueRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revision(uuid, revision)
+}
+
+// Diff compares the text of two revisions of the same value.
+func (s *LanguageValueStore) Diff(uuid string, from, to int) ([]model.DiffSegment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, err := s.revision(uuid, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.revision(uuid, to)
+	if err != nil {
+		return nil, err
+	}
+	return util.Diff(a.Value, b.Value), nil
+}
+
+// Revert stores the text of an older revision as a new revision and returns the value it
+// replaced and the resulting value. A non-zero expectedRevision must match the stored revision.
+func (s *LanguageValueStore) Revert(uuid string, revision, expectedRevision int) (model.LanguageValue, model.LanguageValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.items[uuid]
+	if !exists {
+		return model.LanguageValue{}, model.LanguageValue{}, errors.New("value not found")
+	}
+	if expectedRevision != 0 && expectedRevision != previous.Revision {
+		return model.LanguageValue{}, model.LanguageValue{}, &util.ConflictError{Current: previous}
+	}
+	old, err := s.revision(uuid, revision)
+	if err != nil {
+		return model.LanguageValue{}, model.LanguageValue{}, err
+	}
+
+	current := previous
+	if current.Value != old.Value {
+		current.Status, current.StatusComment = model.ValueStatusDraft, ""
+	}
+	current.Value = old.Value
+	current.Revision++
+	current.LastUpdate = time.Now().Truncate(time.Microsecond)
+	s.track(&current)
+	s.items[uuid] = current
+	s.addRevision(current, current.LastUpdate)
+	if current.Value != previous.Value {
+		s.markDependents(current)
+	}
+	return previous, current, nil
+}
+
+func (s *LanguageValueStore) revision(uuid string, revision int) (model.LanguageValueRevision, error) {
+	revs, ok := s.revisions[uuid]
+	if !ok {
+		return model.LanguageValueRevision{}, errors.New("value not found")
+	}
+	for _, r := range revs {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+	return model.LanguageValueRevision{}, errors.New("revision not found")
+}
+
+func (s *LanguageValueStore) addRevision(v model.LanguageValue, at time.Time) {
+	revs := append(s.revisions[v.Uuid], model.LanguageValueRevision{Revision: v.Revision, Timestamp: at, Value: v.Value})
+	if len(revs) > s.historyLimit {
+		revs = append([]model.LanguageValueRevision(nil), revs[len(revs)-s.historyLimit:]...)
+	}
+	s.revisions[v.Uuid] = revs
+}
+
+// snapshot captures the stored state of uuid, and of the other values of keyUuid whose review
+// flag a change to it may set, and returns a func that puts it back.
+// The caller must hold the write lock for both calls.
+func (s *LanguageValueStore) snapshot(uuid, keyUuid string) func() {
+	v, existed := s.items[uuid]
+	trashed, inTrash := s.trash[uuid]
+	revs, hasRevs := s.revisions[uuid]
+	revs = append([]model.LanguageValueRevision(nil), revs...)
+	var dependents []model.LanguageValue
+	for _, d := range s.items {
+		if d.Uuid != uuid && (d.UuidLanguageKey == keyUuid || existed && d.UuidLanguageKey == v.UuidLanguageKey) {
+			dependents = append(dependents, d)
+		}
+	}
+	return func() {
+		delete(s.items, uuid)
+		delete(s.trash, uuid)
+		delete(s.revisions, uuid)
+		if existed {
+			s.items[uuid] = v
+		}
+		if inTrash {
+			s.trash[uuid] = trashed
+		}
+		if hasRevs {
+			s.revisions[uuid] = revs
+		}
+		for _, d := range dependents {
+			s.items[d.Uuid] = d
+		}
+	}
+}
+
+// SetSourceLanguage sets the language whose values translations are tracked against.
+func (s *LanguageValueStore) SetSourceLanguage(languageUuid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sourceLanguage = languageUuid
+}
+
+// track records which revision of the source value a translation is being saved against.
+func (s *LanguageValueStore) track(v *model.LanguageValue) {
+	v.SourceRevision = 0
+	v.NeedsReview = false
+	if s.sourceLanguage == "" || v.UuidLanguage == s.sourceLanguage {
+		return
+	}
+	if source, ok := s.findByLanguageKey(s.sourceLanguage, v.UuidLanguageKey); ok {
+		v.SourceRevision = source.Revision
+	}
+}
+
+// sourceChanged reports whether the text of source differs from its text at revision. When
+// that revision is no longer in the history, any later revision counts as a change.
+func (s *LanguageValueStore) sourceChanged(source model.LanguageValue, revision int) bool {
+	if source.Revision <= revision {
+		return false
+	}
+	old, err := s.revision(source.Uuid, revision)
+	return err != nil || old.Value != source.Value
+}
+
+// markDependents flags the translations of a changed source value as needing review.
+func (s *LanguageValueStore) markDependents(source model.LanguageValue) {
+	if s.sourceLanguage == "" || source.UuidLanguage != s.sourceLanguage {
+		return
+	}
+	for id, v := range s.items {
+		if v.UuidLanguageKey == source.UuidLanguageKey && v.UuidLanguage != s.sourceLanguage && v.SourceRevision < source.Revision {
+			v.NeedsReview = true
+			s.items[id] = v
+		}
+	}
+}
+
+// Query returns the values matching every set field of q.
+func (s *LanguageValueStore) Query(q model.LanguageValueQuery) []model.LanguageValue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.LanguageValue, 0)
+	for _, v := range s.items {
+		if q.UuidLanguage != "" && v.UuidLanguage != q.UuidLanguage {
+			continue
+		}
+		if q.UuidLanguageKey != "" && v.UuidLanguageKey != q.UuidLanguageKey {
+			continue
+		}
+		if q.NeedsReview && !v.NeedsReview {
+			continue
+		}
+		if q.Status != "" && v.Status != q.Status {
+			continue
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// GetByLanguageKey returns the value translating a key into a language.
+func (s *LanguageValueStore) GetByLanguageKey(languageUuid, keyUuid string) (model.LanguageValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.findByLanguageKey(languageUuid, keyUuid)
+	if !ok {
+		return model.LanguageValue{}, errors.New("value not found")
+	}
+	return v, nil
+}
+
+// Upsert inserts v, or updates the value for the same language and key when there is one.
+func (s *LanguageValueStore) Upsert(v model.LanguageValue) (model.UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists := s.findByLanguageKey(v.UuidLanguage, v.UuidLanguageKey)
+	if !exists {
+		created, err := s.insert(v)
+		if err != nil {
+			return model.UpsertResult{}, err
+		}
+		return model.UpsertResult{Outcome: model.UpsertCreated, Data: created}, nil
+	}
+
+	if current.Value == v.Value {
+		return model.UpsertResult{Outcome: model.UpsertUnchanged, Previous: current, Data: current}, nil
+	}
+
+	v.Uuid = current.Uuid
+	updated, err := s.update(current.Uuid, v)
+	if err != nil {
+		return model.UpsertResult{}, err
+	}
+	return model.UpsertResult{Outcome: model.UpsertChanged, Previous: current, Data: updated}, nil
+}
+
+func (s *LanguageValueStore) findByLanguageKey(languageUuid, keyUuid string) (model.LanguageValue, bool) {
+	for _, v := range s.items {
+		if v.UuidLanguage == languageUuid && v.UuidLanguageKey == keyUuid {
+			return v, true
+		}
+	}
+	return model.LanguageValue{}, false
+}
+
+// Transition moves a value to another workflow status and stores comment with it. Only the
+// moves in valueTransitions are allowed. A non-zero expectedRevision must match the stored
+// revision, which is incremented so that reviewers acting at once conflict. The new revision
+// is added to the history with the unchanged text. Approving or publishing a translation
+// confirms it against the current source value, clearing NeedsReview. It returns the value it
+// replaced and the stored value.
+func (s *LanguageValueStore) Transition(uuid, status, comment string, expectedRevision int) (model.LanguageValue, model.LanguageValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, exists := s.items[uuid]
+	if !exists {
+		return model.LanguageValue{}, model.LanguageValue{}, errors.New("value not found")
+	}
+	if expectedRevision != 0 && expectedRevision != previous.Revision {
+		return model.LanguageValue{}, model.LanguageValue{}, &util.ConflictError{Current: previous}
+	}
+	if !slices.Contains(valueTransitions[previous.Status], status) {
+		return model.LanguageValue{}, model.LanguageValue{}, fmt.Errorf("cannot move value from %s to %s", previous.Status, status)
+	}
+
+	current := previous
+	current.Status = status
+	current.StatusComment = comment
+	current.Revision++
+	current.LastUpdate = time.Now().Truncate(time.Microsecond)
+	if status == model.ValueStatusApproved || status == model.ValueStatusPublished {
+		s.track(&current)
+	}
+	s.items[uuid] = current
+	s.addRevision(current, current.LastUpdate)
+	return previous, current, nil
 }
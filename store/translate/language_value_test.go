@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/util"
 )
 
 func TestLanguageValueStore_InsertAndGet(t *testing.T) {
@@ -23,7 +26,7 @@ func TestLanguageValueStore_InsertAndGet(t *testing.T) {
 		Value:           val,
 	}
 
-	if err := store.Insert(v); err != nil {
+	if _, err := store.Insert(v); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
@@ -42,10 +45,10 @@ func TestLanguageValueStore_InsertDuplicate(t *testing.T) {
 	id := uuid.NewString()
 	v := model.LanguageValue{Uuid: id, Value: "Hello"}
 
-	if err := store.Insert(v); err != nil {
+	if _, err := store.Insert(v); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
-	if err := store.Insert(v); err == nil {
+	if _, err := store.Insert(v); err == nil {
 		t.Error("Expected error on duplicate insert, got nil")
 	}
 }
@@ -100,7 +103,7 @@ func TestLanguageValueStore_Update(t *testing.T) {
 		Value:           "Old",
 	}
 
-	if err := store.Insert(initial); err != nil {
+	if _, err := store.Insert(initial); err != nil {
 		t.Fatalf("Insert failed: %v", err)
 	}
 
@@ -111,9 +114,13 @@ func TestLanguageValueStore_Update(t *testing.T) {
 		Value:           "New",
 	}
 
-	if err := store.Update(id, updated); err != nil {
+	previous, _, err := store.Update(id, updated)
+	if err != nil {
 		t.Fatalf("Update failed: %v", err)
 	}
+	if previous.Value != "Old" || previous.Revision != 1 {
+		t.Errorf("Expected Update to return the replaced record, got %+v", previous)
+	}
 
 	got, _ := store.Get(id)
 	if got.Value != "New" {
@@ -124,7 +131,7 @@ func TestLanguageValueStore_Update(t *testing.T) {
 func TestLanguageValueStore_Update_NotFound(t *testing.T) {
 	store := NewLanguageValueStore()
 
-	err := store.Update("missing-id", model.LanguageValue{Uuid: "missing-id", Value: "Whatever"})
+	_, _, err := store.Update("missing-id", model.LanguageValue{Uuid: "missing-id", Value: "Whatever"})
 	if err == nil {
 		t.Error("Expected error on Update for non-existent value")
 	}
@@ -141,7 +148,7 @@ func TestLanguageValueStore_Delete(t *testing.T) {
 		Value:           "DeleteMe",
 	})
 
-	if err := store.Delete(id); err != nil {
+	if _, err := store.Delete(id, 0); err != nil {
 		t.Fatalf("Delete failed: %v", err)
 	}
 
@@ -153,8 +160,390 @@ func TestLanguageValueStore_Delete(t *testing.T) {
 func TestLanguageValueStore_Delete_NotFound(t *testing.T) {
 	store := NewLanguageValueStore()
 
-	err := store.Delete("nonexistent")
+	_, err := store.Delete("nonexistent", 0)
 	if err == nil {
 		t.Error("Expected error when deleting nonexistent item")
 	}
 }
+
+func TestLanguageValueStore_Revisions(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	id := uuid.NewString()
+	v := model.LanguageValue{Uuid: id, Value: "First"}
+	if _, err := store.Insert(v); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	v.Value = "Second"
+	if _, _, err := store.Update(id, v); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	revs, err := store.Revisions(id)
+	if err != nil {
+		t.Fatalf("Revisions failed: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revs))
+	}
+	if revs[0].Revision != 1 || revs[0].Value != "First" || revs[1].Revision != 2 || revs[1].Value != "Second" {
+		t.Errorf("Unexpected revisions: %+v", revs)
+	}
+}
+
+func TestLanguageValueStore_Revisions_Bounded(t *testing.T) {
+	store := NewLanguageValueStore()
+	store.historyLimit = 3
+
+	id := uuid.NewString()
+	v := model.LanguageValue{Uuid: id, Value: "v1"}
+	store.Insert(v)
+	for _, text := range []string{"v2", "v3", "v4", "v5"} {
+		v.Value = text
+		store.Update(id, v)
+	}
+
+	revs, _ := store.Revisions(id)
+	if len(revs) != 3 {
+		t.Fatalf("Expected 3 retained revisions, got %d", len(revs))
+	}
+	if revs[0].Revision != 3 || revs[2].Revision != 5 || revs[2].Value != "v5" {
+		t.Errorf("Expected revisions 3..5, got %+v", revs)
+	}
+	if _, err := store.Revision(id, 1); err == nil {
+		t.Error("Expected dropped revision to be gone")
+	}
+}
+
+func TestLanguageValueStore_Diff(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	id := uuid.NewString()
+	v := model.LanguageValue{Uuid: id, Value: "Angebot bestätigt"}
+	store.Insert(v)
+	v.Value = "Angebot abgelehnt"
+	store.Update(id, v)
+
+	segments, err := store.Diff(id, 1, 2)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(segments) != 3 || segments[1].Operation != model.DiffDelete || segments[2].Operation != model.DiffInsert {
+		t.Errorf("Unexpected diff: %+v", segments)
+	}
+
+	if _, err := store.Diff(id, 1, 9); err == nil {
+		t.Error("Expected error when diffing unknown revision")
+	}
+}
+
+func TestLanguageValueStore_Revert(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	id := uuid.NewString()
+	v := model.LanguageValue{Uuid: id, Value: "Yesterday"}
+	store.Insert(v)
+	v.Value = "Today"
+	store.Update(id, v)
+
+	_, reverted, err := store.Revert(id, 1, 0)
+	if err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if reverted.Value != "Yesterday" {
+		t.Errorf("Expected reverted value 'Yesterday', got %s", reverted.Value)
+	}
+
+	revs, _ := store.Revisions(id)
+	if len(revs) != 3 || revs[2].Value != "Yesterday" {
+		t.Errorf("Expected revert to append revision 3, got %+v", revs)
+	}
+
+	if _, _, err := store.Revert(id, 42, 0); err == nil {
+		t.Error("Expected error when reverting to unknown revision")
+	}
+
+	if _, _, err := store.Revert(id, 2, reverted.Revision); err != nil {
+		t.Fatalf("Revert at the stored revision failed: %v", err)
+	}
+	var conflict *util.ConflictError
+	if _, _, err := store.Revert(id, 1, reverted.Revision); !errors.As(err, &conflict) || conflict.Current.(model.LanguageValue).Value != "Today" {
+		t.Errorf("Expected a second revert on the same revision to conflict, got %v", err)
+	}
+}
+
+func TestLanguageValueStore_Delete_KeepsRevisionsUntilPurged(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	id := uuid.NewString()
+	store.Insert(model.LanguageValue{Uuid: id, Value: "Gone"})
+	store.Delete(id, 0)
+
+	if revs, err := store.Revisions(id); err != nil || len(revs) != 1 {
+		t.Errorf("Expected revisions to be kept in the trash, got %+v (%v)", revs, err)
+	}
+	store.Purge(time.Now().Add(time.Second))
+	if _, err := store.Revisions(id); err == nil {
+		t.Error("Expected revisions to be removed with the purged value")
+	}
+}
+
+func TestLanguageValueStore_Update_RevisionConflict(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	id := uuid.NewString()
+	store.Insert(model.LanguageValue{Uuid: id, Value: "One"})
+	store.Update(id, model.LanguageValue{Uuid: id, Value: "Two", Revision: 1})
+
+	_, _, err := store.Update(id, model.LanguageValue{Uuid: id, Value: "Three", Revision: 1})
+	var conflict *util.ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if conflict.Current.(model.LanguageValue).Revision != 2 {
+		t.Errorf("Conflict should carry the stored record, got %+v", conflict.Current)
+	}
+
+	var deleteConflict *util.ConflictError
+	if _, err := store.Delete(id, 1); !errors.As(err, &deleteConflict) {
+		t.Errorf("Expected ConflictError on delete, got %v", err)
+	}
+}
+
+func TestLanguageValueStore_Upsert(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	langID, keyID := uuid.NewString(), uuid.NewString()
+	created, err := store.Upsert(model.LanguageValue{UuidLanguage: langID, UuidLanguageKey: keyID, Value: "Bezahlen"})
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if created.Outcome != model.UpsertCreated {
+		t.Errorf("Expected outcome %s, got %s", model.UpsertCreated, created.Outcome)
+	}
+
+	unchanged, _ := store.Upsert(model.LanguageValue{UuidLanguage: langID, UuidLanguageKey: keyID, Value: "Bezahlen"})
+	if unchanged.Outcome != model.UpsertUnchanged {
+		t.Errorf("Expected outcome %s, got %s", model.UpsertUnchanged, unchanged.Outcome)
+	}
+
+	changed, _ := store.Upsert(model.LanguageValue{UuidLanguage: langID, UuidLanguageKey: keyID, Value: "Jetzt bezahlen"})
+	if changed.Outcome != model.UpsertChanged || changed.Previous.(model.LanguageValue).Value != "Bezahlen" {
+		t.Errorf("Unexpected upsert result: %+v", changed)
+	}
+
+	got, err := store.GetByLanguageKey(langID, keyID)
+	if err != nil {
+		t.Fatalf("GetByLanguageKey failed: %v", err)
+	}
+	if got.Value != "Jetzt bezahlen" || got.Uuid != created.Data.(model.LanguageValue).Uuid {
+		t.Errorf("Unexpected stored value: %+v", got)
+	}
+}
+
+func TestLanguageValueStore_Insert_GeneratesUuid(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	created, err := store.Insert(model.LanguageValue{Value: "Hallo"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := uuid.Parse(created.Uuid); err != nil {
+		t.Errorf("Expected generated Uuid, got %q", created.Uuid)
+	}
+	if revs, _ := store.Revisions(created.Uuid); len(revs) != 1 {
+		t.Errorf("Expected first revision under generated Uuid, got %+v", revs)
+	}
+	if _, err := store.Insert(model.LanguageValue{Uuid: "1234", Value: "Hallo"}); err == nil {
+		t.Error("Expected error for malformed Uuid")
+	}
+}
+
+func TestLanguageValueStore_RejectsInvalidMessageFormat(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	if _, err := store.Insert(model.LanguageValue{Value: "{count, plural, one {# item}}"}); err == nil {
+		t.Error("Expected error for plural without other case")
+	}
+
+	created, err := store.Insert(model.LanguageValue{Value: "{count, plural, one {# item} other {# items}}"})
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, _, err := store.Update(created.Uuid, model.LanguageValue{Uuid: created.Uuid, Value: "Hello {name"}); err == nil {
+		t.Error("Expected error for unclosed argument on update")
+	}
+	if got, _ := store.Get(created.Uuid); got.Revision != 1 {
+		t.Errorf("Rejected update must not be stored, got %+v", got)
+	}
+}
+
+func TestLanguageValueStore_SourceTracking(t *testing.T) {
+	store := NewLanguageValueStore()
+	source, target, key := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	early, _ := store.Insert(model.LanguageValue{UuidLanguage: target, UuidLanguageKey: key, Value: "Früh"})
+	if early.SourceRevision != 0 || early.NeedsReview {
+		t.Errorf("Expected no tracking without a source language, got %+v", early)
+	}
+
+	store.SetSourceLanguage(source)
+	original, _ := store.Insert(model.LanguageValue{UuidLanguage: source, UuidLanguageKey: key, Value: "Early"})
+	if got, _ := store.Get(early.Uuid); !got.NeedsReview {
+		t.Error("Expected translation made before the source value to need review")
+	}
+
+	_, translated, _ := store.Update(early.Uuid, model.LanguageValue{Uuid: early.Uuid, UuidLanguage: target, UuidLanguageKey: key, Value: "Früher"})
+	if translated.SourceRevision != 1 || translated.NeedsReview {
+		t.Errorf("Expected update to track source revision 1, got %+v", translated)
+	}
+
+	store.Update(original.Uuid, model.LanguageValue{Uuid: original.Uuid, UuidLanguage: source, UuidLanguageKey: key, Value: "Early"})
+	if got, _ := store.Get(translated.Uuid); got.NeedsReview {
+		t.Error("Re-saving the source unchanged should not flag its translations")
+	}
+	if _, _, err := store.Revert(original.Uuid, 1, 0); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if got, _ := store.Get(translated.Uuid); got.NeedsReview {
+		t.Error("Reverting the source to the same text should not flag its translations")
+	}
+
+	store.Update(original.Uuid, model.LanguageValue{Uuid: original.Uuid, UuidLanguage: source, UuidLanguageKey: key, Value: "Later"})
+	_, translated, _ = store.Update(translated.Uuid, model.LanguageValue{Uuid: translated.Uuid, UuidLanguage: target, UuidLanguageKey: key, Value: "Später"})
+	other, _ := store.Insert(model.LanguageValue{UuidLanguage: target, UuidLanguageKey: uuid.NewString(), Value: "Andere"})
+	if _, _, err := store.Revert(original.Uuid, 1, 0); err != nil {
+		t.Fatalf("Revert failed: %v", err)
+	}
+	if got, _ := store.Get(translated.Uuid); !got.NeedsReview {
+		t.Error("Expected reverting the source to flag its translations")
+	}
+	if got, _ := store.Get(other.Uuid); got.NeedsReview {
+		t.Error("Translations of other keys should not be flagged")
+	}
+
+	if got := store.Query(model.LanguageValueQuery{NeedsReview: true}); len(got) != 1 || got[0].Uuid != translated.Uuid {
+		t.Errorf("Expected one value needing review, got %+v", got)
+	}
+	if got := store.Query(model.LanguageValueQuery{UuidLanguage: target}); len(got) != 2 {
+		t.Errorf("Expected two target values, got %+v", got)
+	}
+
+	store.Transition(translated.Uuid, model.ValueStatusNeedsReview, "", 0)
+	if got, _ := store.Get(translated.Uuid); !got.NeedsReview {
+		t.Error("Submitting a stale translation should not clear its review flag")
+	}
+	_, approved, err := store.Transition(translated.Uuid, model.ValueStatusApproved, "", 0)
+	if source, _ := store.Get(original.Uuid); err != nil || approved.NeedsReview || approved.SourceRevision != source.Revision {
+		t.Errorf("Expected approval to confirm the translation against source revision %d, got %+v (%v)", source.Revision, approved, err)
+	}
+}
+
+func TestLanguageValueStore_Transition(t *testing.T) {
+	store := NewLanguageValueStore()
+
+	v, _ := store.Insert(model.LanguageValue{Value: "Hallo"})
+	if v.Status != model.ValueStatusDraft {
+		t.Fatalf("Expected new value to be a draft, got %q", v.Status)
+	}
+
+	if _, _, err := store.Transition(v.Uuid, model.ValueStatusApproved, "", 0); err == nil {
+		t.Error("Expected a draft not to be approvable before review")
+	}
+	if _, _, err := store.Transition(v.Uuid, model.ValueStatusNeedsReview, "", v.Revision+1); err == nil {
+		t.Error("Expected a stale revision to conflict")
+	}
+
+	_, v, _ = store.Transition(v.Uuid, model.ValueStatusNeedsReview, "", v.Revision)
+	_, v, err := store.Transition(v.Uuid, model.ValueStatusDraft, "Too formal", 0)
+	if err != nil || v.Status != model.ValueStatusDraft || v.StatusComment != "Too formal" {
+		t.Fatalf("Expected rejection back to draft with comment, got %+v (%v)", v, err)
+	}
+
+	_, v, _ = store.Transition(v.Uuid, model.ValueStatusNeedsReview, "", 0)
+	if _, _, err := store.Transition(v.Uuid, model.ValueStatusApproved, "Looks good", v.Revision); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	var conflict *util.ConflictError
+	if _, _, err := store.Transition(v.Uuid, model.ValueStatusDraft, "Too long", v.Revision); !errors.As(err, &conflict) {
+		t.Errorf("Expected a second reviewer on the same revision to conflict, got %v", err)
+	}
+	v, _ = store.Get(v.Uuid)
+	if v.Status != model.ValueStatusApproved || v.Revision != 5 {
+		t.Errorf("Expected approved value at revision 5, got %+v", v)
+	}
+	if revs, _ := store.Revisions(v.Uuid); len(revs) != 5 || revs[4].Revision != 5 || revs[4].Value != "Hallo" {
+		t.Errorf("Expected every status change in the history with the same text, got %+v", revs)
+	}
+	if diff, err := store.Diff(v.Uuid, 1, v.Revision); err != nil || len(diff) != 1 || diff[0].Operation != model.DiffEqual {
+		t.Errorf("Expected the current revision to diff against the first as equal, got %+v (%v)", diff, err)
+	}
+	if _, reverted, err := store.Revert(v.Uuid, v.Revision, 0); err != nil || reverted.Value != "Hallo" {
+		t.Errorf("Expected a revert to the current revision to succeed, got %+v (%v)", reverted, err)
+	}
+	v, _ = store.Get(v.Uuid)
+
+	_, v, _ = store.Update(v.Uuid, v)
+	if v.Status != model.ValueStatusApproved || v.StatusComment != "Looks good" {
+		t.Errorf("Saving unchanged text should keep the status, got %+v", v)
+	}
+	v.Value = "Hallo!"
+	_, v, _ = store.Update(v.Uuid, v)
+	if v.Status != model.ValueStatusDraft || v.StatusComment != "" {
+		t.Errorf("Changing the text should reset the value to draft, got %+v", v)
+	}
+	if got := store.Query(model.LanguageValueQuery{Status: model.ValueStatusDraft}); len(got) != 1 {
+		t.Errorf("Expected one draft, got %+v", got)
+	}
+}
+
+func TestLanguageValueStore_TrashAndRestore(t *testing.T) {
+	store := NewLanguageValueStore()
+	source, keyUuid := uuid.NewString(), uuid.NewString()
+	store.SetSourceLanguage(source)
+
+	original, _ := store.Insert(model.LanguageValue{UuidLanguage: source, UuidLanguageKey: keyUuid, Value: "Save"})
+	translation, _ := store.Insert(model.LanguageValue{UuidLanguage: uuid.NewString(), UuidLanguageKey: keyUuid, Value: "Speichern"})
+
+	if _, err := store.Delete(translation.Uuid, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := store.Query(model.LanguageValueQuery{UuidLanguageKey: keyUuid}); len(got) != 1 {
+		t.Errorf("Expected deleted value to be hidden from queries, got %+v", got)
+	}
+
+	original.Value = "Save changes"
+	store.Update(original.Uuid, original)
+
+	restored, err := store.Restore(translation.Uuid)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if !restored.NeedsReview || restored.Revision != 2 || !restored.Deleted.IsZero() {
+		t.Errorf("Expected restored translation to need review, got %+v", restored)
+	}
+	if revs, _ := store.Revisions(translation.Uuid); len(revs) != 2 || revs[1].Revision != 2 {
+		t.Errorf("Expected restore to add a revision, got %+v", revs)
+	}
+	if len(store.Trash()) != 0 {
+		t.Error("Expected trash to be empty after restore")
+	}
+}
+
+func TestLanguageValueStore_RestoreUnchangedSource(t *testing.T) {
+	store := NewLanguageValueStore()
+	source, keyUuid := uuid.NewString(), uuid.NewString()
+	store.SetSourceLanguage(source)
+
+	original, _ := store.Insert(model.LanguageValue{UuidLanguage: source, UuidLanguageKey: keyUuid, Value: "Save"})
+	translation, _ := store.Insert(model.LanguageValue{UuidLanguage: uuid.NewString(), UuidLanguageKey: keyUuid, Value: "Speichern"})
+	store.Delete(translation.Uuid, 0)
+
+	store.Transition(original.Uuid, model.ValueStatusNeedsReview, "", 0)
+	store.Update(original.Uuid, original)
+
+	restored, err := store.Restore(translation.Uuid)
+	if err != nil || restored.NeedsReview {
+		t.Errorf("Expected a translation whose source text did not change to come back without review, got %+v (%v)", restored, err)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/rah-0/nabu"
 
+	"github.com/rah-0/meisterwerk/icu"
 	"github.com/rah-0/meisterwerk/model"
 	"github.com/rah-0/meisterwerk/util"
 )
@@ -18,6 +20,14 @@ const (
 	EndpointLanguageDelete = "translations.language.delete"
 	EndpointLanguageGet    = "translations.language.get"
 	EndpointLanguageList   = "translations.language.list"
+	EndpointLanguageUpsert = "translations.language.upsert"
+
+	EndpointLanguageRestore      = "translations.language.restore"
+	EndpointLanguageTrash        = "translations.language.trash"
+	EndpointLanguageKeyRestore   = "translations.language_key.restore"
+	EndpointLanguageKeyTrash     = "translations.language_key.trash"
+	EndpointLanguageValueRestore = "translations.language_value.restore"
+	EndpointLanguageValueTrash   = "translations.language_value.trash"
 
 	EndpointLanguageKeyInsert     = "translations.language_key.insert"
 	EndpointLanguageKeyUpdate     = "translations.language_key.update"
@@ -25,18 +35,76 @@ const (
 	EndpointLanguageKeyGet        = "translations.language_key.get"
 	EndpointLanguageKeyGetByValue = "translations.language_key.get_by_value"
 	EndpointLanguageKeyList       = "translations.language_key.list"
+	EndpointLanguageKeyQuery      = "translations.language_key.query"
+	EndpointLanguageKeyUpsert     = "translations.language_key.upsert"
+	EndpointLanguageKeyRename     = "translations.language_key.rename"
+	EndpointLanguageKeyAliases    = "translations.language_key.aliases"
+
+	EndpointNamespaceList   = "translations.namespace.list"
+	EndpointNamespaceKeys   = "translations.namespace.keys"
+	EndpointNamespaceCount  = "translations.namespace.count"
+	EndpointNamespaceRename = "translations.namespace.rename"
+	EndpointNamespaceDelete = "translations.namespace.delete"
 
 	EndpointLanguageValueInsert = "translations.language_value.insert"
 	EndpointLanguageValueUpdate = "translations.language_value.update"
 	EndpointLanguageValueDelete = "translations.language_value.delete"
 	EndpointLanguageValueGet    = "translations.language_value.get"
 	EndpointLanguageValueList   = "translations.language_value.list"
+	EndpointLanguageValueUpsert = "translations.language_value.upsert"
+
+	EndpointLanguageValueRevisions = "translations.language_value.revisions"
+	EndpointLanguageValueDiff      = "translations.language_value.diff"
+	EndpointLanguageValueRevert    = "translations.language_value.revert"
+	EndpointLanguageValueFormat    = "translations.language_value.format"
+	EndpointLanguageValueQuery     = "translations.language_value.query"
+	EndpointLanguageValueSubmit    = "translations.language_value.submit"
+	EndpointLanguageValueApprove   = "translations.language_value.approve"
+	EndpointLanguageValueReject    = "translations.language_value.reject"
+	EndpointLanguageValuePublish   = "translations.language_value.publish"
+
+	EndpointCommentInsert = "translations.comment.insert"
+	EndpointCommentUpdate = "translations.comment.update"
+	EndpointCommentDelete = "translations.comment.delete"
+	EndpointCommentGet    = "translations.comment.get"
+	EndpointCommentList   = "translations.comment.list"
+
+	EndpointBundle  = "translations.bundle"
+	EndpointResolve = "translations.resolve"
+
+	EndpointBatch = "translations.batch"
+
+	EndpointI18nextExport = "translations.i18next.export"
+	EndpointI18nextImport = "translations.i18next.import"
+
+	EndpointGettextTemplate = "translations.gettext.template"
+	EndpointGettextExport   = "translations.gettext.export"
+	EndpointGettextImport   = "translations.gettext.import"
+
+	EndpointXliffExport = "translations.xliff.export"
+	EndpointXliffImport = "translations.xliff.import"
+
+	EndpointAndroidExport = "translations.android.export"
+	EndpointAndroidImport = "translations.android.import"
+	EndpointIOSExport     = "translations.ios.export"
+	EndpointIOSImport     = "translations.ios.import"
+
+	EndpointAuditQuery = "translations.audit.query"
+
+	EndpointQAReport = "translations.qa.report"
+
+	EndpointReportCoverage = "translations.report.coverage"
+	EndpointReportMatrix   = "translations.report.matrix"
 )
 
 var (
 	languageStore      = NewLanguageStore()
 	languageValueStore = NewLanguageValueStore()
 	languageKeyStore   = NewLanguageKeyStore()
+	commentStore       = NewCommentStore()
+	auditStore         = NewAuditStore()
+	idempotencyCache   *util.IdempotencyCache
+	settings           config
 )
 
 func main() {
@@ -49,6 +117,13 @@ func main() {
 }
 
 func start(ctx context.Context) error {
+	// Load settings
+	var err error
+	if settings, err = loadConfig(); err != nil {
+		return nabu.FromError(err).Log()
+	}
+	idempotencyCache = util.NewIdempotencyCache(settings.IdempotencyWindow)
+
 	// Connect to NATS
 	nc, err := nats.Connect(nats.DefaultURL)
 	if err != nil {
@@ -66,6 +141,35 @@ func start(ctx context.Context) error {
 	if err = registerLanguageValueHandlers(nc); err != nil {
 		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
 	}
+	if err = registerCommentHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerTrashHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerNamespaceHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerBundleHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerExchangeHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerBatchHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerAuditHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerQAHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+	if err = registerReportHandlers(nc); err != nil {
+		return nabu.FromError(err).WithArgs(nats.DefaultURL).Log()
+	}
+
+	go purgeTrashEvery(ctx, time.Hour)
 
 	// Block until context is cancelled
 	<-ctx.Done()
@@ -74,20 +178,38 @@ func start(ctx context.Context) error {
 }
 
 func registerLanguageHandlers(nc *nats.Conn) error {
-	if err := util.NatsBindHandler(nc, EndpointLanguageInsert, func(lang model.Language) (any, error) {
-		return nil, languageStore.Insert(lang)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageInsert, idempotencyCache, func(msg *nats.Msg, lang model.Language) (any, error) {
+		created, err := languageStore.Insert(lang)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguage, created.Uuid, model.OperationInsert, nil, created)
+		refreshSourceLanguage()
+		return created, nil
 	}); err != nil {
 		return err
 	}
 
-	if err := util.NatsBindHandler(nc, EndpointLanguageUpdate, func(lang model.Language) (any, error) {
-		return nil, languageStore.Update(lang.Uuid, lang)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageUpdate, idempotencyCache, func(msg *nats.Msg, lang model.Language) (any, error) {
+		previous, current, err := languageStore.Update(lang.Uuid, lang)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguage, current.Uuid, model.OperationUpdate, previous, current)
+		refreshSourceLanguage()
+		return current, nil
 	}); err != nil {
 		return err
 	}
 
-	if err := util.NatsBindHandler(nc, EndpointLanguageDelete, func(req model.Language) (any, error) {
-		return nil, languageStore.Delete(req.Uuid)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageDelete, idempotencyCache, func(msg *nats.Msg, req model.Language) (any, error) {
+		deleted, err := languageStore.Delete(req.Uuid, req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguage, deleted.Uuid, model.OperationDelete, deleted, nil)
+		refreshSourceLanguage()
+		return deleted, nil
 	}); err != nil {
 		return err
 	}
@@ -104,24 +226,56 @@ func registerLanguageHandlers(nc *nats.Conn) error {
 		return err
 	}
 
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageUpsert, idempotencyCache, func(msg *nats.Msg, langs []model.Language) (any, error) {
+		results := make([]model.UpsertResult, len(langs))
+		for i, lang := range langs {
+			result, err := languageStore.Upsert(lang)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			results[i] = result
+			recordUpsert(msg, model.EntityLanguage, result.Data.(model.Language).Uuid, result)
+		}
+		refreshSourceLanguage()
+		return results, nil
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func registerLanguageKeyHandlers(nc *nats.Conn) error {
-	if err := util.NatsBindHandler(nc, EndpointLanguageKeyInsert, func(key model.LanguageKey) (any, error) {
-		return nil, languageKeyStore.Insert(key)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageKeyInsert, idempotencyCache, func(msg *nats.Msg, key model.LanguageKey) (any, error) {
+		created, err := languageKeyStore.Insert(key)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageKey, created.Uuid, model.OperationInsert, nil, created)
+		return created, nil
 	}); err != nil {
 		return err
 	}
 
-	if err := util.NatsBindHandler(nc, EndpointLanguageKeyUpdate, func(key model.LanguageKey) (any, error) {
-		return nil, languageKeyStore.Update(key.Uuid, key)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageKeyUpdate, idempotencyCache, func(msg *nats.Msg, key model.LanguageKey) (any, error) {
+		previous, current, err := languageKeyStore.Update(key.Uuid, key)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageKey, current.Uuid, model.OperationUpdate, previous, current)
+		return current, nil
 	}); err != nil {
 		return err
 	}
 
-	if err := util.NatsBindHandler(nc, EndpointLanguageKeyDelete, func(req model.LanguageKey) (any, error) {
-		return nil, languageKeyStore.Delete(req.Uuid)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageKeyDelete, idempotencyCache, func(msg *nats.Msg, req model.LanguageKey) (any, error) {
+		deleted, err := languageKeyStore.Delete(req.Uuid, req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageKey, deleted.Uuid, model.OperationDelete, deleted, nil)
+		return deleted, nil
 	}); err != nil {
 		return err
 	}
@@ -144,24 +298,84 @@ func registerLanguageKeyHandlers(nc *nats.Conn) error {
 		return err
 	}
 
+	if err := util.NatsBindHandler(nc, EndpointLanguageKeyQuery, func(q model.LanguageKeyQuery) (any, error) {
+		return languageKeyStore.Query(q), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageKeyRename, idempotencyCache, func(msg *nats.Msg, req model.LanguageKeyRename) (any, error) {
+		previous, renamed, err := languageKeyStore.RenameKey(req.From, req.To, aliasExpires(req.Alias))
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageKey, renamed.Uuid, model.OperationUpdate, previous, renamed)
+		return renamed, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageKeyAliases, func(_ any) (any, error) {
+		return languageKeyStore.Aliases(time.Now()), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageKeyUpsert, idempotencyCache, func(msg *nats.Msg, keys []model.LanguageKey) (any, error) {
+		results := make([]model.UpsertResult, len(keys))
+		for i, key := range keys {
+			result, err := languageKeyStore.Upsert(key)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			results[i] = result
+			recordUpsert(msg, model.EntityLanguageKey, result.Data.(model.LanguageKey).Uuid, result)
+		}
+		return results, nil
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func registerLanguageValueHandlers(nc *nats.Conn) error {
-	if err := util.NatsBindHandler(nc, EndpointLanguageValueInsert, func(val model.LanguageValue) (any, error) {
-		return nil, languageValueStore.Insert(val)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageValueInsert, idempotencyCache, func(msg *nats.Msg, val model.LanguageValue) (any, error) {
+		if err := checkLanguageValue(val); err != nil {
+			return nil, err
+		}
+		created, err := languageValueStore.Insert(val)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageValue, created.Uuid, model.OperationInsert, nil, created)
+		return created, nil
 	}); err != nil {
 		return err
 	}
 
-	if err := util.NatsBindHandler(nc, EndpointLanguageValueUpdate, func(val model.LanguageValue) (any, error) {
-		return nil, languageValueStore.Update(val.Uuid, val)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageValueUpdate, idempotencyCache, func(msg *nats.Msg, val model.LanguageValue) (any, error) {
+		if err := checkLanguageValue(val); err != nil {
+			return nil, err
+		}
+		previous, current, err := languageValueStore.Update(val.Uuid, val)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageValue, current.Uuid, model.OperationUpdate, previous, current)
+		return current, nil
 	}); err != nil {
 		return err
 	}
 
-	if err := util.NatsBindHandler(nc, EndpointLanguageValueDelete, func(req model.LanguageValue) (any, error) {
-		return nil, languageValueStore.Delete(req.Uuid)
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageValueDelete, idempotencyCache, func(msg *nats.Msg, req model.LanguageValue) (any, error) {
+		deleted, err := languageValueStore.Delete(req.Uuid, req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageValue, deleted.Uuid, model.OperationDelete, deleted, nil)
+		return deleted, nil
 	}); err != nil {
 		return err
 	}
@@ -178,5 +392,553 @@ func registerLanguageValueHandlers(nc *nats.Conn) error {
 		return err
 	}
 
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageValueUpsert, idempotencyCache, func(msg *nats.Msg, vals []model.LanguageValueUpsert) (any, error) {
+		results := make([]model.UpsertResult, len(vals))
+		for i, val := range vals {
+			lang, err := languageStore.GetByPrefix(val.Prefix)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			key, err := languageKeyStore.GetByValue(val.Key)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			v := model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: val.Value}
+			if err = checkLanguageValue(v); err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			result, err := languageValueStore.Upsert(v)
+			if err != nil {
+				results[i].Error = err.Error()
+				continue
+			}
+			results[i] = result
+			recordUpsert(msg, model.EntityLanguageValue, result.Data.(model.LanguageValue).Uuid, result)
+		}
+		return results, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageValueRevisions, func(req model.LanguageValueRevisionRequest) (any, error) {
+		return languageValueStore.Revisions(req.Uuid)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageValueDiff, func(req model.LanguageValueRevisionRequest) (any, error) {
+		return languageValueStore.Diff(req.Uuid, req.Revision, req.Other)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageValueRevert, idempotencyCache, func(msg *nats.Msg, req model.LanguageValueRevisionRequest) (any, error) {
+		// The old text is checked like an update, as rules such as the key's MaxLength may
+		// have changed since it was stored.
+		val, err := languageValueStore.Get(req.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		old, err := languageValueStore.Revision(req.Uuid, req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		val.Value = old.Value
+		if err = checkLanguageValue(val); err != nil {
+			return nil, err
+		}
+		previous, current, err := languageValueStore.Revert(req.Uuid, req.Revision, req.Expected)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageValue, req.Uuid, model.OperationUpdate, previous, current)
+		return current, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageValueQuery, func(q model.LanguageValueQuery) (any, error) {
+		return languageValueStore.Query(q), nil
+	}); err != nil {
+		return err
+	}
+
+	transitions := []struct {
+		endpoint string
+		status   string
+	}{
+		{EndpointLanguageValueSubmit, model.ValueStatusNeedsReview},
+		{EndpointLanguageValueApprove, model.ValueStatusApproved},
+		{EndpointLanguageValueReject, model.ValueStatusDraft},
+		{EndpointLanguageValuePublish, model.ValueStatusPublished},
+	}
+	for _, tr := range transitions {
+		if err := util.NatsBindIdempotentHandler(nc, tr.endpoint, idempotencyCache, func(msg *nats.Msg, req model.LanguageValueTransition) (any, error) {
+			previous, current, err := languageValueStore.Transition(req.Uuid, tr.status, req.Comment, req.Revision)
+			if err != nil {
+				return nil, err
+			}
+			auditStore.Record(util.NatsActor(msg), model.EntityLanguageValue, current.Uuid, model.OperationUpdate, previous, current)
+			return current, nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageValueFormat, func(req model.LanguageValueFormatRequest) (any, error) {
+		val, err := languageValueStore.Get(req.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		lang, err := languageStore.Get(val.UuidLanguage)
+		if err != nil {
+			return nil, err
+		}
+		return icu.Format(val.Value, lang.Prefix, req.Args)
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkLanguageValue runs the checks that need the language, key or source value of v before
+// it is saved.
+func checkLanguageValue(v model.LanguageValue) error {
+	if err := checkPluralCoverage(v); err != nil {
+		return err
+	}
+	if key, err := languageKeyStore.Get(v.UuidLanguageKey); err == nil {
+		if err = checkMaxLength(v.Value, key); err != nil {
+			return err
+		}
+	}
+	return checkPlaceholders(v)
+}
+
+// checkPluralCoverage requires the plural arguments of v to cover every plural category of its
+// language. Values whose language is unknown are left to the store's syntax check.
+func checkPluralCoverage(v model.LanguageValue) error {
+	lang, err := languageStore.Get(v.UuidLanguage)
+	if err != nil {
+		return nil
+	}
+	return icu.ValidatePlurals(v.Value, lang.Prefix)
+}
+
+// recordBatch audits the changes of an applied batch.
+func recordBatch(msg *nats.Msg, changes []batchChange) {
+	actor := util.NatsActor(msg)
+	for _, c := range changes {
+		auditStore.Record(actor, c.entity, c.uuid, c.operation, c.previous, c.current)
+	}
+}
+
+// recordUpsert audits an upsert as the insert or update it turned out to be.
+func recordUpsert(msg *nats.Msg, entity, entityUuid string, result model.UpsertResult) {
+	switch result.Outcome {
+	case model.UpsertCreated:
+		auditStore.Record(util.NatsActor(msg), entity, entityUuid, model.OperationInsert, nil, result.Data)
+	case model.UpsertChanged:
+		auditStore.Record(util.NatsActor(msg), entity, entityUuid, model.OperationUpdate, result.Previous, result.Data)
+	}
+}
+
+func registerCommentHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindIdempotentHandler(nc, EndpointCommentInsert, idempotencyCache, func(msg *nats.Msg, c model.Comment) (any, error) {
+		var err error
+		switch c.Entity {
+		case model.EntityLanguageKey:
+			_, err = languageKeyStore.Get(c.EntityUuid)
+		case model.EntityLanguageValue:
+			_, err = languageValueStore.Get(c.EntityUuid)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.Author = util.NatsActor(msg)
+		created, err := commentStore.Insert(c)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(c.Author, model.EntityComment, created.Uuid, model.OperationInsert, nil, created)
+		return created, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointCommentUpdate, idempotencyCache, func(msg *nats.Msg, c model.Comment) (any, error) {
+		previous, current, err := commentStore.Update(c.Uuid, c)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityComment, current.Uuid, model.OperationUpdate, previous, current)
+		return current, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointCommentDelete, idempotencyCache, func(msg *nats.Msg, req model.Comment) (any, error) {
+		deleted, err := commentStore.Delete(req.Uuid, req.Revision)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityComment, deleted.Uuid, model.OperationDelete, deleted, nil)
+		return deleted, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointCommentGet, func(req model.Comment) (any, error) {
+		return commentStore.Get(req.Uuid)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointCommentList, func(q model.CommentQuery) (any, error) {
+		return commentStore.List(q), nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func registerTrashHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageRestore, idempotencyCache, func(msg *nats.Msg, req model.Language) (any, error) {
+		restored, err := languageStore.Restore(req.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguage, restored.Uuid, model.OperationRestore, nil, restored)
+		refreshSourceLanguage()
+		return restored, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageTrash, func(_ any) (any, error) {
+		return languageStore.Trash(), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageKeyRestore, idempotencyCache, func(msg *nats.Msg, req model.LanguageKey) (any, error) {
+		restored, err := languageKeyStore.Restore(req.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageKey, restored.Uuid, model.OperationRestore, nil, restored)
+		return restored, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageKeyTrash, func(_ any) (any, error) {
+		return languageKeyStore.Trash(), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointLanguageValueRestore, idempotencyCache, func(msg *nats.Msg, req model.LanguageValue) (any, error) {
+		restored, err := languageValueStore.Restore(req.Uuid)
+		if err != nil {
+			return nil, err
+		}
+		auditStore.Record(util.NatsActor(msg), model.EntityLanguageValue, restored.Uuid, model.OperationRestore, nil, restored)
+		return restored, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointLanguageValueTrash, func(_ any) (any, error) {
+		return languageValueStore.Trash(), nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// purgeTrashEvery purges the trash of every store at each interval until ctx is cancelled.
+func purgeTrashEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purgeTrash(now)
+		}
+	}
+}
+
+// purgeTrash drops the records deleted longer than settings.TrashRetention before now.
+func purgeTrash(now time.Time) {
+	const actor = "system"
+	cutoff := now.Add(-settings.TrashRetention)
+	for _, l := range languageStore.Purge(cutoff) {
+		auditStore.Record(actor, model.EntityLanguage, l.Uuid, model.OperationPurge, l, nil)
+	}
+	for _, k := range languageKeyStore.Purge(cutoff) {
+		auditStore.Record(actor, model.EntityLanguageKey, k.Uuid, model.OperationPurge, k, nil)
+	}
+	for _, v := range languageValueStore.Purge(cutoff) {
+		auditStore.Record(actor, model.EntityLanguageValue, v.Uuid, model.OperationPurge, v, nil)
+	}
+}
+
+func registerNamespaceHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindHandler(nc, EndpointNamespaceList, func(req model.NamespaceRequest) (any, error) {
+		return languageKeyStore.Namespaces(req.Prefix), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointNamespaceKeys, func(req model.NamespaceRequest) (any, error) {
+		return languageKeyStore.ListNamespace(req.Prefix), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointNamespaceCount, func(req model.NamespaceRequest) (any, error) {
+		return model.Namespace{Path: req.Prefix, Keys: languageKeyStore.CountNamespace(req.Prefix)}, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointNamespaceRename, idempotencyCache, func(msg *nats.Msg, req model.NamespaceRename) (any, error) {
+		previous, renamed, err := languageKeyStore.RenameNamespace(req.From, req.To, aliasExpires(req.Alias))
+		if err != nil {
+			return nil, err
+		}
+		actor := util.NatsActor(msg)
+		for i, k := range renamed {
+			auditStore.Record(actor, model.EntityLanguageKey, k.Uuid, model.OperationUpdate, previous[i], k)
+		}
+		return renamed, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointNamespaceDelete, idempotencyCache, func(msg *nats.Msg, req model.NamespaceRequest) (any, error) {
+		deleted, err := languageKeyStore.DeleteNamespace(req.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		actor := util.NatsActor(msg)
+		for _, k := range deleted {
+			auditStore.Record(actor, model.EntityLanguageKey, k.Uuid, model.OperationDelete, k, nil)
+		}
+		return deleted, nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func registerBundleHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindHandler(nc, EndpointBundle, func(req model.BundleRequest) (any, error) {
+		return bundleFor(req.Prefix, req.Namespaces, req.IncludeDrafts)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointResolve, func(req model.ResolveRequest) (any, error) {
+		b, err := bundleFor(req.Prefix, nil, req.IncludeDrafts)
+		if err != nil {
+			return nil, err
+		}
+		return resolveKeys(b, req.Keys, languageKeyStore.AliasTargets(time.Now())), nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// bundleFor builds the bundle of the language with the given Prefix from the stores, limited
+// to the given key namespaces when there are any.
+func bundleFor(prefix string, namespaces []string, includeDrafts bool) (model.Bundle, error) {
+	lang, err := languageStore.GetByPrefix(prefix)
+	if err != nil {
+		return model.Bundle{}, err
+	}
+	values := languageValueStore.Query(model.LanguageValueQuery{UuidLanguage: lang.Uuid})
+	b := buildBundle(lang, languageKeyStore.List(), values, includeDrafts)
+	addAliases(b, languageKeyStore.AliasTargets(time.Now()))
+	if len(namespaces) > 0 {
+		filterNamespaces(b, namespaces)
+	}
+	return b, nil
+}
+
+// aliasExpires returns when an alias recorded now would stop resolving, or the zero time when
+// no alias is wanted.
+func aliasExpires(alias bool) time.Time {
+	if !alias {
+		return time.Time{}
+	}
+	return time.Now().Add(settings.AliasWindow)
+}
+
+func registerExchangeHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindHandler(nc, EndpointI18nextExport, func(req model.ExportRequest) (any, error) {
+		return i18nextExport(req)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointI18nextImport, idempotencyCache, func(msg *nats.Msg, req model.ImportRequest) (any, error) {
+		result, changes, err := i18nextImport(req)
+		if err != nil {
+			return nil, err
+		}
+		recordBatch(msg, changes)
+		return result, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointGettextTemplate, func(_ any) (any, error) {
+		return gettextTemplate()
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointGettextExport, func(req model.ExportRequest) (any, error) {
+		return gettextExport(req)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointGettextImport, idempotencyCache, func(msg *nats.Msg, req model.ImportRequest) (any, error) {
+		result, changes, err := gettextImport(req)
+		if err != nil {
+			return nil, err
+		}
+		recordBatch(msg, changes)
+		return result, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointXliffExport, func(req model.XliffExportRequest) (any, error) {
+		return xliffExport(req)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointXliffImport, idempotencyCache, func(msg *nats.Msg, req model.ImportRequest) (any, error) {
+		result, changes, err := xliffImport(req)
+		if err != nil {
+			return nil, err
+		}
+		recordBatch(msg, changes)
+		return result, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointAndroidExport, func(req model.ExportRequest) (any, error) {
+		return androidExport(req)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointAndroidImport, idempotencyCache, func(msg *nats.Msg, req model.ImportRequest) (any, error) {
+		result, changes, err := androidImport(req)
+		if err != nil {
+			return nil, err
+		}
+		recordBatch(msg, changes)
+		return result, nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointIOSExport, func(req model.ExportRequest) (any, error) {
+		return iosExport(req)
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindIdempotentHandler(nc, EndpointIOSImport, idempotencyCache, func(msg *nats.Msg, req model.ImportRequest) (any, error) {
+		result, changes, err := iosImport(req)
+		if err != nil {
+			return nil, err
+		}
+		recordBatch(msg, changes)
+		return result, nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func registerBatchHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindIdempotentHandler(nc, EndpointBatch, idempotencyCache, func(msg *nats.Msg, req model.BatchRequest) (any, error) {
+		resp, changes := applyBatch(languageStore, languageKeyStore, languageValueStore, req.Operations)
+		recordBatch(msg, changes)
+		return resp, nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func registerAuditHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindHandler(nc, EndpointAuditQuery, func(q model.AuditQuery) (any, error) {
+		return auditStore.Query(q), nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func registerQAHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindHandler(nc, EndpointQAReport, func(q model.QAQuery) (any, error) {
+		source, err := sourceLanguage()
+		if err != nil {
+			return nil, err
+		}
+		return qaReport(languageValueStore.Query(model.LanguageValueQuery{}), source.Uuid, q), nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func registerReportHandlers(nc *nats.Conn) error {
+	if err := util.NatsBindHandler(nc, EndpointReportCoverage, func(q model.CoverageQuery) (any, error) {
+		langs := languageStore.List()
+		if q.UuidLanguage != "" {
+			lang, err := languageStore.Get(q.UuidLanguage)
+			if err != nil {
+				return nil, err
+			}
+			langs = []model.Language{lang}
+		}
+		return coverageReport(langs, languageKeyStore.List(), languageValueStore.Query(model.LanguageValueQuery{})), nil
+	}); err != nil {
+		return err
+	}
+
+	if err := util.NatsBindHandler(nc, EndpointReportMatrix, func(_ any) (any, error) {
+		return coverageMatrix(languageStore.List(), languageKeyStore.List(), languageValueStore.Query(model.LanguageValueQuery{})), nil
+	}); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 
 	"github.com/rah-0/meisterwerk/model"
 	"github.com/rah-0/meisterwerk/util"
+	"github.com/rah-0/meisterwerk/xliff"
 )
 
 var testCtx context.Context
@@ -581,3 +583,1691 @@ func TestLanguageValue_List(t *testing.T) {
 		t.Errorf("expected at least 2 values, got %d", len(vals))
 	}
 }
+
+func TestAuditQuery(t *testing.T) {
+	actor := "auditor-" + uuid.NewString()[0:8]
+	lang := model.Language{
+		Uuid:   uuid.NewString(),
+		Prefix: "fr-FR",
+		Lang:   "French",
+	}
+
+	// INSERT with actor header
+	model.BufferReset()
+	if err := model.Encode(lang); err != nil {
+		t.Fatalf("encode insert failed: %v", err)
+	}
+	reqMsg := nats.NewMsg(EndpointLanguageInsert)
+	reqMsg.Header.Set(util.NatsHeaderActor, actor)
+	reqMsg.Data = model.GetBytes()
+	respMsg, err := natsClientConn.RequestMsg(reqMsg, time.Second)
+	if err != nil {
+		t.Fatalf("insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var insertResp util.NatsResponse
+	if err := model.Decode(&insertResp); err != nil || insertResp.Status != 200 || insertResp.Error != "" {
+		t.Fatalf("insert failed: %v | %s", err, insertResp.Error)
+	}
+
+	// QUERY
+	model.BufferReset()
+	if err := model.Encode(model.AuditQuery{Actor: actor, Entity: model.EntityLanguage}); err != nil {
+		t.Fatalf("encode query failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointAuditQuery, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var queryResp util.NatsResponse
+	if err := model.Decode(&queryResp); err != nil || queryResp.Status != 200 || queryResp.Error != "" {
+		t.Fatalf("query failed: %v | %s", err, queryResp.Error)
+	}
+	entries, ok := queryResp.Data.([]model.AuditEntry)
+	if !ok {
+		t.Fatalf("unexpected type for query response: %T", queryResp.Data)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].EntityUuid != lang.Uuid || entries[0].Operation != model.OperationInsert {
+		t.Errorf("unexpected audit entry: %+v", entries[0])
+	}
+	current, ok := entries[0].Current.(model.Language)
+	if !ok || current.Lang != lang.Lang {
+		t.Errorf("unexpected current value: %+v", entries[0].Current)
+	}
+}
+
+func TestLanguageValue_RevisionsAndRevert(t *testing.T) {
+	value := model.LanguageValue{
+		Uuid:            uuid.NewString(),
+		UuidLanguage:    uuid.NewString(),
+		UuidLanguageKey: uuid.NewString(),
+		Value:           "Original",
+	}
+
+	// Insert and update
+	for _, text := range []string{"Original", "Edited"} {
+		value.Value = text
+		endpoint := EndpointLanguageValueInsert
+		if text != "Original" {
+			endpoint = EndpointLanguageValueUpdate
+		}
+		model.BufferReset()
+		if err := model.Encode(value); err != nil {
+			t.Fatalf("encode %s failed: %v", endpoint, err)
+		}
+		respMsg, err := natsClientConn.Request(endpoint, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", endpoint, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil || resp.Status != 200 || resp.Error != "" {
+			t.Fatalf("%s failed: %v | %s", endpoint, err, resp.Error)
+		}
+	}
+
+	// Revisions
+	model.BufferReset()
+	if err := model.Encode(model.LanguageValueRevisionRequest{Uuid: value.Uuid}); err != nil {
+		t.Fatalf("encode revisions failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageValueRevisions, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("revisions request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var revResp util.NatsResponse
+	if err := model.Decode(&revResp); err != nil || revResp.Status != 200 || revResp.Error != "" {
+		t.Fatalf("revisions failed: %v | %s", err, revResp.Error)
+	}
+	revs, ok := revResp.Data.([]model.LanguageValueRevision)
+	if !ok {
+		t.Fatalf("unexpected type for revisions response: %T", revResp.Data)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("expected 2 revisions, got %d", len(revs))
+	}
+
+	// Diff
+	model.BufferReset()
+	if err := model.Encode(model.LanguageValueRevisionRequest{Uuid: value.Uuid, Revision: 1, Other: 2}); err != nil {
+		t.Fatalf("encode diff failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageValueDiff, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("diff request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var diffResp util.NatsResponse
+	if err := model.Decode(&diffResp); err != nil || diffResp.Status != 200 || diffResp.Error != "" {
+		t.Fatalf("diff failed: %v | %s", err, diffResp.Error)
+	}
+	if _, ok := diffResp.Data.([]model.DiffSegment); !ok {
+		t.Fatalf("unexpected type for diff response: %T", diffResp.Data)
+	}
+
+	// Revert
+	model.BufferReset()
+	if err := model.Encode(model.LanguageValueRevisionRequest{Uuid: value.Uuid, Revision: 1}); err != nil {
+		t.Fatalf("encode revert failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageValueRevert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("revert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var revertResp util.NatsResponse
+	if err := model.Decode(&revertResp); err != nil || revertResp.Status != 200 || revertResp.Error != "" {
+		t.Fatalf("revert failed: %v | %s", err, revertResp.Error)
+	}
+	reverted, ok := revertResp.Data.(model.LanguageValue)
+	if !ok {
+		t.Fatalf("unexpected type for revert response: %T", revertResp.Data)
+	}
+	if reverted.Value != "Original" {
+		t.Errorf("expected reverted value 'Original', got %s", reverted.Value)
+	}
+
+	// A revert expecting the revision before it conflicts
+	model.BufferReset()
+	if err := model.Encode(model.LanguageValueRevisionRequest{Uuid: value.Uuid, Revision: 2, Expected: reverted.Revision - 1}); err != nil {
+		t.Fatalf("encode revert failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageValueRevert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("revert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var staleResp util.NatsResponse
+	if err := model.Decode(&staleResp); err != nil || staleResp.Status != 409 {
+		t.Fatalf("expected stale revert to conflict: %v | %d %s", err, staleResp.Status, staleResp.Error)
+	}
+}
+
+func TestLanguageUpdate_RevisionConflict(t *testing.T) {
+	lang := model.Language{
+		Uuid:   uuid.NewString(),
+		Prefix: "it-IT",
+		Lang:   "Italian",
+	}
+
+	// Insert, then update twice with the same expected revision
+	model.BufferReset()
+	if err := model.Encode(lang); err != nil {
+		t.Fatalf("encode insert failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var insertResp util.NatsResponse
+	if err := model.Decode(&insertResp); err != nil || insertResp.Status != 200 {
+		t.Fatalf("insert failed: %v | %s", err, insertResp.Error)
+	}
+
+	lang.Revision = 1
+	statuses := make([]int, 0, 2)
+	for _, name := range []string{"Italiano", "Stale"} {
+		lang.Lang = name
+		model.BufferReset()
+		if err := model.Encode(lang); err != nil {
+			t.Fatalf("encode update failed: %v", err)
+		}
+		respMsg, err = natsClientConn.Request(EndpointLanguageUpdate, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("update request failed: %v", err)
+		}
+		model.SetBytes(respMsg.Data)
+		var updateResp util.NatsResponse
+		if err := model.Decode(&updateResp); err != nil {
+			t.Fatalf("decode update response failed: %v", err)
+		}
+		statuses = append(statuses, updateResp.Status)
+
+		if updateResp.Status == 409 {
+			current, ok := updateResp.Data.(model.Language)
+			if !ok {
+				t.Fatalf("unexpected conflict payload type: %T", updateResp.Data)
+			}
+			if current.Lang != "Italiano" || current.Revision != 2 {
+				t.Errorf("conflict payload is not the stored record: %+v", current)
+			}
+		}
+	}
+	if statuses[0] != 200 || statuses[1] != 409 {
+		t.Errorf("expected statuses [200 409], got %v", statuses)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	lang := model.Language{Uuid: uuid.NewString(), Prefix: "ja-JP", Lang: "Japanese"}
+	key := model.LanguageKey{Uuid: uuid.NewString(), Value: "batch.greeting." + uuid.NewString()[0:8]}
+	val := model.LanguageValue{Uuid: uuid.NewString(), UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: "こんにちは"}
+
+	req := model.BatchRequest{Operations: []model.BatchOperation{
+		{Entity: model.EntityLanguage, Operation: model.OperationInsert, Record: lang},
+		{Entity: model.EntityLanguageKey, Operation: model.OperationInsert, Record: key},
+		{Entity: model.EntityLanguageValue, Operation: model.OperationInsert, Record: val},
+	}}
+
+	model.BufferReset()
+	if err := model.Encode(req); err != nil {
+		t.Fatalf("encode batch failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointBatch, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("batch request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var resp util.NatsResponse
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 || resp.Error != "" {
+		t.Fatalf("batch failed: %v | %s", err, resp.Error)
+	}
+	batchResp, ok := resp.Data.(model.BatchResponse)
+	if !ok {
+		t.Fatalf("unexpected type for batch response: %T", resp.Data)
+	}
+	if !batchResp.Applied || len(batchResp.Results) != 3 {
+		t.Fatalf("batch not applied: %+v", batchResp)
+	}
+	stored, ok := batchResp.Results[2].Data.(model.LanguageValue)
+	if !ok || stored.Value != val.Value || stored.FirstInsert.IsZero() {
+		t.Errorf("unexpected stored value in result: %+v", batchResp.Results[2].Data)
+	}
+}
+
+func TestLanguageValue_Upsert(t *testing.T) {
+	prefix := "xx-" + uuid.NewString()[0:4]
+	keyValue := "upsert.title." + uuid.NewString()[0:8]
+
+	// Languages and keys are upserted by natural identifiers first
+	model.BufferReset()
+	if err := model.Encode([]model.Language{{Prefix: prefix, Lang: "Test"}}); err != nil {
+		t.Fatalf("encode language upsert failed: %v", err)
+	}
+	if _, err := natsClientConn.Request(EndpointLanguageUpsert, model.GetBytes(), time.Second); err != nil {
+		t.Fatalf("language upsert request failed: %v", err)
+	}
+	model.BufferReset()
+	if err := model.Encode([]model.LanguageKey{{Value: keyValue}}); err != nil {
+		t.Fatalf("encode key upsert failed: %v", err)
+	}
+	if _, err := natsClientConn.Request(EndpointLanguageKeyUpsert, model.GetBytes(), time.Second); err != nil {
+		t.Fatalf("key upsert request failed: %v", err)
+	}
+
+	outcomes := make([]string, 0, 3)
+	for _, text := range []string{"Titel", "Titel", "Überschrift"} {
+		model.BufferReset()
+		if err := model.Encode([]model.LanguageValueUpsert{{Prefix: prefix, Key: keyValue, Value: text}}); err != nil {
+			t.Fatalf("encode value upsert failed: %v", err)
+		}
+		respMsg, err := natsClientConn.Request(EndpointLanguageValueUpsert, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("value upsert request failed: %v", err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil || resp.Status != 200 || resp.Error != "" {
+			t.Fatalf("value upsert failed: %v | %s", err, resp.Error)
+		}
+		results, ok := resp.Data.([]model.UpsertResult)
+		if !ok || len(results) != 1 {
+			t.Fatalf("unexpected upsert response: %T %+v", resp.Data, resp.Data)
+		}
+		if results[0].Error != "" {
+			t.Fatalf("value upsert returned error: %s", results[0].Error)
+		}
+		outcomes = append(outcomes, results[0].Outcome)
+	}
+
+	want := []string{model.UpsertCreated, model.UpsertUnchanged, model.UpsertChanged}
+	for i := range want {
+		if outcomes[i] != want[i] {
+			t.Errorf("upsert #%d: got outcome %s, want %s", i+1, outcomes[i], want[i])
+		}
+	}
+}
+
+func TestLanguageKeyInsert_ReturnsStoredRecord(t *testing.T) {
+	key := model.LanguageKey{Value: "generated." + uuid.NewString()[0:8]}
+
+	model.BufferReset()
+	if err := model.Encode(key); err != nil {
+		t.Fatalf("encode insert failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageKeyInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var resp util.NatsResponse
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 || resp.Error != "" {
+		t.Fatalf("insert failed: %v | %s", err, resp.Error)
+	}
+	created, ok := resp.Data.(model.LanguageKey)
+	if !ok {
+		t.Fatalf("unexpected type for insert response: %T", resp.Data)
+	}
+	if _, err := uuid.Parse(created.Uuid); err != nil {
+		t.Errorf("expected assigned Uuid, got %q", created.Uuid)
+	}
+	if created.FirstInsert.IsZero() || created.Value != key.Value {
+		t.Errorf("unexpected stored record: %+v", created)
+	}
+
+	// A malformed Uuid is rejected
+	model.BufferReset()
+	if err := model.Encode(model.LanguageKey{Uuid: "not-a-uuid", Value: "malformed." + uuid.NewString()[0:8]}); err != nil {
+		t.Fatalf("encode insert failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageKeyInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var badResp util.NatsResponse
+	if err := model.Decode(&badResp); err != nil {
+		t.Fatalf("decode insert response failed: %v", err)
+	}
+	if badResp.Status == 200 {
+		t.Error("expected malformed Uuid to be rejected")
+	}
+}
+
+func TestLanguageValue_MutationsReturnStoredRecord(t *testing.T) {
+	value := model.LanguageValue{
+		UuidLanguage:    uuid.NewString(),
+		UuidLanguageKey: uuid.NewString(),
+		Value:           "Erst",
+	}
+
+	request := func(endpoint string, v model.LanguageValue) model.LanguageValue {
+		model.BufferReset()
+		if err := model.Encode(v); err != nil {
+			t.Fatalf("encode %s failed: %v", endpoint, err)
+		}
+		respMsg, err := natsClientConn.Request(endpoint, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", endpoint, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil || resp.Status != 200 || resp.Error != "" {
+			t.Fatalf("%s failed: %v | %s", endpoint, err, resp.Error)
+		}
+		stored, ok := resp.Data.(model.LanguageValue)
+		if !ok {
+			t.Fatalf("unexpected type for %s response: %T", endpoint, resp.Data)
+		}
+		return stored
+	}
+
+	created := request(EndpointLanguageValueInsert, value)
+	if created.Uuid == "" || created.FirstInsert.IsZero() || created.Revision != 1 {
+		t.Errorf("insert should return the stored record, got %+v", created)
+	}
+
+	created.Value = "Dann"
+	updated := request(EndpointLanguageValueUpdate, created)
+	if updated.Value != "Dann" || updated.Revision != 2 || !updated.FirstInsert.Equal(created.FirstInsert) || updated.LastUpdate.IsZero() {
+		t.Errorf("update should return the stored record, got %+v", updated)
+	}
+
+	deleted := request(EndpointLanguageValueDelete, model.LanguageValue{Uuid: created.Uuid})
+	if deleted.Uuid != created.Uuid || deleted.Revision != 2 {
+		t.Errorf("delete should return the deleted record, got %+v", deleted)
+	}
+}
+
+func TestLanguageKeyInsert_IdempotentRetry(t *testing.T) {
+	key := model.LanguageKey{Value: "retry." + uuid.NewString()[0:8]}
+	idempotencyKey := uuid.NewString()
+
+	send := func() util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(key); err != nil {
+			t.Fatalf("encode insert failed: %v", err)
+		}
+		reqMsg := nats.NewMsg(EndpointLanguageKeyInsert)
+		reqMsg.Header.Set(util.NatsHeaderIdempotencyKey, idempotencyKey)
+		reqMsg.Data = model.GetBytes()
+		respMsg, err := natsClientConn.RequestMsg(reqMsg, time.Second)
+		if err != nil {
+			t.Fatalf("insert request failed: %v", err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode insert response failed: %v", err)
+		}
+		return resp
+	}
+
+	first := send()
+	if first.Status != 200 || first.Error != "" {
+		t.Fatalf("first insert failed: %d %s", first.Status, first.Error)
+	}
+	retry := send()
+	if retry.Status != 200 || retry.Error != "" {
+		t.Fatalf("retried insert was not replayed: %d %s", retry.Status, retry.Error)
+	}
+
+	created, _ := first.Data.(model.LanguageKey)
+	replayed, _ := retry.Data.(model.LanguageKey)
+	if created.Uuid == "" || replayed.Uuid != created.Uuid {
+		t.Errorf("expected replayed response for %s, got %s", created.Uuid, replayed.Uuid)
+	}
+
+	// Without the header the duplicate is rejected as usual
+	model.BufferReset()
+	if err := model.Encode(key); err != nil {
+		t.Fatalf("encode insert failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageKeyInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var plain util.NatsResponse
+	if err := model.Decode(&plain); err != nil {
+		t.Fatalf("decode insert response failed: %v", err)
+	}
+	if plain.Status == 200 {
+		t.Error("expected duplicate insert without idempotency key to fail")
+	}
+}
+
+func TestLanguageValue_Format(t *testing.T) {
+	lang := model.Language{Prefix: "en-GB", Lang: "English"}
+	model.BufferReset()
+	if err := model.Encode(lang); err != nil {
+		t.Fatalf("encode language insert failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("language insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var langResp util.NatsResponse
+	if err := model.Decode(&langResp); err != nil || langResp.Status != 200 {
+		t.Fatalf("language insert failed: %v | %s", err, langResp.Error)
+	}
+	lang = langResp.Data.(model.Language)
+
+	value := model.LanguageValue{
+		UuidLanguage:    lang.Uuid,
+		UuidLanguageKey: uuid.NewString(),
+		Value:           "{name} has {count, plural, one {# message} other {# messages}}",
+	}
+	model.BufferReset()
+	if err := model.Encode(value); err != nil {
+		t.Fatalf("encode value insert failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageValueInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("value insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var valResp util.NatsResponse
+	if err := model.Decode(&valResp); err != nil || valResp.Status != 200 {
+		t.Fatalf("value insert failed: %v | %s", err, valResp.Error)
+	}
+	value = valResp.Data.(model.LanguageValue)
+
+	model.BufferReset()
+	if err := model.Encode(model.LanguageValueFormatRequest{Uuid: value.Uuid, Args: map[string]any{"name": "Ada", "count": 3}}); err != nil {
+		t.Fatalf("encode format failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageValueFormat, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("format request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var formatResp util.NatsResponse
+	if err := model.Decode(&formatResp); err != nil || formatResp.Status != 200 || formatResp.Error != "" {
+		t.Fatalf("format failed: %v | %s", err, formatResp.Error)
+	}
+	if formatResp.Data != "Ada has 3 messages" {
+		t.Errorf("unexpected formatted value: %v", formatResp.Data)
+	}
+}
+
+func TestLanguageValue_PluralCoverage(t *testing.T) {
+	lang := model.Language{Prefix: "ru-RU", Lang: "Russian"}
+	model.BufferReset()
+	if err := model.Encode(lang); err != nil {
+		t.Fatalf("encode language insert failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("language insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var langResp util.NatsResponse
+	if err := model.Decode(&langResp); err != nil || langResp.Status != 200 {
+		t.Fatalf("language insert failed: %v | %s", err, langResp.Error)
+	}
+	lang = langResp.Data.(model.Language)
+	if lang.PluralCategories != "one,few,many,other" {
+		t.Errorf("unexpected plural categories: %v", lang.PluralCategories)
+	}
+
+	insert := func(text string) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: uuid.NewString(), Value: text}); err != nil {
+			t.Fatalf("encode value insert failed: %v", err)
+		}
+		respMsg, err := natsClientConn.Request(EndpointLanguageValueInsert, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("value insert request failed: %v", err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode value insert failed: %v", err)
+		}
+		return resp
+	}
+
+	if resp := insert("{count, plural, one {# файл} other {# файла}}"); resp.Status == 200 || !strings.Contains(resp.Error, "few, many") {
+		t.Errorf("expected missing categories to be rejected, got %d | %s", resp.Status, resp.Error)
+	}
+
+	resp := insert("{count, plural, one {# файл} few {# файла} many {# файлов} other {# файла}}")
+	if resp.Status != 200 {
+		t.Fatalf("value insert failed: %s", resp.Error)
+	}
+	value := resp.Data.(model.LanguageValue)
+
+	model.BufferReset()
+	if err := model.Encode(model.LanguageValueFormatRequest{Uuid: value.Uuid, Args: map[string]any{"count": 22}}); err != nil {
+		t.Fatalf("encode format failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointLanguageValueFormat, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("format request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var formatResp util.NatsResponse
+	if err := model.Decode(&formatResp); err != nil || formatResp.Status != 200 {
+		t.Fatalf("format failed: %v | %s", err, formatResp.Error)
+	}
+	if formatResp.Data != "22 файла" {
+		t.Errorf("unexpected formatted value: %v", formatResp.Data)
+	}
+}
+
+func TestQAReport_Placeholders(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	previous := settings
+	settings.SourceLanguage = "en-CA"
+	defer func() {
+		settings = previous
+		refreshSourceLanguage()
+	}()
+
+	source := request(EndpointLanguageInsert, model.Language{Prefix: "en-CA", Lang: "English"}).Data.(model.Language)
+	target := request(EndpointLanguageInsert, model.Language{Prefix: "de-CH", Lang: "German"}).Data.(model.Language)
+	keyUuid := uuid.NewString()
+
+	if resp := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: keyUuid, Value: "Hello {name}, %d new"}); resp.Status != 200 {
+		t.Fatalf("source insert failed: %s", resp.Error)
+	}
+	resp := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: keyUuid, Value: "Hallo {nmae}, neu"})
+	if resp.Status != 200 {
+		t.Fatalf("target insert failed: %s", resp.Error)
+	}
+	translation := resp.Data.(model.LanguageValue)
+
+	resp = request(EndpointQAReport, model.QAQuery{UuidLanguage: target.Uuid})
+	if resp.Status != 200 {
+		t.Fatalf("qa report failed: %s", resp.Error)
+	}
+	issues := resp.Data.([]model.QAIssue)
+	if len(issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %+v", issues)
+	}
+	for _, issue := range issues {
+		if issue.UuidLanguageValue != translation.Uuid || issue.Check != "placeholders" {
+			t.Errorf("Unexpected issue: %+v", issue)
+		}
+	}
+
+	settings.EnforcePlaceholders = true
+	translation.Value = "Hallo {name}, neu"
+	if resp = request(EndpointLanguageValueUpdate, translation); resp.Status == 200 || !strings.Contains(resp.Error, "missing placeholder %d") {
+		t.Errorf("Expected update with missing placeholder to be rejected, got %d | %s", resp.Status, resp.Error)
+	}
+	translation.Value = "Hallo {name}, %d neu"
+	if resp = request(EndpointLanguageValueUpdate, translation); resp.Status != 200 {
+		t.Errorf("Expected matching placeholders to be accepted: %s", resp.Error)
+	}
+
+	key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: "qa" + strings.ReplaceAll(uuid.NewString(), "-", "")}).Data.(model.LanguageKey)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: key.Uuid, Value: "Bye {name}"})
+	resp = request(EndpointLanguageValueUpsert, []model.LanguageValueUpsert{{Prefix: "de-CH", Key: key.Value, Value: "Tschüss"}})
+	if results := resp.Data.([]model.UpsertResult); !strings.Contains(results[0].Error, "missing placeholder {name}") {
+		t.Errorf("Expected upsert with missing placeholder to be rejected, got %+v", results[0])
+	}
+	file := []byte(`{"` + key.Value + `": "Tschüss"}`)
+	if resp = request(EndpointI18nextImport, model.ImportRequest{Prefix: "de-CH", Data: file}); resp.Status == 200 || !strings.Contains(resp.Error, "missing placeholder {name}") {
+		t.Errorf("Expected import with missing placeholder to be rejected, got %d | %s", resp.Status, resp.Error)
+	}
+
+	resp = request(EndpointQAReport, model.QAQuery{UuidLanguage: target.Uuid})
+	if issues := resp.Data.([]model.QAIssue); len(issues) != 0 {
+		t.Errorf("Expected no issues after fix, got %+v", issues)
+	}
+
+	values := languageValueStore
+	languageValueStore = NewLanguageValueStore()
+	resp = request(EndpointQAReport, model.QAQuery{})
+	languageValueStore = values
+	if resp.Status != 200 || len(resp.Data.([]model.QAIssue)) != 0 {
+		t.Errorf("Expected no issues without values, got %d | %s | %+v", resp.Status, resp.Error, resp.Data)
+	}
+}
+
+func TestReportCoverage(t *testing.T) {
+	lang := model.Language{Prefix: "ja-JP", Lang: "Japanese"}
+	model.BufferReset()
+	if err := model.Encode(lang); err != nil {
+		t.Fatalf("encode language insert failed: %v", err)
+	}
+	respMsg, err := natsClientConn.Request(EndpointLanguageInsert, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("language insert request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var langResp util.NatsResponse
+	if err := model.Decode(&langResp); err != nil || langResp.Status != 200 {
+		t.Fatalf("language insert failed: %v | %s", err, langResp.Error)
+	}
+	lang = langResp.Data.(model.Language)
+
+	model.BufferReset()
+	if err := model.Encode(model.CoverageQuery{UuidLanguage: lang.Uuid}); err != nil {
+		t.Fatalf("encode coverage query failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointReportCoverage, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("coverage request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var resp util.NatsResponse
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 {
+		t.Fatalf("coverage failed: %v | %s", err, resp.Error)
+	}
+	report := resp.Data.([]model.LanguageCoverage)
+	if len(report) != 1 || report[0].UuidLanguage != lang.Uuid {
+		t.Fatalf("Expected coverage of the requested language, got %+v", report)
+	}
+	if c := report[0]; c.Missing != c.Total || len(c.MissingKeys) != c.Total || c.Translated != 0 {
+		t.Errorf("Expected every key to be missing for a new language, got %+v", c)
+	}
+
+	respMsg, err = natsClientConn.Request(EndpointReportMatrix, nil, time.Second)
+	if err != nil {
+		t.Fatalf("matrix request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	resp = util.NatsResponse{}
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 {
+		t.Fatalf("matrix failed: %v | %s", err, resp.Error)
+	}
+	matrix := resp.Data.(model.CoverageMatrix)
+	for _, row := range matrix.Rows {
+		if row.States[lang.Uuid] != model.CoverageMissing {
+			t.Errorf("Expected %s to be missing in the new language, got %q", row.Key, row.States[lang.Uuid])
+		}
+	}
+
+	// Keys without any values yet are all missing, not an error.
+	values := languageValueStore
+	languageValueStore = NewLanguageValueStore()
+	defer func() { languageValueStore = values }()
+
+	model.BufferReset()
+	if err := model.Encode(model.CoverageQuery{UuidLanguage: lang.Uuid}); err != nil {
+		t.Fatalf("encode coverage query failed: %v", err)
+	}
+	respMsg, err = natsClientConn.Request(EndpointReportCoverage, model.GetBytes(), time.Second)
+	if err != nil {
+		t.Fatalf("coverage request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	resp = util.NatsResponse{}
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 {
+		t.Fatalf("coverage without values failed: %v | %s", err, resp.Error)
+	}
+	if c := resp.Data.([]model.LanguageCoverage)[0]; c.Total == 0 || c.Missing != c.Total || c.Percent != 0 {
+		t.Errorf("Expected every key to be missing without values, got %+v", c)
+	}
+
+	respMsg, err = natsClientConn.Request(EndpointReportMatrix, nil, time.Second)
+	if err != nil {
+		t.Fatalf("matrix request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	resp = util.NatsResponse{}
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 {
+		t.Fatalf("matrix without values failed: %v | %s", err, resp.Error)
+	}
+	if m := resp.Data.(model.CoverageMatrix); len(m.Rows) != len(matrix.Rows) {
+		t.Errorf("Expected a row per key without values, got %d rows", len(m.Rows))
+	}
+}
+
+func TestLanguageValue_NeedsReviewWhenSourceChanges(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	previous := settings
+	settings.SourceLanguage = "en-NZ"
+	defer func() {
+		settings = previous
+		refreshSourceLanguage()
+	}()
+
+	source := request(EndpointLanguageInsert, model.Language{Prefix: "en-NZ", Lang: "English"}).Data.(model.Language)
+	target := request(EndpointLanguageInsert, model.Language{Prefix: "mi-NZ", Lang: "Maori"}).Data.(model.Language)
+	keyUuid := uuid.NewString()
+
+	sourceValue := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: keyUuid, Value: "Welcome"}).Data.(model.LanguageValue)
+	translation := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: keyUuid, Value: "Nau mai"}).Data.(model.LanguageValue)
+	if translation.SourceRevision != 1 || translation.NeedsReview {
+		t.Fatalf("Expected translation made against source revision 1, got %+v", translation)
+	}
+
+	sourceValue.Value = "Welcome back"
+	if resp := request(EndpointLanguageValueUpdate, sourceValue); resp.Status != 200 {
+		t.Fatalf("source update failed: %s", resp.Error)
+	}
+
+	resp := request(EndpointLanguageValueQuery, model.LanguageValueQuery{UuidLanguage: target.Uuid, NeedsReview: true})
+	stale := resp.Data.([]model.LanguageValue)
+	if len(stale) != 1 || stale[0].Uuid != translation.Uuid {
+		t.Fatalf("Expected the translation to need review, got %+v", stale)
+	}
+
+	translation = stale[0]
+	translation.Value = "Nau mai anō"
+	resp = request(EndpointLanguageValueUpdate, translation)
+	if resp.Status != 200 {
+		t.Fatalf("translation update failed: %s", resp.Error)
+	}
+	if updated := resp.Data.(model.LanguageValue); updated.NeedsReview || updated.SourceRevision != 2 {
+		t.Errorf("Expected review to be cleared against source revision 2, got %+v", updated)
+	}
+	if stale := request(EndpointLanguageValueQuery, model.LanguageValueQuery{UuidLanguage: target.Uuid, NeedsReview: true}).Data.([]model.LanguageValue); len(stale) != 0 {
+		t.Errorf("Expected no values needing review, got %+v", stale)
+	}
+}
+
+func TestLanguageValue_ReviewWorkflow(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	lang := request(EndpointLanguageInsert, model.Language{Prefix: "nl-NL", Lang: "Dutch"}).Data.(model.Language)
+	key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: "workflow." + uuid.NewString()}).Data.(model.LanguageKey)
+	value := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: "Opslaan"}).Data.(model.LanguageValue)
+
+	resolve := func(includeDrafts bool) model.ResolveResult {
+		resp := request(EndpointResolve, model.ResolveRequest{Prefix: "nl-NL", Keys: []string{key.Value}, IncludeDrafts: includeDrafts})
+		if resp.Status != 200 {
+			t.Fatalf("resolve failed: %s", resp.Error)
+		}
+		return resp.Data.([]model.ResolveResult)[0]
+	}
+	if resolve(false).Found {
+		t.Error("Draft should not be served to production")
+	}
+	if r := resolve(true); !r.Found || r.Value != "Opslaan" {
+		t.Errorf("Draft should be served to preview, got %+v", r)
+	}
+
+	if resp := request(EndpointLanguageValueApprove, model.LanguageValueTransition{Uuid: value.Uuid}); resp.Status == 200 {
+		t.Error("Expected approving a draft to fail")
+	}
+	request(EndpointLanguageValueSubmit, model.LanguageValueTransition{Uuid: value.Uuid})
+	resp := request(EndpointLanguageValueReject, model.LanguageValueTransition{Uuid: value.Uuid, Comment: "Use 'Bewaren'"})
+	if v := resp.Data.(model.LanguageValue); resp.Status != 200 || v.Status != model.ValueStatusDraft || v.StatusComment != "Use 'Bewaren'" {
+		t.Fatalf("Expected rejection with comment, got %d | %s", resp.Status, resp.Error)
+	}
+
+	request(EndpointLanguageValueSubmit, model.LanguageValueTransition{Uuid: value.Uuid})
+	if resp = request(EndpointLanguageValueApprove, model.LanguageValueTransition{Uuid: value.Uuid, Comment: "OK"}); resp.Status != 200 {
+		t.Fatalf("approve failed: %s", resp.Error)
+	}
+	if r := resolve(false); !r.Found || r.Value != "Opslaan" {
+		t.Errorf("Approved value should be served to production, got %+v", r)
+	}
+
+	resp = request(EndpointBundle, model.BundleRequest{Prefix: "nl-NL"})
+	if b := resp.Data.(model.Bundle); resp.Status != 200 || b.Values[key.Value] != "Opslaan" {
+		t.Errorf("Expected approved value in bundle, got %+v | %s", resp.Data, resp.Error)
+	}
+}
+
+func TestComment_Thread(t *testing.T) {
+	request := func(subject, actor string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		msg := nats.NewMsg(subject)
+		msg.Data = model.GetBytes()
+		msg.Header.Set(util.NatsHeaderActor, actor)
+		respMsg, err := natsClientConn.RequestMsg(msg, time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	key := request(EndpointLanguageKeyInsert, "dev", model.LanguageKey{Value: "feed.post." + uuid.NewString()}).Data.(model.LanguageKey)
+
+	if resp := request(EndpointCommentInsert, "ana", model.Comment{Entity: model.EntityLanguageKey, EntityUuid: uuid.NewString(), Text: "?"}); resp.Status == 200 {
+		t.Error("Expected comment on an unknown key to be rejected")
+	}
+
+	resp := request(EndpointCommentInsert, "ana", model.Comment{Entity: model.EntityLanguageKey, EntityUuid: key.Uuid, Author: "someone else", Text: "Is 'Post' a verb or a noun here?"})
+	if resp.Status != 200 {
+		t.Fatalf("comment insert failed: %s", resp.Error)
+	}
+	question := resp.Data.(model.Comment)
+	if question.Author != "ana" {
+		t.Errorf("Expected author from actor header, got %q", question.Author)
+	}
+
+	resp = request(EndpointCommentInsert, "dev", model.Comment{Entity: model.EntityLanguageKey, EntityUuid: key.Uuid, UuidParent: question.Uuid, Text: "A verb."})
+	if resp.Status != 200 {
+		t.Fatalf("reply insert failed: %s", resp.Error)
+	}
+
+	question.Resolved = true
+	if resp = request(EndpointCommentUpdate, "ana", question); resp.Status != 200 {
+		t.Fatalf("comment update failed: %s", resp.Error)
+	}
+
+	resp = request(EndpointCommentList, "ana", model.CommentQuery{Entity: model.EntityLanguageKey, EntityUuid: key.Uuid})
+	thread := resp.Data.([]model.Comment)
+	if len(thread) != 2 || !thread[0].Resolved || thread[1].Author != "dev" {
+		t.Errorf("Unexpected thread: %+v", thread)
+	}
+
+	entries := auditStore.Query(model.AuditQuery{Entity: model.EntityComment, EntityUuid: question.Uuid})
+	if len(entries) != 2 || entries[0].Actor != "ana" {
+		t.Errorf("Expected comment insert and update to be audited, got %+v", entries)
+	}
+}
+
+func TestLanguageKey_MaxLengthAndTags(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	tag := "tag-" + uuid.NewString()
+	resp := request(EndpointLanguageKeyInsert, model.LanguageKey{
+		Value:       "metadata." + uuid.NewString(),
+		Description: "Title of the order summary",
+		MaxLength:   10,
+		Tags:        []string{tag, "checkout"},
+	})
+	if resp.Status != 200 {
+		t.Fatalf("key insert failed: %s", resp.Error)
+	}
+	key := resp.Data.(model.LanguageKey)
+	request(EndpointLanguageKeyInsert, model.LanguageKey{Value: "metadata." + uuid.NewString(), Tags: []string{"checkout"}})
+
+	lang := request(EndpointLanguageInsert, model.Language{Prefix: "fr-BE", Lang: "French"}).Data.(model.Language)
+	value := model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: "Récapitulatif de la commande"}
+	if resp = request(EndpointLanguageValueInsert, value); resp.Status == 200 || !strings.Contains(resp.Error, "the limit is 10") {
+		t.Errorf("Expected overlong value to be rejected, got %d | %s", resp.Status, resp.Error)
+	}
+	value.Value = "Commande"
+	if resp = request(EndpointLanguageValueInsert, value); resp.Status != 200 {
+		t.Fatalf("Expected value within the limit to be accepted: %s", resp.Error)
+	}
+	value = resp.Data.(model.LanguageValue)
+	value.Value = "Ordre"
+	request(EndpointLanguageValueUpdate, value)
+	key.MaxLength = 6
+	if resp = request(EndpointLanguageKeyUpdate, key); resp.Status != 200 {
+		t.Fatalf("key update failed: %s", resp.Error)
+	}
+	if resp = request(EndpointLanguageValueRevert, model.LanguageValueRevisionRequest{Uuid: value.Uuid, Revision: 1}); resp.Status == 200 || !strings.Contains(resp.Error, "the limit is 6") {
+		t.Errorf("Expected revert to an overlong value to be rejected, got %d | %s", resp.Status, resp.Error)
+	}
+
+	resp = request(EndpointLanguageKeyQuery, model.LanguageKeyQuery{Tags: []string{tag, "checkout"}})
+	if resp.Status != 200 {
+		t.Fatalf("key query failed: %s", resp.Error)
+	}
+	if keys := resp.Data.([]model.LanguageKey); len(keys) != 1 || keys[0].Uuid != key.Uuid {
+		t.Errorf("Expected only the tagged key, got %+v", keys)
+	}
+}
+
+func TestNamespace_RenameAndBundle(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	root := "ns" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	lang := request(EndpointLanguageInsert, model.Language{Prefix: "nl-BE", Lang: "Dutch"}).Data.(model.Language)
+	for _, name := range []string{"checkout.pay", "checkout.cancel", "home.title"} {
+		key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + "." + name}).Data.(model.LanguageKey)
+		value := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: name}).Data.(model.LanguageValue)
+		request(EndpointLanguageValueSubmit, model.LanguageValueTransition{Uuid: value.Uuid})
+		request(EndpointLanguageValueApprove, model.LanguageValueTransition{Uuid: value.Uuid})
+	}
+
+	resp := request(EndpointNamespaceList, model.NamespaceRequest{Prefix: root})
+	if namespaces := resp.Data.([]model.Namespace); len(namespaces) != 2 || namespaces[0] != (model.Namespace{Path: root + ".checkout", Keys: 2}) {
+		t.Errorf("Unexpected namespaces: %+v", namespaces)
+	}
+
+	resp = request(EndpointNamespaceRename, model.NamespaceRename{From: root + ".checkout", To: root + ".cart"})
+	if resp.Status != 200 || len(resp.Data.([]model.LanguageKey)) != 2 {
+		t.Fatalf("rename failed: %d | %s", resp.Status, resp.Error)
+	}
+	if resp = request(EndpointNamespaceCount, model.NamespaceRequest{Prefix: root + ".checkout"}); resp.Data.(model.Namespace).Keys != 0 {
+		t.Errorf("Expected old namespace to be empty, got %+v", resp.Data)
+	}
+
+	resp = request(EndpointBundle, model.BundleRequest{Prefix: "nl-BE", Namespaces: []string{root + ".cart"}})
+	if resp.Status != 200 {
+		t.Fatalf("bundle failed: %s", resp.Error)
+	}
+	b := resp.Data.(model.Bundle)
+	if len(b.Values) != 2 || b.Values[root+".cart.pay"] != "checkout.pay" {
+		t.Errorf("Expected renamed subtree with its values, got %+v", b.Values)
+	}
+
+	if resp = request(EndpointNamespaceDelete, model.NamespaceRequest{Prefix: root}); resp.Status != 200 || len(resp.Data.([]model.LanguageKey)) != 3 {
+		t.Errorf("Expected namespace delete to remove 3 keys, got %d | %s", resp.Status, resp.Error)
+	}
+}
+
+func TestLanguageKey_RenameWithAlias(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")
+	from, to := "btn.ok"+suffix, "common.actions.ok"+suffix
+	lang := request(EndpointLanguageInsert, model.Language{Prefix: "it-CH", Lang: "Italian"}).Data.(model.Language)
+	key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: from}).Data.(model.LanguageKey)
+	value := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: "Va bene"}).Data.(model.LanguageValue)
+
+	resp := request(EndpointLanguageKeyRename, model.LanguageKeyRename{From: from, To: to, Alias: true})
+	if resp.Status != 200 {
+		t.Fatalf("rename failed: %s", resp.Error)
+	}
+	if renamed := resp.Data.(model.LanguageKey); renamed.Uuid != key.Uuid || renamed.Value != to {
+		t.Errorf("Unexpected renamed key: %+v", renamed)
+	}
+	if got, _ := languageValueStore.Get(value.Uuid); got.UuidLanguageKey != key.Uuid {
+		t.Errorf("Expected value to stay attached, got %+v", got)
+	}
+
+	resp = request(EndpointResolve, model.ResolveRequest{Prefix: "it-CH", Keys: []string{from}, IncludeDrafts: true})
+	if resp.Status != 200 {
+		t.Fatalf("resolve failed: %s", resp.Error)
+	}
+	if results := resp.Data.([]model.ResolveResult); !results[0].Found || results[0].Value != "Va bene" || results[0].RenamedTo != to {
+		t.Errorf("Expected old key string to resolve through its alias, got %+v", results)
+	}
+
+	respMsg, err := natsClientConn.Request(EndpointLanguageKeyAliases, nil, time.Second)
+	if err != nil {
+		t.Fatalf("aliases request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	if err := model.Decode(&resp); err != nil {
+		t.Fatalf("decode aliases failed: %v", err)
+	}
+	found := false
+	for _, a := range resp.Data.([]model.LanguageKeyAlias) {
+		found = found || a.Alias == from && a.UuidLanguageKey == key.Uuid
+	}
+	if !found {
+		t.Errorf("Expected alias %s to be listed, got %+v", from, resp.Data)
+	}
+}
+
+func TestLanguageKey_DeprecatedResolve(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	suffix := strings.ReplaceAll(uuid.NewString(), "-", "")
+	lang := request(EndpointLanguageInsert, model.Language{Prefix: "pt-PT", Lang: "Portuguese"}).Data.(model.Language)
+	current := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: "common.save" + suffix}).Data.(model.LanguageKey)
+	old := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: "btn.save" + suffix}).Data.(model.LanguageKey)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: current.Uuid, Value: "Guardar"})
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: old.Uuid, Value: "Gravar"})
+
+	old.Deprecated = true
+	old.ReplacedBy = current.Uuid
+	if resp := request(EndpointLanguageKeyUpdate, old); resp.Status != 200 {
+		t.Fatalf("deprecating key failed: %s", resp.Error)
+	}
+
+	resp := request(EndpointResolve, model.ResolveRequest{Prefix: "pt-PT", Keys: []string{old.Value}, IncludeDrafts: true})
+	if resp.Status != 200 {
+		t.Fatalf("resolve failed: %s", resp.Error)
+	}
+	result := resp.Data.([]model.ResolveResult)[0]
+	if result.Value != "Guardar" || result.ReplacedBy != current.Value || !strings.Contains(result.Warning, "deprecated") {
+		t.Errorf("Expected replacement value with a warning, got %+v", result)
+	}
+
+	resp = request(EndpointBundle, model.BundleRequest{Prefix: "pt-PT", IncludeDrafts: true})
+	if b := resp.Data.(model.Bundle); b.Values[old.Value] != "Guardar" || b.Deprecated[old.Value] != current.Value {
+		t.Errorf("Expected bundle to serve the replacement and list the deprecation, got %+v", b)
+	}
+}
+
+func TestTrash_RestoreAndPurge(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+	inTrash := func(subject, id string) bool {
+		respMsg, err := natsClientConn.Request(subject, nil, time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		for _, k := range resp.Data.([]model.LanguageKey) {
+			if k.Uuid == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: "trash." + uuid.NewString()}).Data.(model.LanguageKey)
+	if resp := request(EndpointLanguageKeyDelete, model.LanguageKey{Uuid: key.Uuid}); resp.Status != 200 || resp.Data.(model.LanguageKey).Deleted.IsZero() {
+		t.Fatalf("delete failed: %d | %s", resp.Status, resp.Error)
+	}
+	if resp := request(EndpointLanguageKeyGet, model.LanguageKey{Uuid: key.Uuid}); resp.Status == 200 {
+		t.Error("Expected deleted key to be hidden")
+	}
+	if !inTrash(EndpointLanguageKeyTrash, key.Uuid) {
+		t.Error("Expected deleted key in the trash")
+	}
+
+	resp := request(EndpointLanguageKeyRestore, model.LanguageKey{Uuid: key.Uuid})
+	if resp.Status != 200 || resp.Data.(model.LanguageKey).Value != key.Value {
+		t.Fatalf("restore failed: %d | %s", resp.Status, resp.Error)
+	}
+	if resp = request(EndpointLanguageKeyGet, model.LanguageKey{Uuid: key.Uuid}); resp.Status != 200 {
+		t.Errorf("Expected restored key to be found: %s", resp.Error)
+	}
+
+	request(EndpointLanguageKeyDelete, model.LanguageKey{Uuid: key.Uuid})
+	purgeTrash(time.Now())
+	if !inTrash(EndpointLanguageKeyTrash, key.Uuid) {
+		t.Error("Expected key deleted within the retention period to stay in the trash")
+	}
+	purgeTrash(time.Now().Add(settings.TrashRetention + time.Minute))
+	if inTrash(EndpointLanguageKeyTrash, key.Uuid) {
+		t.Error("Expected key to be purged after the retention period")
+	}
+	purged := auditStore.Query(model.AuditQuery{EntityUuid: key.Uuid})
+	if last := purged[len(purged)-1]; last.Operation != model.OperationPurge {
+		t.Errorf("Expected purge to be audited, got %+v", last)
+	}
+}
+
+func TestI18next_ImportAndExport(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	root := "i18n" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	lang := request(EndpointLanguageInsert, model.Language{Prefix: "da-DK", Lang: "Danish"}).Data.(model.Language)
+	key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".title"}).Data.(model.LanguageKey)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: key.Uuid, Value: "Titel"})
+	leave := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".leave"}).Data.(model.LanguageKey)
+	approved := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: lang.Uuid, UuidLanguageKey: leave.Uuid, Value: "Don't go"}).Data.(model.LanguageValue)
+	approved = request(EndpointLanguageValueSubmit, model.LanguageValueTransition{Uuid: approved.Uuid}).Data.(model.LanguageValue)
+	approved = request(EndpointLanguageValueApprove, model.LanguageValueTransition{Uuid: approved.Uuid}).Data.(model.LanguageValue)
+
+	file := []byte(`{"` + root + `": {
+		"title": "Overskrift",
+		"files_one": "{{count}} fil",
+		"files_other": "{{count}} filer"
+	}}`)
+
+	resp := request(EndpointI18nextImport, model.ImportRequest{Prefix: "da-DK", Data: file, DryRun: true})
+	if resp.Status != 200 {
+		t.Fatalf("dry run failed: %s", resp.Error)
+	}
+	want := []model.ImportChange{
+		{Key: root + ".files", Operation: model.OperationInsert, Current: "{count, plural, one {# fil} other {# filer}}"},
+		{Key: root + ".title", Operation: model.OperationUpdate, Previous: "Titel", Current: "Overskrift"},
+	}
+	dry := resp.Data.(model.ImportResult)
+	if dry.Applied || len(dry.Changes) != 2 || dry.Changes[0] != want[0] || dry.Changes[1] != want[1] {
+		t.Errorf("Unexpected dry run result: %+v", dry)
+	}
+	if resp = request(EndpointLanguageKeyGetByValue, model.LanguageKey{Value: root + ".files"}); resp.Status == 200 {
+		t.Error("Expected dry run to leave the stores alone")
+	}
+
+	resp = request(EndpointI18nextImport, model.ImportRequest{Prefix: "da-DK", Data: file})
+	if resp.Status != 200 || !resp.Data.(model.ImportResult).Applied {
+		t.Fatalf("import failed: %d | %s", resp.Status, resp.Error)
+	}
+	if resp = request(EndpointI18nextImport, model.ImportRequest{Prefix: "da-DK", Data: file, DryRun: true}); len(resp.Data.(model.ImportResult).Changes) != 0 {
+		t.Errorf("Expected no changes after importing the same file, got %+v", resp.Data)
+	}
+
+	resp = request(EndpointI18nextExport, model.ExportRequest{Prefix: "da-DK", IncludeDrafts: true})
+	if resp.Status != 200 {
+		t.Fatalf("export failed: %s", resp.Error)
+	}
+	files := resp.Data.([]model.ExportFile)
+	if len(files) != 1 || files[0].Path != "da-DK/translation.json" {
+		t.Fatalf("Unexpected export files: %+v", files)
+	}
+	for _, s := range []string{`"title": "Overskrift"`, `"files_one": "{{count}} fil"`, `"files_other": "{{count}} filer"`, `"leave": "Don't go"`} {
+		if !strings.Contains(string(files[0].Data), s) {
+			t.Errorf("Expected export to contain %s, got\n%s", s, files[0].Data)
+		}
+	}
+	resp = request(EndpointI18nextImport, model.ImportRequest{Prefix: "da-DK", Data: files[0].Data})
+	if resp.Status != 200 || len(resp.Data.(model.ImportResult).Changes) != 0 {
+		t.Errorf("Expected the unchanged export to import without changes, got %d | %s | %+v", resp.Status, resp.Error, resp.Data)
+	}
+	if got := request(EndpointLanguageValueGet, approved).Data.(model.LanguageValue); got.Value != "Don't go" || got.Status != model.ValueStatusApproved {
+		t.Errorf("Expected the approved value to be left alone, got %+v", got)
+	}
+
+	incomplete := []byte(`{"` + root + `": {"pages_other": "{{count}} sider"}}`)
+	if resp = request(EndpointI18nextImport, model.ImportRequest{Prefix: "da-DK", Data: incomplete}); resp.Status == 200 || !strings.Contains(resp.Error, "missing one") {
+		t.Errorf("Expected import with missing plural forms to fail, got %d | %s", resp.Status, resp.Error)
+	}
+}
+
+func TestGettext_ExportAndImport(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	previous := settings
+	settings.SourceLanguage = "en-IE"
+	defer func() {
+		settings = previous
+		refreshSourceLanguage()
+	}()
+
+	root := "po" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	source := request(EndpointLanguageInsert, model.Language{Prefix: "en-IE", Lang: "English"}).Data.(model.Language)
+	target := request(EndpointLanguageInsert, model.Language{Prefix: "pl-PL", Lang: "Polish"}).Data.(model.Language)
+	title := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".title", Context: "heading", Description: "Title of the page", SourceFile: "page.php:12"}).Data.(model.LanguageKey)
+	files := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".files"}).Data.(model.LanguageKey)
+	sourceTitle := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: title.Uuid, Value: "Title"}).Data.(model.LanguageValue)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: files.Uuid, Value: "{n, plural, one {# file} other {# files}}"})
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: title.Uuid, Value: "Tytuł"})
+
+	respMsg, err := natsClientConn.Request(EndpointGettextTemplate, nil, time.Second)
+	if err != nil {
+		t.Fatalf("template request failed: %v", err)
+	}
+	model.SetBytes(respMsg.Data)
+	var resp util.NatsResponse
+	if err := model.Decode(&resp); err != nil || resp.Status != 200 {
+		t.Fatalf("template failed: %v | %s", err, resp.Error)
+	}
+	pot := resp.Data.(model.ExportFile)
+	for _, s := range []string{"#. Title of the page\n#: page.php:12\nmsgctxt \"heading\"\nmsgid \"Title\"\nmsgstr \"\"", "msgid \"# file\"\nmsgid_plural \"# files\"\nmsgstr[0] \"\"\nmsgstr[1] \"\""} {
+		if pot.Path != "messages.pot" || !strings.Contains(string(pot.Data), s) {
+			t.Errorf("Expected %s to contain %q, got\n%s", pot.Path, s, pot.Data)
+		}
+	}
+
+	sourceTitle.Value = "Page title"
+	request(EndpointLanguageValueUpdate, sourceTitle)
+	resp = request(EndpointGettextExport, model.ExportRequest{Prefix: "pl-PL", IncludeDrafts: true})
+	if resp.Status != 200 {
+		t.Fatalf("export failed: %s", resp.Error)
+	}
+	po := resp.Data.([]model.ExportFile)
+	if len(po) != 1 || po[0].Path != "pl_PL/LC_MESSAGES/messages.po" {
+		t.Fatalf("Unexpected export files: %+v", po)
+	}
+	for _, s := range []string{"\"Language: pl_PL\\n\"", "nplurals=3;", "#, fuzzy\nmsgctxt \"heading\"\nmsgid \"Page title\"\nmsgstr \"Tytuł\"", "msgstr[2] \"\""} {
+		if !strings.Contains(string(po[0].Data), s) {
+			t.Errorf("Expected export to contain %q, got\n%s", s, po[0].Data)
+		}
+	}
+
+	file := []byte(`msgid ""
+msgstr "Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"
+
+msgctxt "heading"
+msgid "Page title"
+msgstr "Tytuł strony"
+
+msgid "# file"
+msgid_plural "# files"
+msgstr[0] "# plik"
+msgstr[1] "# pliki"
+msgstr[2] "# plików"
+
+#, fuzzy
+msgid "Unknown"
+msgstr "Nieznany"
+`)
+	resp = request(EndpointGettextImport, model.ImportRequest{Prefix: "pl-PL", Data: file})
+	if resp.Status != 200 {
+		t.Fatalf("import failed: %s", resp.Error)
+	}
+	result := resp.Data.(model.ImportResult)
+	want := []model.ImportChange{
+		{Key: root + ".files", Operation: model.OperationInsert, Current: "{n, plural, one {# plik} few {# pliki} many {# plików} other {# plików}}"},
+		{Key: root + ".title", Operation: model.OperationUpdate, Previous: "Tytuł", Current: "Tytuł strony"},
+	}
+	if !result.Applied || len(result.Changes) != 2 || result.Changes[0] != want[0] || result.Changes[1] != want[1] {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "Unknown" {
+		t.Errorf("Expected the unknown entry to be skipped, got %v", result.Skipped)
+	}
+
+	backups := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".backups"}).Data.(model.LanguageKey)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: backups.Uuid, Value: "{n, plural, one {# backup} other {# backups}}"})
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: backups.Uuid, Value: "{n, plural, one {# kopia ''zip'' gotowa} few {# kopie ''zip'' gotowe} many {# kopii ''zip'' gotowych} other {# kopii ''zip'' gotowych}}"})
+	po = request(EndpointGettextExport, model.ExportRequest{Prefix: "pl-PL", IncludeDrafts: true}).Data.([]model.ExportFile)
+	if s := "msgstr[0] \"# kopia 'zip' gotowa\""; !strings.Contains(string(po[0].Data), s) {
+		t.Errorf("Expected export to contain %q, got\n%s", s, po[0].Data)
+	}
+	resp = request(EndpointGettextImport, model.ImportRequest{Prefix: "pl-PL", Data: po[0].Data})
+	if resp.Status != 200 || len(resp.Data.(model.ImportResult).Changes) != 0 {
+		t.Errorf("Expected the unchanged export to import without changes, got %d | %s | %+v", resp.Status, resp.Error, resp.Data)
+	}
+
+	wrong := []byte("msgid \"\"\nmsgstr \"Plural-Forms: nplurals=2; plural=(n != 1);\\n\"\n\nmsgctxt \"heading\"\nmsgid \"Page title\"\nmsgstr \"Tytuł strony\"\n")
+	if resp = request(EndpointGettextImport, model.ImportRequest{Prefix: "pl-PL", Data: wrong}); resp.Status != 200 {
+		t.Errorf("Expected the plural forms of a file without plural entries not to matter, got %d | %s", resp.Status, resp.Error)
+	}
+	wrong = append(wrong, "\nmsgid \"# file\"\nmsgid_plural \"# files\"\nmsgstr[0] \"# plik\"\nmsgstr[1] \"# pliki\"\n"...)
+	if resp = request(EndpointGettextImport, model.ImportRequest{Prefix: "pl-PL", Data: wrong}); resp.Status == 200 || !strings.Contains(resp.Error, "2 plural forms") {
+		t.Errorf("Expected import with the wrong number of plural forms to fail, got %d | %s", resp.Status, resp.Error)
+	}
+}
+
+func TestXliff_ExportAndImport(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	root := "xlf" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	source := request(EndpointLanguageInsert, model.Language{Prefix: "en-AU", Lang: "English"}).Data.(model.Language)
+	target := request(EndpointLanguageInsert, model.Language{Prefix: "es-ES", Lang: "Spanish"}).Data.(model.Language)
+	keys := make(map[string]model.LanguageKey)
+	for _, name := range []string{"title", "body", "footer", "legacy"} {
+		keys[name] = request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + "." + name, Description: "The " + name, Deprecated: name == "legacy"}).Data.(model.LanguageKey)
+		request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: keys[name].Uuid, Value: "Source " + name})
+	}
+	title := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: keys["title"].Uuid, Value: "Título"}).Data.(model.LanguageValue)
+	body := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: keys["body"].Uuid, Value: "Cuerpo"}).Data.(model.LanguageValue)
+
+	resp := request(EndpointXliffExport, model.XliffExportRequest{Source: "en-AU", Target: "es-ES", Version: "2.0"})
+	if resp.Status != 200 {
+		t.Fatalf("export failed: %s", resp.Error)
+	}
+	exported := resp.Data.(model.ExportFile)
+	doc, err := xliff.Parse(exported.Data)
+	if err != nil {
+		t.Fatalf("exported file does not parse: %v\n%s", err, exported.Data)
+	}
+	if exported.Path != "en-AU_es-ES.xlf" || doc.Version != "2.0" || len(doc.Units) != 3 {
+		t.Fatalf("Unexpected export %s:\n%s", exported.Path, exported.Data)
+	}
+	if u := doc.Units[2]; u.ID != root+".title" || u.Target != "Título" || u.State != xliff.StateTranslated || u.Revision != title.Revision || u.Notes[0].Text != "The title" {
+		t.Errorf("Unexpected unit %+v", u)
+	}
+
+	// The vendor translates every unit, while the body is edited here in the meantime.
+	for i := range doc.Units {
+		doc.Units[i].Target = "Nuevo " + doc.Units[i].Source
+		doc.Units[i].State = xliff.StateFinal
+	}
+	body.Value = "Cuerpo editado"
+	request(EndpointLanguageValueUpdate, body)
+	doc.Version = "1.2"
+	data, err := xliff.Write(doc)
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	resp = request(EndpointXliffImport, model.ImportRequest{Data: data})
+	if resp.Status != 200 {
+		t.Fatalf("import failed: %s", resp.Error)
+	}
+	result := resp.Data.(model.ImportResult)
+	want := []model.ImportChange{
+		{Key: root + ".footer", Operation: model.OperationInsert, Current: "Nuevo Source footer"},
+		{Key: root + ".title", Operation: model.OperationUpdate, Previous: "Título", Current: "Nuevo Source title"},
+	}
+	if !result.Applied || len(result.Changes) != 2 || result.Changes[0] != want[0] || result.Changes[1] != want[1] {
+		t.Errorf("Unexpected changes: %+v", result.Changes)
+	}
+	conflict := model.ImportConflict{Key: root + ".body", Reason: "target changed since export", Current: "Cuerpo editado", Imported: "Nuevo Source body"}
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != conflict {
+		t.Errorf("Unexpected conflicts: %+v", result.Conflicts)
+	}
+
+	if resp = request(EndpointXliffImport, model.ImportRequest{Prefix: "en-AU", Data: data}); resp.Status == 200 || !strings.Contains(resp.Error, "translates to") {
+		t.Errorf("Expected import into another language to fail, got %d | %s", resp.Status, resp.Error)
+	}
+}
+
+func TestXliff_ImportConfirmsReview(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	previous := settings
+	settings.SourceLanguage = "en-SG"
+	defer func() {
+		settings = previous
+		refreshSourceLanguage()
+	}()
+
+	root := "rev" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	source := request(EndpointLanguageInsert, model.Language{Prefix: "en-SG", Lang: "English"}).Data.(model.Language)
+	target := request(EndpointLanguageInsert, model.Language{Prefix: "es-MX", Lang: "Spanish"}).Data.(model.Language)
+	key := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".leave"}).Data.(model.LanguageKey)
+	original := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: key.Uuid, Value: "Leave"}).Data.(model.LanguageValue)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: key.Uuid, Value: "No te vayas"})
+	original.Value = "Leave now"
+	request(EndpointLanguageValueUpdate, original)
+
+	resp := request(EndpointXliffExport, model.XliffExportRequest{Source: "en-SG", Target: "es-MX", Version: "2.0"})
+	if resp.Status != 200 {
+		t.Fatalf("export failed: %s", resp.Error)
+	}
+	doc, err := xliff.Parse(resp.Data.(model.ExportFile).Data)
+	if err != nil || len(doc.Units) != 1 || doc.Units[0].State != xliff.StateInitial {
+		t.Fatalf("Expected one unit needing review, got %+v (%v)", doc.Units, err)
+	}
+
+	// The vendor confirms the stored translation without changing it.
+	doc.Units[0].State = xliff.StateFinal
+	data, err := xliff.Write(doc)
+	if err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	resp = request(EndpointXliffImport, model.ImportRequest{Data: data})
+	if resp.Status != 200 {
+		t.Fatalf("import failed: %s", resp.Error)
+	}
+	result := resp.Data.(model.ImportResult)
+	if len(result.Changes) != 1 || len(result.Conflicts) != 0 {
+		t.Errorf("Expected the confirmation as the only change, got %+v", result)
+	}
+	stored := request(EndpointLanguageValueQuery, model.LanguageValueQuery{UuidLanguage: target.Uuid, UuidLanguageKey: key.Uuid}).Data.([]model.LanguageValue)
+	if len(stored) != 1 || stored[0].Value != "No te vayas" || stored[0].NeedsReview {
+		t.Errorf("Expected the confirmed translation to no longer need review, got %+v", stored)
+	}
+
+	resp = request(EndpointXliffImport, model.ImportRequest{Data: data})
+	if result = resp.Data.(model.ImportResult); len(result.Changes) != 0 || len(result.Conflicts) != 0 {
+		t.Errorf("Expected a second import of the same file to change nothing, got %+v", result)
+	}
+}
+
+func TestMobile_ExportAndImport(t *testing.T) {
+	request := func(subject string, payload any) util.NatsResponse {
+		model.BufferReset()
+		if err := model.Encode(payload); err != nil {
+			t.Fatalf("encode %s failed: %v", subject, err)
+		}
+		respMsg, err := natsClientConn.Request(subject, model.GetBytes(), time.Second)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", subject, err)
+		}
+		model.SetBytes(respMsg.Data)
+		var resp util.NatsResponse
+		if err := model.Decode(&resp); err != nil {
+			t.Fatalf("decode %s failed: %v", subject, err)
+		}
+		return resp
+	}
+
+	previous := settings
+	settings.SourceLanguage = "en-ZA"
+	defer func() {
+		settings = previous
+		refreshSourceLanguage()
+	}()
+
+	root := "app" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	source := request(EndpointLanguageInsert, model.Language{Prefix: "en-ZA", Lang: "English"}).Data.(model.Language)
+	target := request(EndpointLanguageInsert, model.Language{Prefix: "sk-SK", Lang: "Slovak"}).Data.(model.Language)
+	greet := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".greet"}).Data.(model.LanguageKey)
+	files := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".files"}).Data.(model.LanguageKey)
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: greet.Uuid, Value: "Hello {name}, it's {day}"})
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: files.Uuid, Value: "{n, plural, one {# file} other {# files}}"})
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: target.Uuid, UuidLanguageKey: greet.Uuid, Value: "Je {day}, {name}"})
+
+	resp := request(EndpointAndroidExport, model.ExportRequest{IncludeDrafts: true})
+	if resp.Status != 200 {
+		t.Fatalf("android export failed: %s", resp.Error)
+	}
+	exported := make(map[string]string)
+	for _, f := range resp.Data.([]model.ExportFile) {
+		exported[f.Path] = string(f.Data)
+	}
+	if s := exported["values/strings.xml"]; !strings.Contains(s, `<string name="`+root+`.greet">Hello %1$s, it\'s %2$s</string>`) {
+		t.Errorf("Unexpected source strings.xml:\n%s", s)
+	}
+	if s := exported["values-sk-rSK/strings.xml"]; !strings.Contains(s, `<string name="`+root+`.greet">Je %2$s, %1$s</string>`) {
+		t.Errorf("Unexpected target strings.xml:\n%s", s)
+	}
+
+	android := []byte(`<resources>
+    <plurals name="` + root + `.files">
+        <item quantity="one">%1$d súbor</item>
+        <item quantity="few">%1$d súbory</item>
+        <item quantity="many">%1$d súboru</item>
+        <item quantity="other">%1$d súborov</item>
+    </plurals>
+</resources>`)
+	resp = request(EndpointAndroidImport, model.ImportRequest{Prefix: "sk-SK", Data: android})
+	if resp.Status != 200 {
+		t.Fatalf("android import failed: %s", resp.Error)
+	}
+	change := model.ImportChange{Key: root + ".files", Operation: model.OperationInsert, Current: "{n, plural, one {# súbor} few {# súbory} many {# súboru} other {# súborov}}"}
+	if result := resp.Data.(model.ImportResult); !result.Applied || len(result.Changes) != 1 || result.Changes[0] != change {
+		t.Errorf("Unexpected android import result: %+v", result)
+	}
+
+	resp = request(EndpointIOSExport, model.ExportRequest{Prefix: "sk-SK", IncludeDrafts: true})
+	if resp.Status != 200 {
+		t.Fatalf("ios export failed: %s", resp.Error)
+	}
+	ios := resp.Data.([]model.ExportFile)
+	if len(ios) != 2 || ios[0].Path != "sk-SK.lproj/Localizable.strings" || ios[1].Path != "sk-SK.lproj/Localizable.stringsdict" {
+		t.Fatalf("Unexpected ios export files: %+v", ios)
+	}
+	if s := string(ios[0].Data); !strings.Contains(s, `"`+root+`.greet" = "Je %2$@, %1$@";`) {
+		t.Errorf("Unexpected Localizable.strings:\n%s", s)
+	}
+	if s := string(ios[1].Data); !strings.Contains(s, "<string>%1$lld súbory</string>") {
+		t.Errorf("Unexpected Localizable.stringsdict:\n%s", s)
+	}
+
+	stringsFile := []byte(`"` + root + `.greet" = "Dnes je %2$@, %1$@";`)
+	resp = request(EndpointIOSImport, model.ImportRequest{Prefix: "sk-SK", Data: stringsFile, Plurals: ios[1].Data, DryRun: true})
+	if resp.Status != 200 {
+		t.Fatalf("ios import failed: %s", resp.Error)
+	}
+	change = model.ImportChange{Key: root + ".greet", Operation: model.OperationUpdate, Previous: "Je {day}, {name}", Current: "Dnes je {day}, {name}"}
+	if result := resp.Data.(model.ImportResult); result.Applied || len(result.Changes) != 1 || result.Changes[0] != change {
+		t.Errorf("Unexpected ios import result: %+v", result)
+	}
+
+	leave := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".leave"}).Data.(model.LanguageKey)
+	count := request(EndpointLanguageKeyInsert, model.LanguageKey{Value: root + ".count"}).Data.(model.LanguageKey)
+	approved := request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: leave.Uuid, Value: "Don't go"}).Data.(model.LanguageValue)
+	request(EndpointLanguageValueSubmit, model.LanguageValueTransition{Uuid: approved.Uuid})
+	request(EndpointLanguageValueApprove, model.LanguageValueTransition{Uuid: approved.Uuid})
+	request(EndpointLanguageValueInsert, model.LanguageValue{UuidLanguage: source.Uuid, UuidLanguageKey: count.Uuid, Value: "it's {n, number}"})
+
+	android = request(EndpointAndroidExport, model.ExportRequest{Prefix: "en-ZA", IncludeDrafts: true}).Data.([]model.ExportFile)[0].Data
+	resp = request(EndpointAndroidImport, model.ImportRequest{Prefix: "en-ZA", Data: android})
+	if resp.Status != 200 || len(resp.Data.(model.ImportResult).Changes) != 0 {
+		t.Errorf("Expected the unchanged strings.xml to import without changes, got %d | %s | %+v", resp.Status, resp.Error, resp.Data)
+	}
+	ios = request(EndpointIOSExport, model.ExportRequest{Prefix: "en-ZA", IncludeDrafts: true}).Data.([]model.ExportFile)
+	resp = request(EndpointIOSImport, model.ImportRequest{Prefix: "en-ZA", Data: ios[0].Data, Plurals: ios[1].Data})
+	if resp.Status != 200 || len(resp.Data.(model.ImportResult).Changes) != 0 {
+		t.Errorf("Expected the unchanged .strings files to import without changes, got %d | %s | %+v", resp.Status, resp.Error, resp.Data)
+	}
+	if got := request(EndpointLanguageValueGet, approved).Data.(model.LanguageValue); got.Value != "Don't go" || got.Status != model.ValueStatusApproved {
+		t.Errorf("Expected the approved value to be left alone, got %+v", got)
+	}
+}
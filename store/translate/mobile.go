@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rah-0/meisterwerk/mobile"
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// sourceTexts returns the source values by key string, in any workflow state. They decide the
+// placeholder positions of mobile resources, so every language passes arguments the same way.
+// It is empty when the source language does not exist.
+func sourceTexts(keys []model.LanguageKey) map[string]string {
+	out := make(map[string]string)
+	source, err := sourceLanguage()
+	if err != nil {
+		return out
+	}
+	byUuid := make(map[string]string, len(keys))
+	for _, k := range keys {
+		byUuid[k.Uuid] = k.Value
+	}
+	for _, v := range languageValueStore.Query(model.LanguageValueQuery{UuidLanguage: source.Uuid}) {
+		if name, ok := byUuid[v.UuidLanguageKey]; ok {
+			out[name] = v.Value
+		}
+	}
+	return out
+}
+
+// androidDirectory returns the resource directory of a language: "values" for the source
+// language, which apps fall back to, and values-<qualifier> for the others, e.g.,
+// "values-de-rDE", or "values-b+sr+Latn+RS" for tags Android's short form cannot express.
+func androidDirectory(lang model.Language) string {
+	if lang.Prefix == settings.SourceLanguage {
+		return "values"
+	}
+	parts := strings.Split(lang.Prefix, "-")
+	switch {
+	case len(parts) == 1:
+		return "values-" + parts[0]
+	case len(parts) == 2 && len(parts[1]) == 2:
+		return "values-" + parts[0] + "-r" + strings.ToUpper(parts[1])
+	}
+	return "values-b+" + strings.Join(parts, "+")
+}
+
+// androidExport writes one strings.xml per language, holding the values a bundle of that
+// language would serve.
+func androidExport(req model.ExportRequest) ([]model.ExportFile, error) {
+	langs, err := exportLanguages(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := languageKeyStore.List()
+	sources := sourceTexts(keys)
+
+	files := make([]model.ExportFile, 0, len(langs))
+	for _, lang := range langs {
+		values := languageValueStore.Query(model.LanguageValueQuery{UuidLanguage: lang.Uuid})
+		data, err := mobile.ExportAndroid(buildBundle(lang, keys, values, req.IncludeDrafts).Values, sources)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, model.ExportFile{Path: androidDirectory(lang) + "/strings.xml", Data: data})
+	}
+	return files, nil
+}
+
+// androidImport reads a strings.xml into the language with req.Prefix. Resource names are
+// mapped back to the key strings they were exported from; others are taken as key strings.
+func androidImport(req model.ImportRequest) (model.ImportResult, []batchChange, error) {
+	lang, err := languageStore.GetByPrefix(req.Prefix)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	keys := languageKeyStore.List()
+	byName := make(map[string]string, len(keys))
+	for _, k := range keys {
+		byName[mobile.ResourceName(k.Value)] = k.Value
+	}
+	sources := make(map[string]string)
+	for key, text := range sourceTexts(keys) {
+		sources[mobile.ResourceName(key)] = text
+	}
+
+	resources, err := mobile.ImportAndroid(req.Data, sources)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	texts := make(map[string]string, len(resources))
+	for name, text := range resources {
+		if key, ok := byName[name]; ok {
+			name = key
+		}
+		texts[name] = text
+	}
+	return importTexts(lang, texts, nil, req.DryRun)
+}
+
+// iosExport writes a Localizable.strings and a Localizable.stringsdict per language into its
+// .lproj directory, holding the values a bundle of that language would serve.
+func iosExport(req model.ExportRequest) ([]model.ExportFile, error) {
+	langs, err := exportLanguages(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := languageKeyStore.List()
+	sources := sourceTexts(keys)
+
+	files := make([]model.ExportFile, 0, 2*len(langs))
+	for _, lang := range langs {
+		values := languageValueStore.Query(model.LanguageValueQuery{UuidLanguage: lang.Uuid})
+		stringsFile, stringsdict := mobile.ExportIOS(buildBundle(lang, keys, values, req.IncludeDrafts).Values, sources)
+		dir := lang.Prefix + ".lproj/"
+		files = append(files,
+			model.ExportFile{Path: dir + "Localizable.strings", Data: stringsFile},
+			model.ExportFile{Path: dir + "Localizable.stringsdict", Data: stringsdict},
+		)
+	}
+	return files, nil
+}
+
+// iosImport reads a Localizable.strings, with its .stringsdict in req.Plurals, into the language
+// with req.Prefix.
+func iosImport(req model.ImportRequest) (model.ImportResult, []batchChange, error) {
+	lang, err := languageStore.GetByPrefix(req.Prefix)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	texts, err := mobile.ImportIOS(req.Data, req.Plurals, sourceTexts(languageKeyStore.List()))
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	return importTexts(lang, texts, nil, req.DryRun)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// namespaceNode is one segment of the dotted key paths held by a LanguageKeyStore. A node
+// can be a key and a namespace at the same time, e.g., "checkout" and "checkout.pay".
+type namespaceNode struct {
+	children map[string]*namespaceNode
+	uuid     string // Key whose Value ends at this node, empty for a namespace only
+	size     int    // Keys at or below this node
+}
+
+func newNamespaceNode() *namespaceNode {
+	return &namespaceNode{children: make(map[string]*namespaceNode)}
+}
+
+// splitNamespace returns the segments of a dotted path, none for the root.
+func splitNamespace(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// inNamespace reports whether the key value lies at or below prefix.
+func inNamespace(value, prefix string) bool {
+	return prefix == "" || value == prefix || strings.HasPrefix(value, prefix+".")
+}
+
+func (n *namespaceNode) add(value, uuid string) {
+	n.size++
+	for _, segment := range strings.Split(value, ".") {
+		child, ok := n.children[segment]
+		if !ok {
+			child = newNamespaceNode()
+			n.children[segment] = child
+		}
+		child.size++
+		n = child
+	}
+	n.uuid = uuid
+}
+
+// remove drops the key value from the tree, pruning namespaces left without keys.
+func (n *namespaceNode) remove(value string) {
+	path := []*namespaceNode{n}
+	segments := strings.Split(value, ".")
+	for _, segment := range segments {
+		child, ok := n.children[segment]
+		if !ok {
+			return
+		}
+		path = append(path, child)
+		n = child
+	}
+	if n.uuid == "" {
+		return
+	}
+	n.uuid = ""
+
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].size--
+		if i > 0 && path[i].size == 0 {
+			delete(path[i-1].children, segments[i-1])
+		}
+	}
+}
+
+// find returns the node at prefix, or nil when no key lies at or below it.
+func (n *namespaceNode) find(prefix string) *namespaceNode {
+	for _, segment := range splitNamespace(prefix) {
+		child, ok := n.children[segment]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
+// collect appends the uuids of every key at or below n.
+func (n *namespaceNode) collect(out []string) []string {
+	if n.uuid != "" {
+		out = append(out, n.uuid)
+	}
+	for _, child := range n.children {
+		out = child.collect(out)
+	}
+	return out
+}
+
+// namespaces lists the direct child namespaces of n, sorted by path.
+func (n *namespaceNode) namespaces(prefix string) []model.Namespace {
+	out := make([]model.Namespace, 0, len(n.children))
+	for segment, child := range n.children {
+		path := segment
+		if prefix != "" {
+			path = prefix + "." + segment
+		}
+		out = append(out, model.Namespace{Path: path, Keys: child.size})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
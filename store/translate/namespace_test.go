@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestLanguageKeyStore_Namespaces(t *testing.T) {
+	store := NewLanguageKeyStore()
+	for _, v := range []string{
+		"checkout",
+		"checkout.payment.error.declined",
+		"checkout.payment.error.expired",
+		"checkout.payment.title",
+		"checkout.paymentx",
+		"home.title",
+	} {
+		if _, err := store.Insert(model.LanguageKey{Value: v}); err != nil {
+			t.Fatalf("Insert %s failed: %v", v, err)
+		}
+	}
+
+	if n := store.CountNamespace("checkout.payment"); n != 3 {
+		t.Errorf("Expected 3 keys in checkout.payment, got %d", n)
+	}
+	if n := store.CountNamespace("checkout"); n != 5 {
+		t.Errorf("Expected key and namespace checkout to count 5, got %d", n)
+	}
+	if n := store.CountNamespace(""); n != 6 {
+		t.Errorf("Expected 6 keys at the root, got %d", n)
+	}
+	if n := store.CountNamespace("checkout.pay"); n != 0 {
+		t.Errorf("Expected partial segment to match nothing, got %d", n)
+	}
+
+	keys := store.ListNamespace("checkout.payment.error")
+	if len(keys) != 2 || keys[0].Value != "checkout.payment.error.declined" || keys[1].Value != "checkout.payment.error.expired" {
+		t.Errorf("Unexpected keys: %+v", keys)
+	}
+
+	want := []model.Namespace{{Path: "checkout.payment", Keys: 3}, {Path: "checkout.paymentx", Keys: 1}}
+	got := store.Namespaces("checkout")
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Namespaces(checkout) = %+v, want %+v", got, want)
+	}
+
+	k, _ := store.GetByValue("home.title")
+	if _, err := store.Delete(k.Uuid, 0); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got := store.Namespaces(""); len(got) != 1 || got[0].Path != "checkout" {
+		t.Errorf("Expected empty namespace home to be pruned, got %+v", got)
+	}
+}
+
+func TestLanguageKeyStore_RenameNamespace(t *testing.T) {
+	store := NewLanguageKeyStore()
+	ok, _ := store.Insert(model.LanguageKey{Value: "btn.ok"})
+	store.Insert(model.LanguageKey{Value: "btn.cancel"})
+	store.Insert(model.LanguageKey{Value: "common.actions.cancel"})
+
+	if _, _, err := store.RenameNamespace("btn", "common.actions", time.Time{}); err == nil {
+		t.Fatal("Expected rename onto an existing key to fail")
+	}
+	if n := store.CountNamespace("btn"); n != 2 {
+		t.Errorf("Expected failed rename to leave btn alone, got %d keys", n)
+	}
+
+	cancel, _ := store.GetByValue("common.actions.cancel")
+	store.Delete(cancel.Uuid, 0)
+
+	previous, renamed, err := store.RenameNamespace("btn", "common.actions", time.Time{})
+	if err != nil {
+		t.Fatalf("RenameNamespace failed: %v", err)
+	}
+	if len(renamed) != 2 || previous[1].Value != "btn.ok" || renamed[1].Value != "common.actions.ok" || renamed[1].Uuid != ok.Uuid || renamed[1].Revision != 2 {
+		t.Errorf("Unexpected rename result: %+v -> %+v", previous, renamed)
+	}
+	if _, err := store.GetByValue("btn.ok"); err == nil {
+		t.Error("Expected old key value to be gone")
+	}
+	if n := store.CountNamespace("common.actions"); n != 2 {
+		t.Errorf("Expected 2 keys in common.actions, got %d", n)
+	}
+	if _, _, err := store.RenameNamespace("missing", "other", time.Time{}); err == nil {
+		t.Error("Expected renaming an unknown namespace to fail")
+	}
+}
+
+func TestLanguageKeyStore_DeleteNamespace(t *testing.T) {
+	store := NewLanguageKeyStore()
+	store.Insert(model.LanguageKey{Value: "legacy.a"})
+	store.Insert(model.LanguageKey{Value: "legacy.b.c"})
+	store.Insert(model.LanguageKey{Value: "legacyx"})
+
+	if _, err := store.DeleteNamespace(""); err == nil {
+		t.Error("Expected deleting the root to be refused")
+	}
+	deleted, err := store.DeleteNamespace("legacy")
+	if err != nil || len(deleted) != 2 {
+		t.Fatalf("Expected 2 deleted keys, got %+v (%v)", deleted, err)
+	}
+	if keys := store.List(); len(keys) != 1 || keys[0].Value != "legacyx" {
+		t.Errorf("Expected only legacyx to remain, got %+v", keys)
+	}
+}
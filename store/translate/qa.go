@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/qa"
+)
+
+// qaReport checks every value matching q against the value of the same key in the source
+// language. Source values themselves, and values whose key has no source value, are skipped.
+func qaReport(values []model.LanguageValue, sourceUuid string, q model.QAQuery) []model.QAIssue {
+	sources := make(map[string]model.LanguageValue)
+	for _, v := range values {
+		if v.UuidLanguage == sourceUuid {
+			sources[v.UuidLanguageKey] = v
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].UuidLanguageKey != values[j].UuidLanguageKey {
+			return values[i].UuidLanguageKey < values[j].UuidLanguageKey
+		}
+		return values[i].UuidLanguage < values[j].UuidLanguage
+	})
+
+	issues := make([]model.QAIssue, 0)
+	for _, v := range values {
+		if v.UuidLanguage == sourceUuid {
+			continue
+		}
+		if q.UuidLanguage != "" && v.UuidLanguage != q.UuidLanguage {
+			continue
+		}
+		if q.UuidLanguageKey != "" && v.UuidLanguageKey != q.UuidLanguageKey {
+			continue
+		}
+		source, ok := sources[v.UuidLanguageKey]
+		if !ok {
+			continue
+		}
+
+		for _, issue := range qa.Check(source.Value, v.Value) {
+			issues = append(issues, model.QAIssue{
+				UuidLanguageValue: v.Uuid,
+				UuidLanguage:      v.UuidLanguage,
+				UuidLanguageKey:   v.UuidLanguageKey,
+				Check:             issue.Check,
+				Message:           issue.Message,
+			})
+		}
+	}
+	return issues
+}
+
+// checkPlaceholders rejects a translation whose placeholders differ from its source value, when
+// enforcement is enabled. Source values, and translations of keys without one, always pass.
+func checkPlaceholders(v model.LanguageValue) error {
+	if !settings.EnforcePlaceholders {
+		return nil
+	}
+	source, err := sourceLanguage()
+	if err != nil || v.UuidLanguage == source.Uuid {
+		return nil
+	}
+	sourceValue, err := languageValueStore.GetByLanguageKey(source.Uuid, v.UuidLanguageKey)
+	if err != nil {
+		return nil
+	}
+	return matchPlaceholders(sourceValue.Value, v.Value)
+}
+
+// matchPlaceholders rejects text whose placeholders differ from those of source.
+func matchPlaceholders(source, text string) error {
+	issues := qa.Placeholders(source, text)
+	if len(issues) == 0 {
+		return nil
+	}
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.Message
+	}
+	return fmt.Errorf("placeholders do not match the source: %s", strings.Join(messages, ", "))
+}
+
+// checkMaxLength rejects text longer than the MaxLength of its key.
+func checkMaxLength(text string, key model.LanguageKey) error {
+	if issues := qa.Length(text, key.MaxLength); len(issues) > 0 {
+		return fmt.Errorf("key %q: %s", key.Value, issues[0].Message)
+	}
+	return nil
+}
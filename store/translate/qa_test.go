@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestQAReport(t *testing.T) {
+	source, german, french := uuid.NewString(), uuid.NewString(), uuid.NewString()
+	greeting, farewell, orphan := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	values := []model.LanguageValue{
+		{Uuid: uuid.NewString(), UuidLanguage: source, UuidLanguageKey: greeting, Value: "Hello {name}"},
+		{Uuid: uuid.NewString(), UuidLanguage: source, UuidLanguageKey: farewell, Value: "Bye %s"},
+		{Uuid: uuid.NewString(), UuidLanguage: german, UuidLanguageKey: greeting, Value: "Hallo {Name}"},
+		{Uuid: uuid.NewString(), UuidLanguage: german, UuidLanguageKey: farewell, Value: "Tschüss %s"},
+		{Uuid: uuid.NewString(), UuidLanguage: french, UuidLanguageKey: farewell, Value: "Au revoir"},
+		{Uuid: uuid.NewString(), UuidLanguage: french, UuidLanguageKey: orphan, Value: "{missing} source"},
+	}
+
+	issues := qaReport(values, source, model.QAQuery{})
+	if len(issues) != 3 {
+		t.Fatalf("Expected 3 issues, got %+v", issues)
+	}
+
+	issues = qaReport(values, source, model.QAQuery{UuidLanguage: french})
+	if len(issues) != 1 || issues[0].Message != "missing placeholder %s" || issues[0].UuidLanguageKey != farewell {
+		t.Errorf("Unexpected French issues: %+v", issues)
+	}
+
+	issues = qaReport(values, source, model.QAQuery{UuidLanguageKey: greeting})
+	if len(issues) != 2 || issues[0].UuidLanguage != german {
+		t.Errorf("Unexpected greeting issues: %+v", issues)
+	}
+}
+
+func TestQAReport_Markup(t *testing.T) {
+	source, german, key := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	values := []model.LanguageValue{
+		{Uuid: uuid.NewString(), UuidLanguage: source, UuidLanguageKey: key, Value: "Read the <a href=\"/terms\">terms</a>"},
+		{Uuid: uuid.NewString(), UuidLanguage: german, UuidLanguageKey: key, Value: "Lesen Sie die <a href=\"/terms\">AGB"},
+	}
+
+	issues := qaReport(values, source, model.QAQuery{})
+	if len(issues) != 1 || issues[0].Check != "markup" || issues[0].Message != "unclosed tag <a>" {
+		t.Errorf("Unexpected issues: %+v", issues)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// coverageIndex answers which state each key is in for each language.
+type coverageIndex struct {
+	values map[string]map[string]model.LanguageValue // by language UUID, then key UUID
+}
+
+func newCoverageIndex(values []model.LanguageValue) coverageIndex {
+	idx := coverageIndex{values: make(map[string]map[string]model.LanguageValue)}
+	for _, v := range values {
+		byKey, ok := idx.values[v.UuidLanguage]
+		if !ok {
+			byKey = make(map[string]model.LanguageValue)
+			idx.values[v.UuidLanguage] = byKey
+		}
+		byKey[v.UuidLanguageKey] = v
+	}
+	return idx
+}
+
+// state classifies a key in a language. A value is stale while it needs review because its
+// source value changed.
+func (idx coverageIndex) state(languageUuid, keyUuid string) string {
+	v, ok := idx.values[languageUuid][keyUuid]
+	if !ok || v.Value == "" {
+		return model.CoverageMissing
+	}
+	if v.NeedsReview {
+		return model.CoverageStale
+	}
+	return model.CoverageTranslated
+}
+
+// languageCoverage counts the states of all keys in lang, listing the missing keys.
+func (idx coverageIndex) languageCoverage(lang model.Language, keys []model.LanguageKey) model.LanguageCoverage {
+	c := model.LanguageCoverage{UuidLanguage: lang.Uuid, Prefix: lang.Prefix, Total: len(keys), MissingKeys: []model.LanguageKey{}}
+	for _, k := range keys {
+		switch idx.state(lang.Uuid, k.Uuid) {
+		case model.CoverageTranslated:
+			c.Translated++
+		case model.CoverageStale:
+			c.Stale++
+		case model.CoverageMissing:
+			c.Missing++
+			c.MissingKeys = append(c.MissingKeys, k)
+		}
+	}
+
+	c.Percent = 100
+	if c.Total > 0 {
+		c.Percent = float64(c.Translated) / float64(c.Total) * 100
+	}
+	return c
+}
+
+// coverageReport returns the coverage of each language, sorted by Prefix.
+func coverageReport(langs []model.Language, keys []model.LanguageKey, values []model.LanguageValue) []model.LanguageCoverage {
+	idx := newCoverageIndex(values)
+	sortKeys(keys)
+	sortLanguages(langs)
+
+	out := make([]model.LanguageCoverage, len(langs))
+	for i, l := range langs {
+		out[i] = idx.languageCoverage(l, keys)
+	}
+	return out
+}
+
+// coverageMatrix returns per-language totals and the state of every key in every language.
+func coverageMatrix(langs []model.Language, keys []model.LanguageKey, values []model.LanguageValue) model.CoverageMatrix {
+	m := model.CoverageMatrix{Languages: coverageReport(langs, keys, values)}
+	for i := range m.Languages {
+		m.Languages[i].MissingKeys = nil
+	}
+
+	idx := newCoverageIndex(values)
+	m.Rows = make([]model.CoverageRow, len(keys))
+	for i, k := range keys {
+		row := model.CoverageRow{UuidLanguageKey: k.Uuid, Key: k.Value, States: make(map[string]string, len(langs))}
+		for _, l := range langs {
+			row.States[l.Uuid] = idx.state(l.Uuid, k.Uuid)
+		}
+		m.Rows[i] = row
+	}
+	return m
+}
+
+func sortKeys(keys []model.LanguageKey) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Value < keys[j].Value })
+}
+
+func sortLanguages(langs []model.Language) {
+	sort.Slice(langs, func(i, j int) bool { return langs[i].Prefix < langs[j].Prefix })
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestCoverageReport(t *testing.T) {
+	en := model.Language{Uuid: uuid.NewString(), Prefix: "en-US"}
+	ja := model.Language{Uuid: uuid.NewString(), Prefix: "ja-JP"}
+	hello := model.LanguageKey{Uuid: uuid.NewString(), Value: "hello"}
+	bye := model.LanguageKey{Uuid: uuid.NewString(), Value: "bye"}
+	thanks := model.LanguageKey{Uuid: uuid.NewString(), Value: "thanks"}
+
+	values := []model.LanguageValue{
+		{UuidLanguage: en.Uuid, UuidLanguageKey: hello.Uuid, Value: "Hello"},
+		{UuidLanguage: en.Uuid, UuidLanguageKey: bye.Uuid, Value: "Bye"},
+		{UuidLanguage: en.Uuid, UuidLanguageKey: thanks.Uuid, Value: "Thanks"},
+		{UuidLanguage: ja.Uuid, UuidLanguageKey: hello.Uuid, Value: "こんにちは", SourceRevision: 1},
+		{UuidLanguage: ja.Uuid, UuidLanguageKey: bye.Uuid, Value: "さようなら", SourceRevision: 1, NeedsReview: true},
+		{UuidLanguage: ja.Uuid, UuidLanguageKey: thanks.Uuid, Value: ""},
+	}
+
+	report := coverageReport([]model.Language{ja, en}, []model.LanguageKey{thanks, hello, bye}, values)
+	if len(report) != 2 || report[0].Prefix != "en-US" || report[1].Prefix != "ja-JP" {
+		t.Fatalf("Expected languages sorted by prefix, got %+v", report)
+	}
+
+	if c := report[0]; c.Total != 3 || c.Translated != 3 || c.Missing != 0 || c.Stale != 0 || c.Percent != 100 {
+		t.Errorf("Unexpected source coverage: %+v", c)
+	}
+	c := report[1]
+	if c.Total != 3 || c.Translated != 1 || c.Missing != 1 || c.Stale != 1 {
+		t.Errorf("Unexpected Japanese coverage: %+v", c)
+	}
+	if len(c.MissingKeys) != 1 || c.MissingKeys[0].Uuid != thanks.Uuid {
+		t.Errorf("Expected thanks to be missing, got %+v", c.MissingKeys)
+	}
+
+	if c := coverageReport([]model.Language{ja}, nil, nil)[0]; c.Total != 0 || c.Percent != 100 {
+		t.Errorf("Expected full coverage without keys, got %+v", c)
+	}
+}
+
+func TestCoverageMatrix(t *testing.T) {
+	en := model.Language{Uuid: uuid.NewString(), Prefix: "en-US"}
+	de := model.Language{Uuid: uuid.NewString(), Prefix: "de-DE"}
+	a := model.LanguageKey{Uuid: uuid.NewString(), Value: "a"}
+	b := model.LanguageKey{Uuid: uuid.NewString(), Value: "b"}
+
+	values := []model.LanguageValue{
+		{UuidLanguage: en.Uuid, UuidLanguageKey: a.Uuid, Value: "A"},
+		{UuidLanguage: en.Uuid, UuidLanguageKey: b.Uuid, Value: "B"},
+		{UuidLanguage: de.Uuid, UuidLanguageKey: b.Uuid, Value: "B"},
+	}
+
+	m := coverageMatrix([]model.Language{en, de}, []model.LanguageKey{b, a}, values)
+	if len(m.Languages) != 2 || m.Languages[0].Prefix != "de-DE" || m.Languages[0].MissingKeys != nil {
+		t.Errorf("Unexpected matrix languages: %+v", m.Languages)
+	}
+	if len(m.Rows) != 2 || m.Rows[0].Key != "a" || m.Rows[1].Key != "b" {
+		t.Fatalf("Expected rows sorted by key, got %+v", m.Rows)
+	}
+	if m.Rows[0].States[de.Uuid] != model.CoverageMissing || m.Rows[0].States[en.Uuid] != model.CoverageTranslated {
+		t.Errorf("Unexpected states for a: %v", m.Rows[0].States)
+	}
+	if m.Rows[1].States[de.Uuid] != model.CoverageTranslated {
+		t.Errorf("Unexpected states for b: %v", m.Rows[1].States)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// sourceLanguage returns the language translations are made from, as configured by Prefix.
+func sourceLanguage() (model.Language, error) {
+	l, err := languageStore.GetByPrefix(settings.SourceLanguage)
+	if err != nil {
+		return model.Language{}, fmt.Errorf("source language %q: %w", settings.SourceLanguage, err)
+	}
+	return l, nil
+}
+
+// sourceLanguageUuid returns the Uuid of the source language, or "" when it is not set up yet.
+func sourceLanguageUuid() string {
+	l, err := sourceLanguage()
+	if err != nil {
+		return ""
+	}
+	return l.Uuid
+}
+
+// sourceLanguageIn is sourceLanguageUuid for a store whose lock the caller already holds.
+func sourceLanguageIn(ls *LanguageStore) string {
+	matches := ls.findByPrefix(settings.SourceLanguage)
+	if len(matches) != 1 {
+		return ""
+	}
+	return matches[0].Uuid
+}
+
+// refreshSourceLanguage tells the value store which language to track translations against,
+// after languages may have changed.
+func refreshSourceLanguage() {
+	languageValueStore.SetSourceLanguage(sourceLanguageUuid())
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rah-0/meisterwerk/icu"
+	"github.com/rah-0/meisterwerk/model"
+	"github.com/rah-0/meisterwerk/xliff"
+)
+
+// xliffLanguage returns the language with the given Prefix, or the source language when it is
+// empty.
+func xliffLanguage(prefix string) (model.Language, error) {
+	if prefix == "" {
+		return sourceLanguage()
+	}
+	return languageStore.GetByPrefix(prefix)
+}
+
+// xliffState maps the workflow status of a translation to an XLIFF state. Translations whose
+// source changed have to be redone.
+func xliffState(v model.LanguageValue) string {
+	switch {
+	case v.NeedsReview:
+		return xliff.StateInitial
+	case v.Status == model.ValueStatusPublished:
+		return xliff.StateFinal
+	case v.Status == model.ValueStatusApproved:
+		return xliff.StateReviewed
+	}
+	return xliff.StateTranslated
+}
+
+// xliffNotes returns the key metadata and the unresolved comments of a unit as notes.
+func xliffNotes(k model.LanguageKey, target model.LanguageValue) []xliff.Note {
+	var notes []xliff.Note
+	if k.Description != "" {
+		notes = append(notes, xliff.Note{Category: "description", Text: k.Description})
+	}
+	if k.Context != "" {
+		notes = append(notes, xliff.Note{Category: "context", Text: k.Context})
+	}
+	if k.MaxLength > 0 {
+		notes = append(notes, xliff.Note{Category: "max-length", Text: strconv.Itoa(k.MaxLength)})
+	}
+	comments := commentStore.List(model.CommentQuery{Entity: model.EntityLanguageKey, EntityUuid: k.Uuid, Unresolved: true})
+	if target.Uuid != "" {
+		comments = append(comments, commentStore.List(model.CommentQuery{Entity: model.EntityLanguageValue, EntityUuid: target.Uuid, Unresolved: true})...)
+	}
+	for _, c := range comments {
+		notes = append(notes, xliff.Note{Category: "comment", Text: c.Author + ": " + c.Text})
+	}
+	return notes
+}
+
+// xliffExport writes one unit per key with a source value, holding the current translation of
+// the target language in any workflow state. Deprecated keys are left out, so vendors are not
+// paid to translate them. Each unit records the revision of its target, which the import uses
+// to detect conflicting edits.
+func xliffExport(req model.XliffExportRequest) (model.ExportFile, error) {
+	source, err := xliffLanguage(req.Source)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+	target, err := languageStore.GetByPrefix(req.Target)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+	if req.Version == "" {
+		req.Version = xliff.Version12
+	}
+
+	doc := xliff.Document{Version: req.Version, SourceLanguage: source.Prefix, TargetLanguage: target.Prefix, Original: "messages"}
+	keys := languageKeyStore.List()
+	sortKeys(keys)
+	for _, k := range keys {
+		if k.Deprecated {
+			continue
+		}
+		sv, err := languageValueStore.GetByLanguageKey(source.Uuid, k.Uuid)
+		if err != nil {
+			continue
+		}
+		u := xliff.Unit{ID: k.Value, Source: sv.Value, State: xliff.StateInitial}
+		tv, err := languageValueStore.GetByLanguageKey(target.Uuid, k.Uuid)
+		if err == nil {
+			u.Target, u.State, u.Revision = tv.Value, xliffState(tv), tv.Revision
+		}
+		u.Notes = xliffNotes(k, tv)
+		doc.Units = append(doc.Units, u)
+	}
+
+	data, err := xliff.Write(doc)
+	if err != nil {
+		return model.ExportFile{}, err
+	}
+	return model.ExportFile{Path: fmt.Sprintf("%s_%s.xlf", source.Prefix, target.Prefix), Data: data}, nil
+}
+
+// xliffImport applies the targets of an XLIFF file that differ from the stored ones. Units of
+// unknown keys and units that are not translated are skipped. A translated target equal to a
+// stored one that needs review confirms it. A target is not applied, but reported as a
+// conflict, when its source text is no longer the stored one or when the stored target
+// changed since the file was exported.
+func xliffImport(req model.ImportRequest) (model.ImportResult, []batchChange, error) {
+	doc, err := xliff.Parse(req.Data)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	prefix := req.Prefix
+	switch {
+	case prefix == "":
+		prefix = doc.TargetLanguage
+	case doc.TargetLanguage != "" && doc.TargetLanguage != prefix:
+		return model.ImportResult{}, nil, fmt.Errorf("file translates to %q, not %q", doc.TargetLanguage, prefix)
+	}
+	target, err := languageStore.GetByPrefix(prefix)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	source, err := xliffLanguage(doc.SourceLanguage)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+
+	texts := make(map[string]string)
+	reviewed := make(map[string]bool)
+	var skipped []string
+	var conflicts []model.ImportConflict
+	for _, u := range doc.Units {
+		k, err := languageKeyStore.GetByValue(u.ID)
+		if err != nil || u.Target == "" || u.State == xliff.StateInitial {
+			skipped = append(skipped, u.ID)
+			continue
+		}
+		current, err := languageValueStore.GetByLanguageKey(target.Uuid, k.Uuid)
+		exists := err == nil
+		unchanged := exists && icu.Equal(current.Value, u.Target)
+		if unchanged && !current.NeedsReview {
+			continue
+		}
+
+		conflict := model.ImportConflict{Key: u.ID, Current: current.Value, Imported: u.Target}
+		sv, err := languageValueStore.GetByLanguageKey(source.Uuid, k.Uuid)
+		switch {
+		case err != nil || !icu.Equal(sv.Value, u.Source):
+			conflict.Reason = "source changed since export"
+		case exists && current.Revision != u.Revision:
+			conflict.Reason = "target changed since export"
+		case !exists && u.Revision != 0:
+			conflict.Reason = "target deleted since export"
+		default:
+			texts[u.ID] = u.Target
+			reviewed[u.ID] = unchanged
+			continue
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	result, changes, err := importTexts(target, texts, reviewed, req.DryRun)
+	if err != nil {
+		return model.ImportResult{}, nil, err
+	}
+	result.Skipped, result.Conflicts = skipped, conflicts
+	return result, changes, nil
+}
@@ -0,0 +1,79 @@
+package util
+
+import (
+	"unicode"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+// Diff computes a word-level diff turning a into b. Whitespace runs are kept as
+// their own tokens so that joining the segments of either side restores the input.
+func Diff(a, b string) []model.DiffSegment {
+	at := diffTokens(a)
+	bt := diffTokens(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of at[i:] and bt[j:]
+	lcs := make([][]int, len(at)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bt)+1)
+	}
+	for i := len(at) - 1; i >= 0; i-- {
+		for j := len(bt) - 1; j >= 0; j-- {
+			if at[i] == bt[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	out := make([]model.DiffSegment, 0)
+	push := func(op, text string) {
+		if n := len(out); n > 0 && out[n-1].Operation == op {
+			out[n-1].Text += text
+			return
+		}
+		out = append(out, model.DiffSegment{Operation: op, Text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(at) && j < len(bt) {
+		switch {
+		case at[i] == bt[j]:
+			push(model.DiffEqual, at[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			push(model.DiffDelete, at[i])
+			i++
+		default:
+			push(model.DiffInsert, bt[j])
+			j++
+		}
+	}
+	for ; i < len(at); i++ {
+		push(model.DiffDelete, at[i])
+	}
+	for ; j < len(bt); j++ {
+		push(model.DiffInsert, bt[j])
+	}
+	return out
+}
+
+func diffTokens(s string) []string {
+	var tokens []string
+	start := 0
+	var inSpace bool
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			tokens = append(tokens, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
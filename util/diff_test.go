@@ -0,0 +1,57 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rah-0/meisterwerk/model"
+)
+
+func TestDiff(t *testing.T) {
+	a := "Quote confirmed today"
+	b := "Quote accepted today at noon"
+
+	segments := Diff(a, b)
+
+	var before, after strings.Builder
+	for _, s := range segments {
+		if s.Operation != model.DiffInsert {
+			before.WriteString(s.Text)
+		}
+		if s.Operation != model.DiffDelete {
+			after.WriteString(s.Text)
+		}
+	}
+	if before.String() != a {
+		t.Errorf("old side mismatch: got %q, want %q", before.String(), a)
+	}
+	if after.String() != b {
+		t.Errorf("new side mismatch: got %q, want %q", after.String(), b)
+	}
+
+	want := []model.DiffSegment{
+		{Operation: model.DiffEqual, Text: "Quote "},
+		{Operation: model.DiffDelete, Text: "confirmed"},
+		{Operation: model.DiffInsert, Text: "accepted"},
+		{Operation: model.DiffEqual, Text: " today"},
+		{Operation: model.DiffInsert, Text: " at noon"},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("expected %d segments, got %d: %+v", len(want), len(segments), segments)
+	}
+	for i := range want {
+		if segments[i] != want[i] {
+			t.Errorf("segment %d: got %+v, want %+v", i, segments[i], want[i])
+		}
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	segments := Diff("Hallo Welt", "Hallo Welt")
+	if len(segments) != 1 || segments[0].Operation != model.DiffEqual {
+		t.Errorf("expected single equal segment, got %+v", segments)
+	}
+	if len(Diff("", "")) != 0 {
+		t.Error("expected no segments for empty inputs")
+	}
+}
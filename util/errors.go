@@ -0,0 +1,11 @@
+package util
+
+// ConflictError is returned when a write names an expected revision that no longer matches
+// the stored record. Current carries the stored record so the caller can merge and retry.
+type ConflictError struct {
+	Current any
+}
+
+func (e *ConflictError) Error() string {
+	return "revision conflict"
+}
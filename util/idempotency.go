@@ -0,0 +1,55 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// IdempotencyCache remembers encoded responses by idempotency key for a fixed window.
+type IdempotencyCache struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]idempotencyEntry
+}
+
+type idempotencyEntry struct {
+	response []byte
+	expires  time.Time
+}
+
+func NewIdempotencyCache(window time.Duration) *IdempotencyCache {
+	return &IdempotencyCache{
+		window:  window,
+		entries: make(map[string]idempotencyEntry),
+	}
+}
+
+// Get returns the response stored for key, if it has not expired yet.
+func (c *IdempotencyCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.response, true
+}
+
+// Put stores response under key and drops entries whose window has passed.
+func (c *IdempotencyCache) Put(key string, response []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = idempotencyEntry{response: response, expires: now.Add(c.window)}
+}
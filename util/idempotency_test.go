@@ -0,0 +1,36 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	cache := NewIdempotencyCache(time.Minute)
+
+	if _, ok := cache.Get("k1"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+
+	cache.Put("k1", []byte("first"))
+	got, ok := cache.Get("k1")
+	if !ok || string(got) != "first" {
+		t.Errorf("expected stored response, got %q (%v)", got, ok)
+	}
+}
+
+func TestIdempotencyCache_Expires(t *testing.T) {
+	cache := NewIdempotencyCache(5 * time.Millisecond)
+
+	cache.Put("k1", []byte("first"))
+	time.Sleep(10 * time.Millisecond)
+
+	if _, ok := cache.Get("k1"); ok {
+		t.Error("expected entry to expire after the window")
+	}
+
+	cache.Put("k2", []byte("second"))
+	if len(cache.entries) != 1 {
+		t.Errorf("expected expired entries to be purged on Put, got %d entries", len(cache.entries))
+	}
+}
@@ -1,12 +1,19 @@
 package util
 
 import (
+	"errors"
+
 	"github.com/nats-io/nats.go"
 	"github.com/rah-0/nabu"
 
 	"github.com/rah-0/meisterwerk/model"
 )
 
+const (
+	NatsHeaderActor          = "Meisterwerk-Actor" // Identifies who issued a request
+	NatsHeaderIdempotencyKey = "Idempotency-Key"   // Lets a retried request replay the original response
+)
+
 func init() {
 	model.PreloadGob(NatsResponse{})
 }
@@ -18,11 +25,21 @@ type NatsResponse struct {
 }
 
 func NatsRespondWith(nc *nats.Msg, payload any, err error) {
+	natsRespond(nc, natsEncodeResponse(payload, err))
+}
+
+// natsEncodeResponse wraps payload and err in a NatsResponse and returns a copy of its encoding.
+func natsEncodeResponse(payload any, err error) []byte {
 	model.BufferReset()
 
 	resp := NatsResponse{}
 
-	if err != nil {
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		resp.Status = 409
+		resp.Error = err.Error()
+		resp.Data = conflict.Current
+	} else if err != nil {
 		resp.Status = 500
 		resp.Error = err.Error()
 	} else {
@@ -35,12 +52,24 @@ func NatsRespondWith(nc *nats.Msg, payload any, err error) {
 		resp.Error = err.Error()
 	}
 
-	if err := nc.Respond(model.GetBytes()); err != nil {
+	return append([]byte(nil), model.GetBytes()...)
+}
+
+func natsRespond(nc *nats.Msg, data []byte) {
+	if err := nc.Respond(data); err != nil {
 		nabu.FromError(err).Log()
 	}
 }
 
 func NatsBindHandler[T any](nc *nats.Conn, subject string, handler func(req T) (any, error)) error {
+	return NatsBindMsgHandler(nc, subject, func(_ *nats.Msg, req T) (any, error) {
+		return handler(req)
+	})
+}
+
+// NatsBindMsgHandler is like NatsBindHandler but also hands the raw message to the handler,
+// for handlers that need to inspect headers.
+func NatsBindMsgHandler[T any](nc *nats.Conn, subject string, handler func(msg *nats.Msg, req T) (any, error)) error {
 	_, err := nc.Subscribe(subject, func(msg *nats.Msg) {
 		var req T
 
@@ -52,8 +81,56 @@ func NatsBindHandler[T any](nc *nats.Conn, subject string, handler func(req T) (
 			}
 		}
 
-		resp, err := handler(req)
+		resp, err := handler(msg, req)
 		NatsRespondWith(msg, resp, err)
 	})
 	return err
 }
+
+// NatsBindIdempotentHandler is like NatsBindMsgHandler, but when a request carries an
+// Idempotency-Key header the response is remembered in cache and replayed verbatim for any
+// retry with the same key on the same subject, without running the handler again.
+func NatsBindIdempotentHandler[T any](nc *nats.Conn, subject string, cache *IdempotencyCache, handler func(msg *nats.Msg, req T) (any, error)) error {
+	_, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+		key := ""
+		if msg.Header != nil {
+			if k := msg.Header.Get(NatsHeaderIdempotencyKey); k != "" {
+				key = subject + "|" + k
+			}
+		}
+		if key != "" {
+			if data, ok := cache.Get(key); ok {
+				natsRespond(msg, data)
+				return
+			}
+		}
+
+		var req T
+
+		if len(msg.Data) > 0 {
+			model.SetBytes(msg.Data)
+			if err := model.Decode(&req); err != nil {
+				NatsRespondWith(msg, nil, err)
+				return
+			}
+		}
+
+		resp, err := handler(msg, req)
+		data := natsEncodeResponse(resp, err)
+		if key != "" {
+			cache.Put(key, data)
+		}
+		natsRespond(msg, data)
+	})
+	return err
+}
+
+// NatsActor returns the actor named in the request headers, or "anonymous" when none was sent.
+func NatsActor(msg *nats.Msg) string {
+	if msg != nil && msg.Header != nil {
+		if actor := msg.Header.Get(NatsHeaderActor); actor != "" {
+			return actor
+		}
+	}
+	return "anonymous"
+}
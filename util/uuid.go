@@ -0,0 +1,27 @@
+package util
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// UuidEnsure returns id unchanged when it is a well-formed UUID, or a new time-ordered
+// (v7) UUID when id is empty.
+func UuidEnsure(id string) (string, error) {
+	if id == "" {
+		u, err := uuid.NewV7()
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	}
+
+	if len(id) != 36 {
+		return "", errors.New("malformed uuid")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", errors.New("malformed uuid")
+	}
+	return id, nil
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUuidEnsure(t *testing.T) {
+	id, err := UuidEnsure("")
+	if err != nil {
+		t.Fatalf("UuidEnsure failed: %v", err)
+	}
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		t.Fatalf("generated uuid does not parse: %v", err)
+	}
+	if parsed.Version() != 7 {
+		t.Errorf("expected v7 uuid, got v%d", parsed.Version())
+	}
+
+	existing := uuid.NewString()
+	if got, err := UuidEnsure(existing); err != nil || got != existing {
+		t.Errorf("expected %s to be kept, got %s (%v)", existing, got, err)
+	}
+
+	for _, bad := range []string{"nonexistent-id", "1234", "{" + existing + "}", "urn:uuid:" + existing} {
+		if _, err := UuidEnsure(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+// Package xliff reads and writes XLIFF 1.2 and 2.0 documents of one source/target language pair.
+package xliff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	Version12 = "1.2"
+	Version20 = "2.0"
+
+	namespace12 = "urn:oasis:names:tc:xliff:document:1.2"
+	namespace20 = "urn:oasis:names:tc:xliff:document:2.0"
+
+	// Namespace of the extension attributes written on units, e.g., mw:revision.
+	Namespace = "https://github.com/rah-0/meisterwerk"
+	prefix    = "mw"
+)
+
+// Translation states, named as in XLIFF 2.0. XLIFF 1.2 files use the state values mapped in
+// states12.
+const (
+	StateInitial    = "initial"    // Not translated yet, or the translation has to be redone
+	StateTranslated = "translated" // Translated, not reviewed yet
+	StateReviewed   = "reviewed"   // Reviewed, not final yet
+	StateFinal      = "final"      // Done
+)
+
+var states12 = map[string]string{
+	StateInitial:    "needs-translation",
+	StateTranslated: "translated",
+	StateReviewed:   "signed-off",
+	StateFinal:      "final",
+}
+
+// Document is the content of an XLIFF file.
+type Document struct {
+	Version        string // Version12 or Version20
+	SourceLanguage string // e.g., "en-US"
+	TargetLanguage string // e.g., "de-DE"
+	Original       string // Name of the resource the units come from, e.g., "messages"
+	Units          []Unit
+}
+
+// Unit is one translatable text.
+type Unit struct {
+	ID       string // Key string, e.g., "checkout.title"
+	Source   string
+	Target   string // Empty when not translated
+	State    string // One of the State constants
+	Notes    []Note
+	Revision int // Revision of the target when exported, written as mw:revision; 0 when unknown
+}
+
+// Note is a remark for the translator.
+type Note struct {
+	Category string // e.g., "description"; written as "from" in XLIFF 1.2
+	Text     string
+}
+
+// text collects the character data of an element and of any inline elements it contains.
+type text string
+
+func (t *text) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var b strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			b.Write(tok)
+		}
+	}
+	*t = text(b.String())
+	return nil
+}
+
+type document12 struct {
+	XMLName   xml.Name `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version   string   `xml:"version,attr"`
+	Extension string   `xml:"xmlns:mw,attr,omitempty"`
+	Files     []file12 `xml:"file"`
+}
+
+type file12 struct {
+	Original       string   `xml:"original,attr"`
+	SourceLanguage string   `xml:"source-language,attr"`
+	TargetLanguage string   `xml:"target-language,attr,omitempty"`
+	Datatype       string   `xml:"datatype,attr"`
+	Units          []unit12 `xml:"body>trans-unit"`
+}
+
+type unit12 struct {
+	ID     string     `xml:"id,attr"`
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Source text       `xml:"source"`
+	Target *target12  `xml:"target"`
+	Notes  []note12   `xml:"note"`
+}
+
+type target12 struct {
+	State string
+	Text  text
+}
+
+func (t *target12) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		if a.Name.Local == "state" {
+			t.State = a.Value
+		}
+	}
+	return t.Text.UnmarshalXML(d, start)
+}
+
+func (t target12) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if t.State != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "state"}, Value: t.State})
+	}
+	return e.EncodeElement(string(t.Text), start)
+}
+
+type note12 struct {
+	From string `xml:"from,attr,omitempty"`
+	Text string `xml:",chardata"`
+}
+
+type document20 struct {
+	XMLName        xml.Name `xml:"urn:oasis:names:tc:xliff:document:2.0 xliff"`
+	Version        string   `xml:"version,attr"`
+	SourceLanguage string   `xml:"srcLang,attr"`
+	TargetLanguage string   `xml:"trgLang,attr,omitempty"`
+	Extension      string   `xml:"xmlns:mw,attr,omitempty"`
+	Files          []file20 `xml:"file"`
+}
+
+type file20 struct {
+	ID       string   `xml:"id,attr"`
+	Original string   `xml:"original,attr,omitempty"`
+	Units    []unit20 `xml:"unit"`
+}
+
+type unit20 struct {
+	ID       string      `xml:"id,attr"`
+	Attrs    []xml.Attr  `xml:",any,attr"`
+	Notes    *notes20    `xml:"notes"`
+	Segments []segment20 `xml:"segment"`
+}
+
+type notes20 struct {
+	Notes []note20 `xml:"note"`
+}
+
+type note20 struct {
+	Category string `xml:"category,attr,omitempty"`
+	Text     string `xml:",chardata"`
+}
+
+type segment20 struct {
+	State  string `xml:"state,attr,omitempty"`
+	Source text   `xml:"source"`
+	Target *text  `xml:"target"`
+}
+
+// Write renders doc as XLIFF of doc.Version.
+func Write(doc Document) ([]byte, error) {
+	var v any
+	switch doc.Version {
+	case Version12:
+		v = write12(doc)
+	case Version20:
+		v = write20(doc)
+	default:
+		return nil, fmt.Errorf("xliff: unsupported version %q", doc.Version)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("xliff: %w", err)
+	}
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+// revisionAttrs returns the extension attributes of u.
+func revisionAttrs(u Unit) []xml.Attr {
+	if u.Revision == 0 {
+		return nil
+	}
+	return []xml.Attr{{Name: xml.Name{Local: prefix + ":revision"}, Value: strconv.Itoa(u.Revision)}}
+}
+
+// revision reads the mw:revision attribute, 0 when there is none.
+func revision(attrs []xml.Attr) int {
+	for _, a := range attrs {
+		if a.Name.Local == "revision" && (a.Name.Space == Namespace || a.Name.Space == prefix) {
+			n, _ := strconv.Atoi(a.Value)
+			return n
+		}
+	}
+	return 0
+}
+
+func write12(doc Document) document12 {
+	f := file12{Original: doc.Original, SourceLanguage: doc.SourceLanguage, TargetLanguage: doc.TargetLanguage, Datatype: "plaintext"}
+	for _, u := range doc.Units {
+		w := unit12{ID: u.ID, Attrs: revisionAttrs(u), Source: text(u.Source)}
+		if u.Target != "" || u.State != "" {
+			w.Target = &target12{State: states12[u.State], Text: text(u.Target)}
+		}
+		for _, n := range u.Notes {
+			w.Notes = append(w.Notes, note12{From: n.Category, Text: n.Text})
+		}
+		f.Units = append(f.Units, w)
+	}
+	return document12{Version: Version12, Extension: Namespace, Files: []file12{f}}
+}
+
+func write20(doc Document) document20 {
+	f := file20{ID: "f1", Original: doc.Original}
+	for _, u := range doc.Units {
+		w := unit20{ID: u.ID, Attrs: revisionAttrs(u)}
+		if len(u.Notes) > 0 {
+			w.Notes = &notes20{}
+			for _, n := range u.Notes {
+				w.Notes.Notes = append(w.Notes.Notes, note20{Category: n.Category, Text: n.Text})
+			}
+		}
+		seg := segment20{State: u.State, Source: text(u.Source)}
+		if u.Target != "" {
+			t := text(u.Target)
+			seg.Target = &t
+		}
+		w.Segments = append(w.Segments, seg)
+		f.Units = append(f.Units, w)
+	}
+	return document20{
+		Version:        Version20,
+		SourceLanguage: doc.SourceLanguage,
+		TargetLanguage: doc.TargetLanguage,
+		Extension:      Namespace,
+		Files:          []file20{f},
+	}
+}
+
+// Parse reads an XLIFF 1.2 or 2.0 document. Units of every file are returned in document order;
+// the languages and original come from the first file.
+func Parse(data []byte) (Document, error) {
+	version, err := detectVersion(data)
+	if err != nil {
+		return Document{}, err
+	}
+	switch version {
+	case Version12:
+		var in document12
+		if err := xml.Unmarshal(data, &in); err != nil {
+			return Document{}, fmt.Errorf("xliff: %w", err)
+		}
+		return parse12(in), nil
+	default:
+		var in document20
+		if err := xml.Unmarshal(data, &in); err != nil {
+			return Document{}, fmt.Errorf("xliff: %w", err)
+		}
+		return parse20(in), nil
+	}
+}
+
+// detectVersion tells the version from the namespace of the root element.
+func detectVersion(data []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", fmt.Errorf("xliff: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			switch {
+			case start.Name.Local != "xliff":
+				return "", errors.New("xliff: root element is not <xliff>")
+			case start.Name.Space == namespace12:
+				return Version12, nil
+			case start.Name.Space == namespace20:
+				return Version20, nil
+			}
+			return "", fmt.Errorf("xliff: unsupported namespace %q", start.Name.Space)
+		}
+	}
+}
+
+func parse12(in document12) Document {
+	doc := Document{Version: Version12}
+	for i, f := range in.Files {
+		if i == 0 {
+			doc.Original, doc.SourceLanguage, doc.TargetLanguage = f.Original, f.SourceLanguage, f.TargetLanguage
+		}
+		for _, r := range f.Units {
+			u := Unit{ID: r.ID, Source: string(r.Source), State: StateInitial, Revision: revision(r.Attrs)}
+			if r.Target != nil {
+				u.Target = string(r.Target.Text)
+				u.State = state12(r.Target.State, u.Target != "")
+			}
+			for _, n := range r.Notes {
+				u.Notes = append(u.Notes, Note{Category: n.From, Text: n.Text})
+			}
+			doc.Units = append(doc.Units, u)
+		}
+	}
+	return doc
+}
+
+// state12 maps an XLIFF 1.2 state to the XLIFF 2.0 one, the way the XLIFF 2.0 specification
+// suggests: the needs-review states still hold a translation.
+func state12(state string, translated bool) string {
+	switch {
+	case state == "final":
+		return StateFinal
+	case state == "signed-off":
+		return StateReviewed
+	case state == "translated", strings.HasPrefix(state, "needs-review"):
+		return StateTranslated
+	case state == "" && translated:
+		return StateTranslated
+	}
+	return StateInitial
+}
+
+func parse20(in document20) Document {
+	doc := Document{Version: Version20, SourceLanguage: in.SourceLanguage, TargetLanguage: in.TargetLanguage}
+	for i, f := range in.Files {
+		if i == 0 {
+			doc.Original = f.Original
+		}
+		for _, r := range f.Units {
+			u := Unit{ID: r.ID, Revision: revision(r.Attrs)}
+			var source, target strings.Builder
+			for j, s := range r.Segments {
+				source.WriteString(string(s.Source))
+				if s.Target != nil {
+					target.WriteString(string(*s.Target))
+				}
+				if j == 0 {
+					u.State = s.State
+				}
+			}
+			u.Source, u.Target = source.String(), target.String()
+			if u.State == "" {
+				u.State = StateInitial
+				if u.Target != "" {
+					u.State = StateTranslated
+				}
+			}
+			if r.Notes != nil {
+				for _, n := range r.Notes.Notes {
+					u.Notes = append(u.Notes, Note{Category: n.Category, Text: n.Text})
+				}
+			}
+			doc.Units = append(doc.Units, u)
+		}
+	}
+	return doc
+}
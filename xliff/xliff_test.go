@@ -0,0 +1,101 @@
+package xliff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteAndParse(t *testing.T) {
+	doc := Document{
+		SourceLanguage: "en-US",
+		TargetLanguage: "de-DE",
+		Original:       "messages",
+		Units: []Unit{
+			{
+				ID:       "checkout.greeting",
+				Source:   "Hello <b>{name}</b> & welcome",
+				Target:   "Hallo <b>{name}</b> & willkommen",
+				State:    StateReviewed,
+				Notes:    []Note{{Category: "description", Text: "Greeting on top"}},
+				Revision: 3,
+			},
+			{ID: "checkout.title", Source: "Checkout", State: StateInitial},
+		},
+	}
+
+	for version, contains := range map[string][]string{
+		Version12: {
+			`<trans-unit id="checkout.greeting" mw:revision="3">`,
+			`<source>Hello &lt;b&gt;{name}&lt;/b&gt; &amp; welcome</source>`,
+			`<target state="signed-off">`,
+			`<note from="description">Greeting on top</note>`,
+		},
+		Version20: {
+			`srcLang="en-US" trgLang="de-DE"`,
+			`<unit id="checkout.greeting" mw:revision="3">`,
+			`<note category="description">Greeting on top</note>`,
+			`<segment state="reviewed">`,
+		},
+	} {
+		doc.Version = version
+		data, err := Write(doc)
+		if err != nil {
+			t.Fatalf("Write %s failed: %v", version, err)
+		}
+		for _, s := range contains {
+			if !strings.Contains(string(data), s) {
+				t.Errorf("Expected XLIFF %s to contain %s, got\n%s", version, s, data)
+			}
+		}
+
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse %s failed: %v", version, err)
+		}
+		if !reflect.DeepEqual(parsed, doc) {
+			t.Errorf("Parse %s =\n%+v\nwant\n%+v", version, parsed, doc)
+		}
+	}
+
+	if _, err := Write(Document{Version: "1.1"}); err == nil {
+		t.Error("Expected unsupported version to fail")
+	}
+}
+
+func TestParse12(t *testing.T) {
+	doc, err := Parse([]byte(`<?xml version="1.0"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2" xmlns:x="https://github.com/rah-0/meisterwerk">
+  <file original="a" source-language="en" target-language="fr" datatype="plaintext">
+    <body>
+      <trans-unit id="one" x:revision="7">
+        <source>Hi <g id="1">there</g></source>
+        <target state="needs-review-translation">Salut <g id="1">toi</g></target>
+      </trans-unit>
+      <trans-unit id="two"><source>Two</source><target>Deux</target></trans-unit>
+      <trans-unit id="three"><source>Three</source><target state="new"></target></trans-unit>
+    </body>
+  </file>
+  <file original="b" source-language="en" datatype="plaintext">
+    <body><trans-unit id="four"><source>Four</source></trans-unit></body>
+  </file>
+</xliff>`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	want := []Unit{
+		{ID: "one", Source: "Hi there", Target: "Salut toi", State: StateTranslated, Revision: 7},
+		{ID: "two", Source: "Two", Target: "Deux", State: StateTranslated},
+		{ID: "three", Source: "Three", State: StateInitial},
+		{ID: "four", Source: "Four", State: StateInitial},
+	}
+	if doc.Original != "a" || doc.TargetLanguage != "fr" || !reflect.DeepEqual(doc.Units, want) {
+		t.Errorf("Parse = %+v", doc)
+	}
+
+	for _, bad := range []string{`<xliff version="1.2">`, `<foo xmlns="urn:oasis:names:tc:xliff:document:1.2"/>`, `not xml`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}